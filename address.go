@@ -127,12 +127,81 @@ type Address interface {
 	IsForNet(*chaincfg.Params) bool
 }
 
+// AddressEqual returns whether the two addresses are the same type of address
+// for the same network and pay to the same script address.  Unlike comparing
+// the results of EncodeAddress, it does not allocate for the address types
+// defined by this package.  Addresses of other types are considered equal
+// when both their encodings and script addresses are equal.
+//
+// A nil pointer to one of the address types defined by this package is only
+// equal to a nil pointer of the same type.
+func AddressEqual(a, b Address) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	switch a := a.(type) {
+	case *AddressPubKeyHash:
+		b, ok := b.(*AddressPubKeyHash)
+		if !ok || a == nil || b == nil {
+			return ok && a == b
+		}
+		return a.netID == b.netID && a.hash == b.hash
+
+	case *AddressScriptHash:
+		b, ok := b.(*AddressScriptHash)
+		if !ok || a == nil || b == nil {
+			return ok && a == b
+		}
+		return a.netID == b.netID && a.hash == b.hash
+
+	case *AddressPubKey:
+		b, ok := b.(*AddressPubKey)
+		if !ok || a == nil || b == nil {
+			return ok && a == b
+		}
+		return a.pubKeyHashID == b.pubKeyHashID &&
+			a.pubKeyFormat == b.pubKeyFormat &&
+			a.pubKey.IsEqual(b.pubKey)
+
+	case *AddressWitnessPubKeyHash:
+		b, ok := b.(*AddressWitnessPubKeyHash)
+		if !ok || a == nil || b == nil {
+			return ok && a == b
+		}
+		return a.hrp == b.hrp &&
+			a.witnessVersion == b.witnessVersion &&
+			a.witnessProgram == b.witnessProgram
+
+	case *AddressWitnessScriptHash:
+		b, ok := b.(*AddressWitnessScriptHash)
+		if !ok || a == nil || b == nil {
+			return ok && a == b
+		}
+		return a.hrp == b.hrp &&
+			a.witnessVersion == b.witnessVersion &&
+			a.witnessProgram == b.witnessProgram
+	}
+
+	switch b.(type) {
+	case *AddressPubKeyHash, *AddressScriptHash, *AddressPubKey,
+		*AddressWitnessPubKeyHash, *AddressWitnessScriptHash:
+		return false
+	}
+	return a.EncodeAddress() == b.EncodeAddress() &&
+		bytes.Equal(a.ScriptAddress(), b.ScriptAddress())
+}
+
 // DecodeAddress decodes the string encoding of an address and returns
 // the Address if addr is a valid encoding for a known address type.
 //
 // The bitcoin network the address is associated with is extracted if possible.
 // When the address does not encode the network, such as in the case of a raw
 // public key, the address will be associated with the passed defaultNet.
+// The address IDs of defaultNet are tried before those of the other
+// registered networks, so an ID shared by multiple networks is interpreted
+// as defaultNet intends.  Like those of any other network, the IDs of
+// defaultNet are only accepted once registered with chaincfg.Register.
 func DecodeAddress(addr string, defaultNet *chaincfg.Params) (Address, error) {
 	// Bech32 encoded segwit addresses start with a human-readable part
 	// (hrp) followed by '1'. For Bitcoin mainnet the hrp is "bc", and for
@@ -190,6 +259,17 @@ func DecodeAddress(addr string, defaultNet *chaincfg.Params) (Address, error) {
 	case ripemd160.Size: // P2PKH or P2SH
 		isP2PKH := chaincfg.IsPubKeyHashAddrID(netID)
 		isP2SH := chaincfg.IsScriptHashAddrID(netID)
+
+		// Prefer the address IDs of the provided network.
+		if defaultNet != nil {
+			switch {
+			case isP2PKH && netID == defaultNet.PubKeyHashAddrID:
+				return newAddressPubKeyHash(decoded, netID)
+			case isP2SH && netID == defaultNet.ScriptHashAddrID:
+				return newAddressScriptHashFromHash(decoded, netID)
+			}
+		}
+
 		switch hash160 := decoded; {
 		case isP2PKH && isP2SH:
 			return nil, ErrAddressCollision
@@ -303,6 +383,42 @@ func (a *AddressPubKeyHash) String() string {
 	return a.EncodeAddress()
 }
 
+// HasPrefix returns whether the string encoding of the pay-to-pubkey-hash
+// address begins with prefix, which is equivalent to calling strings.HasPrefix
+// with the result of EncodeAddress.  It is intended for searching for vanity
+// addresses.
+//
+// Each leading zero byte of the encoded network identifier and hash is encoded
+// as a '1', and the character which follows them never is, so a prefix which
+// does not begin with exactly that many '1's is rejected without encoding the
+// address.
+func (a *AddressPubKeyHash) HasPrefix(prefix string) bool {
+	// Count the leading zero bytes before the checksum.  The fast path is
+	// skipped when all of them are zero since any leading zero bytes of
+	// the checksum are then encoded as '1' as well.
+	zeros := 0
+	if a.netID == 0 {
+		zeros = 1
+		for zeros <= len(a.hash) && a.hash[zeros-1] == 0 {
+			zeros++
+		}
+	}
+	if zeros <= len(a.hash) {
+		ones := 0
+		for ones < len(prefix) && prefix[ones] == '1' {
+			ones++
+		}
+		if ones > zeros || (ones < zeros && ones < len(prefix)) {
+			return false
+		}
+		if ones == len(prefix) {
+			return true
+		}
+	}
+
+	return strings.HasPrefix(a.EncodeAddress(), prefix)
+}
+
 // Hash160 returns the underlying array of the pubkey hash.  This can be useful
 // when an array is more appropiate than a slice (for example, when used as map
 // keys).
@@ -484,7 +600,10 @@ func (a *AddressPubKey) Format() PubKeyFormat {
 }
 
 // SetFormat sets the format (uncompressed, compressed, etc) of the
-// pay-to-pubkey address.
+// pay-to-pubkey address.  Only the serialization of the public key is
+// affected, the underlying public key is unchanged.  Since EncodeAddress,
+// ScriptAddress, String, and AddressPubKeyHash all operate on the serialized
+// public key, their results will change accordingly.
 func (a *AddressPubKey) SetFormat(pkFormat PubKeyFormat) {
 	a.pubKeyFormat = pkFormat
 }
@@ -803,3 +803,451 @@ func TestAddresses(t *testing.T) {
 		}
 	}
 }
+
+// TestAddressPubKeyFormat ensures changing the format of a pay-to-pubkey
+// address changes the serialization and derived pay-to-pubkey-hash address
+// accordingly.
+func TestAddressPubKeyFormat(t *testing.T) {
+	// The public key from the genesis block coinbase output.
+	serializedPubKey, _ := hex.DecodeString("04678afdb0fe5548271967f1a6713" +
+		"0b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51e" +
+		"c112de5c384df7ba0b8d578a4c702b6bf11d5f")
+	addr, err := btcutil.NewAddressPubKey(serializedPubKey,
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("NewAddressPubKey: unexpected error: %v", err)
+	}
+	if addr.Format() != btcutil.PKFUncompressed {
+		t.Fatalf("Format: got %v, want %v", addr.Format(),
+			btcutil.PKFUncompressed)
+	}
+
+	tests := []struct {
+		name    string
+		format  btcutil.PubKeyFormat
+		encoded string
+		hash160 string
+		prefix  byte
+	}{
+		{
+			name:    "uncompressed",
+			format:  btcutil.PKFUncompressed,
+			encoded: "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa",
+			hash160: "62e907b15cbf27d5425399ebf6f0fb50ebb88f18",
+			prefix:  0x04,
+		},
+		{
+			name:    "compressed",
+			format:  btcutil.PKFCompressed,
+			encoded: "1FYPDCP1uVnPgEE3gaDMbAApdv9XYX7Si5",
+			hash160: "9f81322cc88622ca4ccb2a52a21e2888727aa535",
+			prefix:  0x03,
+		},
+		{
+			name:    "hybrid",
+			format:  btcutil.PKFHybrid,
+			encoded: "18RtxZe1S3MBYgJ3heu6bBjjvrZiCJGJUr",
+			hash160: "517e3c6448dae41cd4064748436d5c79e7d58e94",
+			prefix:  0x07,
+		},
+	}
+
+	for _, test := range tests {
+		addr.SetFormat(test.format)
+		if addr.Format() != test.format {
+			t.Errorf("%s: Format: got %v, want %v", test.name,
+				addr.Format(), test.format)
+			continue
+		}
+
+		if got := addr.EncodeAddress(); got != test.encoded {
+			t.Errorf("%s: EncodeAddress: got %s, want %s", test.name,
+				got, test.encoded)
+			continue
+		}
+
+		pkh := addr.AddressPubKeyHash()
+		if got := pkh.EncodeAddress(); got != test.encoded {
+			t.Errorf("%s: AddressPubKeyHash: got %s, want %s",
+				test.name, got, test.encoded)
+			continue
+		}
+		if got := hex.EncodeToString(pkh.Hash160()[:]); got != test.hash160 {
+			t.Errorf("%s: Hash160: got %s, want %s", test.name, got,
+				test.hash160)
+			continue
+		}
+
+		if got := addr.ScriptAddress()[0]; got != test.prefix {
+			t.Errorf("%s: ScriptAddress prefix: got %#x, want %#x",
+				test.name, got, test.prefix)
+			continue
+		}
+
+		// The underlying public key must not be changed by the format.
+		pubKey := addr.PubKey().SerializeUncompressed()
+		if !bytes.Equal(pubKey, serializedPubKey) {
+			t.Errorf("%s: underlying public key changed", test.name)
+			continue
+		}
+	}
+}
+
+// TestAddressIsForNet ensures the same hash160 decoded under the address IDs
+// of different networks is only associated with the network it was encoded
+// for, and that the address IDs of unregistered networks are rejected.
+func TestAddressIsForNet(t *testing.T) {
+	hash160, _ := hex.DecodeString("e34cce70c86373273efcc54ce7d2a491bb4a0e84")
+	nets := []*chaincfg.Params{
+		&chaincfg.MainNetParams,
+		&chaincfg.TestNet3Params,
+		&chaincfg.SimNetParams,
+	}
+
+	for _, encodeNet := range nets {
+		p2pkh, err := btcutil.NewAddressPubKeyHash(hash160, encodeNet)
+		if err != nil {
+			t.Fatalf("%s: NewAddressPubKeyHash: %v", encodeNet.Name, err)
+		}
+		p2sh, err := btcutil.NewAddressScriptHashFromHash(hash160, encodeNet)
+		if err != nil {
+			t.Fatalf("%s: NewAddressScriptHashFromHash: %v",
+				encodeNet.Name, err)
+		}
+
+		for _, addr := range []btcutil.Address{p2pkh, p2sh} {
+			decoded, err := btcutil.DecodeAddress(addr.EncodeAddress(),
+				encodeNet)
+			if err != nil {
+				t.Errorf("%s: DecodeAddress(%s): %v", encodeNet.Name,
+					addr, err)
+				continue
+			}
+			if reflect.TypeOf(decoded) != reflect.TypeOf(addr) {
+				t.Errorf("%s: DecodeAddress(%s): got type %T, want %T",
+					encodeNet.Name, addr, decoded, addr)
+				continue
+			}
+			if !bytes.Equal(decoded.ScriptAddress(), hash160) {
+				t.Errorf("%s: DecodeAddress(%s): mismatched hash160",
+					encodeNet.Name, addr)
+			}
+			for _, net := range nets {
+				want := net == encodeNet
+				if got := decoded.IsForNet(net); got != want {
+					t.Errorf("%s: %s IsForNet(%s): got %v, want %v",
+						encodeNet.Name, addr, net.Name, got, want)
+				}
+			}
+		}
+	}
+
+	// Regression test shares its address IDs with testnet3.
+	regtest, err := btcutil.NewAddressPubKeyHash(hash160,
+		&chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatalf("NewAddressPubKeyHash: %v", err)
+	}
+	addr, err := btcutil.DecodeAddress(regtest.EncodeAddress(),
+		&chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %v", err)
+	}
+	if !addr.IsForNet(&chaincfg.RegressionNetParams) ||
+		!addr.IsForNet(&chaincfg.TestNet3Params) ||
+		addr.IsForNet(&chaincfg.MainNetParams) {
+		t.Errorf("regtest address associated with the wrong networks")
+	}
+
+	// The address IDs of a network which was never registered must not be
+	// accepted even when it is the default network.
+	customNet := chaincfg.MainNetParams
+	customNet.Name = "unregistered"
+	customNet.PubKeyHashAddrID = 0x1c
+	customNet.ScriptHashAddrID = 0x1d
+	p2pkh, err := btcutil.NewAddressPubKeyHash(hash160, &customNet)
+	if err != nil {
+		t.Fatalf("NewAddressPubKeyHash: %v", err)
+	}
+	p2sh, err := btcutil.NewAddressScriptHashFromHash(hash160, &customNet)
+	if err != nil {
+		t.Fatalf("NewAddressScriptHashFromHash: %v", err)
+	}
+	for _, addr := range []btcutil.Address{p2pkh, p2sh} {
+		_, err := btcutil.DecodeAddress(addr.EncodeAddress(), &customNet)
+		if err != btcutil.ErrUnknownAddressType {
+			t.Errorf("DecodeAddress(%s) for unregistered network: got "+
+				"error %v, want %v", addr, err,
+				btcutil.ErrUnknownAddressType)
+		}
+	}
+}
+
+// TestAddressEqual ensures AddressEqual compares the type, network, and script
+// address of addresses.
+func TestAddressEqual(t *testing.T) {
+	hash160, _ := hex.DecodeString("e34cce70c86373273efcc54ce7d2a491bb4a0e84")
+	otherHash160, _ := hex.DecodeString("0ef030107fd26e0b6bf40512bca2ceb1dd80adaa")
+	hash256 := bytes.Repeat([]byte{0xab}, 32)
+	serializedPubKey, _ := hex.DecodeString("02192d74d0cb94344c9569c2e779015" +
+		"73d8d7903c3ebec3a957724895dca52c6b4")
+
+	mainNet := &chaincfg.MainNetParams
+	testNet := &chaincfg.TestNet3Params
+	mustAddr := func(addr btcutil.Address, err error) btcutil.Address {
+		if err != nil {
+			t.Fatalf("unexpected error creating address: %v", err)
+		}
+		return addr
+	}
+
+	p2pkh := mustAddr(btcutil.NewAddressPubKeyHash(hash160, mainNet))
+	pubKey := mustAddr(btcutil.NewAddressPubKey(serializedPubKey, mainNet))
+	uncompressed := pubKey.(*btcutil.AddressPubKey).PubKey().SerializeUncompressed()
+	p2wsh := mustAddr(btcutil.NewAddressWitnessScriptHash(hash256, mainNet))
+
+	tests := []struct {
+		name string
+		a, b btcutil.Address
+		want bool
+	}{
+		{
+			name: "p2pkh same hash and network",
+			a:    p2pkh,
+			b:    mustAddr(btcutil.DecodeAddress(p2pkh.EncodeAddress(), mainNet)),
+			want: true,
+		},
+		{
+			name: "p2pkh same hash different network",
+			a:    p2pkh,
+			b:    mustAddr(btcutil.NewAddressPubKeyHash(hash160, testNet)),
+			want: false,
+		},
+		{
+			name: "p2pkh different hash",
+			a:    p2pkh,
+			b:    mustAddr(btcutil.NewAddressPubKeyHash(otherHash160, mainNet)),
+			want: false,
+		},
+		{
+			name: "p2pkh and p2sh same hash",
+			a:    p2pkh,
+			b:    mustAddr(btcutil.NewAddressScriptHashFromHash(hash160, mainNet)),
+			want: false,
+		},
+		{
+			name: "p2sh same hash and network",
+			a:    mustAddr(btcutil.NewAddressScriptHashFromHash(hash160, mainNet)),
+			b:    mustAddr(btcutil.NewAddressScriptHashFromHash(hash160, mainNet)),
+			want: true,
+		},
+		{
+			name: "p2sh same hash different network",
+			a:    mustAddr(btcutil.NewAddressScriptHashFromHash(hash160, mainNet)),
+			b:    mustAddr(btcutil.NewAddressScriptHashFromHash(hash160, testNet)),
+			want: false,
+		},
+		{
+			// Regression test shares its address IDs with testnet3,
+			// so the addresses are indistinguishable.
+			name: "p2pkh testnet and regtest",
+			a:    mustAddr(btcutil.NewAddressPubKeyHash(hash160, testNet)),
+			b:    mustAddr(btcutil.NewAddressPubKeyHash(hash160, &chaincfg.RegressionNetParams)),
+			want: true,
+		},
+		{
+			name: "pubkey same key and network",
+			a:    pubKey,
+			b:    mustAddr(btcutil.NewAddressPubKey(serializedPubKey, mainNet)),
+			want: true,
+		},
+		{
+			name: "pubkey same key different network",
+			a:    pubKey,
+			b:    mustAddr(btcutil.NewAddressPubKey(serializedPubKey, testNet)),
+			want: false,
+		},
+		{
+			name: "pubkey compressed and uncompressed",
+			a:    pubKey,
+			b:    mustAddr(btcutil.NewAddressPubKey(uncompressed, mainNet)),
+			want: false,
+		},
+		{
+			name: "pubkey and its p2pkh address",
+			a:    pubKey,
+			b:    pubKey.(*btcutil.AddressPubKey).AddressPubKeyHash(),
+			want: false,
+		},
+		{
+			name: "p2wpkh same program and network",
+			a:    mustAddr(btcutil.NewAddressWitnessPubKeyHash(hash160, mainNet)),
+			b:    mustAddr(btcutil.NewAddressWitnessPubKeyHash(hash160, mainNet)),
+			want: true,
+		},
+		{
+			name: "p2wpkh same program different network",
+			a:    mustAddr(btcutil.NewAddressWitnessPubKeyHash(hash160, mainNet)),
+			b:    mustAddr(btcutil.NewAddressWitnessPubKeyHash(hash160, testNet)),
+			want: false,
+		},
+		{
+			name: "p2wpkh and p2pkh same hash",
+			a:    mustAddr(btcutil.NewAddressWitnessPubKeyHash(hash160, mainNet)),
+			b:    p2pkh,
+			want: false,
+		},
+		{
+			name: "p2wsh same program and network",
+			a:    p2wsh,
+			b:    mustAddr(btcutil.DecodeAddress(p2wsh.EncodeAddress(), mainNet)),
+			want: true,
+		},
+		{
+			name: "p2wsh same program different network",
+			a:    p2wsh,
+			b:    mustAddr(btcutil.NewAddressWitnessScriptHash(hash256, testNet)),
+			want: false,
+		},
+		{
+			name: "nil addresses",
+			want: true,
+		},
+		{
+			name: "nil and p2pkh",
+			b:    p2pkh,
+			want: false,
+		},
+	}
+
+	for _, test := range tests {
+		if got := btcutil.AddressEqual(test.a, test.b); got != test.want {
+			t.Errorf("AddressEqual (%s): got %v, want %v", test.name,
+				got, test.want)
+		}
+		if got := btcutil.AddressEqual(test.b, test.a); got != test.want {
+			t.Errorf("AddressEqual (%s, reversed): got %v, want %v",
+				test.name, got, test.want)
+		}
+		if test.a != nil && test.b != nil && test.want &&
+			test.a.EncodeAddress() != test.b.EncodeAddress() {
+			t.Errorf("AddressEqual (%s): equal addresses have different "+
+				"encodings", test.name)
+		}
+	}
+
+	// Nil pointers of the address types must not be dereferenced, and are
+	// only equal to nil pointers of the same type.
+	nilAddrs := []btcutil.Address{
+		(*btcutil.AddressPubKeyHash)(nil),
+		(*btcutil.AddressScriptHash)(nil),
+		(*btcutil.AddressPubKey)(nil),
+		(*btcutil.AddressWitnessPubKeyHash)(nil),
+		(*btcutil.AddressWitnessScriptHash)(nil),
+	}
+	addrs := []btcutil.Address{
+		p2pkh,
+		mustAddr(btcutil.NewAddressScriptHashFromHash(hash160, mainNet)),
+		pubKey,
+		mustAddr(btcutil.NewAddressWitnessPubKeyHash(hash160, mainNet)),
+		p2wsh,
+	}
+	for i, nilAddr := range nilAddrs {
+		for j, other := range nilAddrs {
+			if got := btcutil.AddressEqual(nilAddr, other); got != (i == j) {
+				t.Errorf("AddressEqual (%T, %T): got %v, want %v",
+					nilAddr, other, got, i == j)
+			}
+		}
+		for _, addr := range append(addrs, nil) {
+			if btcutil.AddressEqual(nilAddr, addr) ||
+				btcutil.AddressEqual(addr, nilAddr) {
+
+				t.Errorf("AddressEqual (%T, %v): got true, want false",
+					nilAddr, addr)
+			}
+		}
+	}
+}
+
+// TestAddressPubKeyHashHasPrefix ensures HasPrefix agrees with comparing the
+// prefix against the string encoding of the address.
+func TestAddressPubKeyHashHasPrefix(t *testing.T) {
+	hash160, _ := hex.DecodeString("e34cce70c86373273efcc54ce7d2a491bb4a0e84")
+	zeroLed, _ := hex.DecodeString("0000ab7f3d2a01c2b3e4f5a6978695a4b3c2d1e0")
+	zeroHash := make([]byte, 20)
+
+	mustAddr := func(pkHash []byte, net *chaincfg.Params) *btcutil.AddressPubKeyHash {
+		addr, err := btcutil.NewAddressPubKeyHash(pkHash, net)
+		if err != nil {
+			t.Fatalf("NewAddressPubKeyHash: unexpected error: %v", err)
+		}
+		return addr
+	}
+	mainAddr := mustAddr(hash160, &chaincfg.MainNetParams)
+	zeroLedAddr := mustAddr(zeroLed, &chaincfg.MainNetParams)
+	zeroAddr := mustAddr(zeroHash, &chaincfg.MainNetParams)
+	testAddr := mustAddr(hash160, &chaincfg.TestNet3Params)
+
+	tests := []struct {
+		addr   *btcutil.AddressPubKeyHash
+		prefix string
+		want   bool
+	}{
+		{mainAddr, "", true},
+		{mainAddr, "1", true},
+		{mainAddr, "1Mir", true},
+		{mainAddr, "1MirQ9bwyQcGVJPwKUgapu5ouK2E2Ey4gX", true},
+		{mainAddr, "1Mix", false},
+		{mainAddr, "11", false},
+		{mainAddr, "1MirQ9bwyQcGVJPwKUgapu5ouK2E2Ey4gXz", false},
+		{mainAddr, "M", false},
+		{zeroLedAddr, "111", true},
+		{zeroLedAddr, "11", true},
+		{zeroLedAddr, "1111", false},
+		{zeroLedAddr, "11A", false},
+		{zeroAddr, "111111111111111111111", true},
+		{testAddr, "n", true},
+		{testAddr, "m", false},
+		{testAddr, "1", false},
+		{testAddr, "", true},
+	}
+
+	for _, test := range tests {
+		encoded := test.addr.EncodeAddress()
+		got := test.addr.HasPrefix(test.prefix)
+		if got != test.want {
+			t.Errorf("HasPrefix(%q) for %s: got %v, want %v",
+				test.prefix, encoded, got, test.want)
+		}
+		if want := strings.HasPrefix(encoded, test.prefix); got != want {
+			t.Errorf("HasPrefix(%q) for %s: got %v, strings.HasPrefix "+
+				"returned %v", test.prefix, encoded, got, want)
+		}
+	}
+
+	// Every prefix of the encoding matches, and changing the final
+	// character of the prefix does not.
+	for _, addr := range []*btcutil.AddressPubKeyHash{mainAddr, zeroLedAddr,
+		zeroAddr, testAddr} {
+
+		encoded := addr.EncodeAddress()
+		for i := 1; i <= len(encoded); i++ {
+			prefix := encoded[:i]
+			if !addr.HasPrefix(prefix) {
+				t.Errorf("HasPrefix(%q) for %s: got false, want true",
+					prefix, encoded)
+			}
+			for _, c := range []byte{'1', '2', 'z'} {
+				if c == prefix[i-1] {
+					continue
+				}
+				changed := prefix[:i-1] + string(c)
+				if addr.HasPrefix(changed) {
+					t.Errorf("HasPrefix(%q) for %s: got true, want "+
+						"false", changed, encoded)
+				}
+			}
+		}
+	}
+}
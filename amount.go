@@ -6,8 +6,10 @@ package btcutil
 
 import (
 	"errors"
+	"fmt"
 	"math"
 	"strconv"
+	"strings"
 )
 
 // AmountUnit describes a method of converting an Amount to something
@@ -88,6 +90,164 @@ func NewAmount(f float64) (Amount, error) {
 	return round(f * SatoshiPerBitcoin), nil
 }
 
+// NewAmountFromUnit creates an Amount from a floating point value
+// representing some value in the passed unit, such as 1.5 for 1.5 mBTC with
+// AmountMilliBTC, rounding to the nearest satoshi.  Unlike NewAmount, an
+// error is also returned if the magnitude of the amount exceeds MaxSatoshi.
+func NewAmountFromUnit(f float64, u AmountUnit) (Amount, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, errors.New("invalid bitcoin amount")
+	}
+
+	// Reject values too large to convert to an integer before checking
+	// the rounded amount against the limit.
+	satoshi := f * math.Pow10(int(u+8))
+	if math.Abs(satoshi) > MaxSatoshi+0.5 {
+		return 0, errors.New("bitcoin amount out of range")
+	}
+	amt := round(satoshi)
+	if amt > MaxSatoshi || amt < -MaxSatoshi {
+		return 0, errors.New("bitcoin amount out of range")
+	}
+	return amt, nil
+}
+
+// parseFixedPoint parses a decimal string with up to the passed number of
+// fractional digits into an integer count of the smallest unit.  For example,
+// parsing "1.5" with 8 decimals returns 150000000.  Only ASCII digits, a
+// single optional decimal point, and a single optional leading minus sign
+// are accepted.  Both the integer part and, when a decimal point is present,
+// the fractional part must contain at least one digit.
+func parseFixedPoint(s string, decimals int) (int64, error) {
+	str := s
+	negative := strings.HasPrefix(str, "-")
+	if negative {
+		str = str[1:]
+	}
+
+	intPart, fracPart := str, ""
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		intPart, fracPart = str[:i], str[i+1:]
+		if fracPart == "" {
+			return 0, fmt.Errorf("invalid amount %q: missing "+
+				"fractional digits", s)
+		}
+	}
+	if intPart == "" {
+		return 0, fmt.Errorf("invalid amount %q: missing integer digits", s)
+	}
+	for _, part := range []string{intPart, fracPart} {
+		for i := 0; i < len(part); i++ {
+			if part[i] < '0' || part[i] > '9' {
+				return 0, fmt.Errorf("invalid amount %q: "+
+					"unexpected character %q", s, part[i])
+			}
+		}
+	}
+	if len(fracPart) > decimals {
+		return 0, fmt.Errorf("invalid amount %q: more than %d "+
+			"fractional digits", s, decimals)
+	}
+
+	// Pad the fractional part to the full precision and parse the
+	// combined digits as a single integer so overflow is detected.
+	digits := intPart + fracPart + strings.Repeat("0", decimals-len(fracPart))
+	if negative {
+		digits = "-" + digits
+	}
+	v, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: out of range", s)
+	}
+	return v, nil
+}
+
+// ParseAmount parses a decimal string denominated in bitcoin, such as
+// "0.12345678", into an Amount.  Unlike converting the result of
+// strconv.ParseFloat with NewAmount, the conversion is performed with integer
+// arithmetic so it is exact.
+//
+// The string may be surrounded by ASCII whitespace and may have a leading
+// minus sign, but must otherwise consist only of decimal digits with an
+// optional decimal point followed by no more than 8 fractional digits.  Any
+// other characters, including non-ASCII minus signs and spaces, are rejected.
+// See ParseAmountLenient for a variant which accepts them.
+func ParseAmount(s string) (Amount, error) {
+	v, err := parseFixedPoint(strings.Trim(s, " \t\r\n"), 8)
+	if err != nil {
+		return 0, err
+	}
+	return Amount(v), nil
+}
+
+// ParseAmountWithUnit parses a decimal string followed by an optional unit,
+// such as "1000 sat", "1.5 mBTC", or "0.01 BTC", into an Amount.  The
+// supported units are BTC, mBTC, μBTC (also written with the micro sign or as
+// uBTC), and sat or satoshi, optionally pluralized.  The unit may be separated
+// from the number by whitespace and is matched case-insensitively, except that
+// "MBTC" is rejected since it denotes a megabitcoin rather than a millibitcoin.
+// The amount is denominated in bitcoin when no unit is given.
+//
+// The number is parsed in the same way as ParseAmount, using integer
+// arithmetic, except that it may have no more fractional digits than the
+// precision of the unit allows.  For example, "1.5 sat" is rejected while
+// "0.00000001 BTC" and "0.01 μBTC" are accepted.
+func ParseAmountWithUnit(s string) (Amount, error) {
+	str := strings.Trim(s, " \t\r\n")
+
+	// The unit begins with the first character which can not be part of
+	// the number.
+	i := strings.IndexFunc(str, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != '-'
+	})
+	number, unit := str, ""
+	if i >= 0 {
+		number = str[:i]
+		unit = strings.TrimLeft(str[i:], " \t")
+	}
+
+	u := AmountBTC
+	switch strings.ToLower(unit) {
+	case "", "btc":
+	case "mbtc":
+		if unit[0] == 'M' {
+			return 0, fmt.Errorf("invalid amount %q: ambiguous unit "+
+				"%q", s, unit)
+		}
+		u = AmountMilliBTC
+	case "\u03bcbtc", "\u00b5btc", "ubtc":
+		u = AmountMicroBTC
+	case "sat", "sats", "satoshi", "satoshis":
+		u = AmountSatoshi
+	default:
+		return 0, fmt.Errorf("invalid amount %q: unknown unit %q", s, unit)
+	}
+
+	v, err := parseFixedPoint(number, int(u+8))
+	if err != nil {
+		return 0, err
+	}
+	return Amount(v), nil
+}
+
+// lenientAmountReplacer normalizes the unicode characters commonly found in
+// amounts copied from web pages and documents to their ASCII equivalents.
+var lenientAmountReplacer = strings.NewReplacer(
+	"\u2212", "-", // MINUS SIGN
+	"\u00a0", "", // NO-BREAK SPACE
+	"\u2007", "", // FIGURE SPACE
+	"\u2009", "", // THIN SPACE
+	"\u202f", "", // NARROW NO-BREAK SPACE
+)
+
+// ParseAmountLenient is identical to ParseAmount except the unicode minus
+// sign (U+2212) is first converted to an ASCII minus and any no-break, figure,
+// thin, or narrow no-break spaces are removed.  This allows parsing amounts
+// that have been pasted from sources which use those characters for display.
+func ParseAmountLenient(s string) (Amount, error) {
+	return ParseAmount(lenientAmountReplacer.Replace(s))
+}
+
 // ToUnit converts a monetary amount counted in bitcoin base units to a
 // floating point value representing an amount of bitcoin.
 func (a Amount) ToUnit(u AmountUnit) float64 {
@@ -108,11 +268,208 @@ func (a Amount) Format(u AmountUnit) string {
 	return strconv.FormatFloat(a.ToUnit(u), 'f', -int(u+8), 64) + units
 }
 
+// FormatGrouped formats a monetary amount counted in bitcoin base units as a
+// string for a given unit in the same way as Format, except that the digits
+// of the integer part are grouped in thousands separated by commas, such as
+// "1,234.56789012 BTC".  Integer arithmetic is used, so every fractional digit
+// of the amount in the unit is included exactly, with trailing zeros
+// removed.
+func (a Amount) FormatGrouped(u AmountUnit) string {
+	magnitude := uint64(a)
+	sign := ""
+	if a < 0 {
+		magnitude = -magnitude
+		sign = "-"
+	}
+
+	// Split the digits of the amount in satoshi into the integer and
+	// fractional parts for the unit.
+	digits := strconv.FormatUint(magnitude, 10)
+	var intPart, fracPart string
+	switch decimals := int(u + 8); {
+	case magnitude == 0:
+		intPart = "0"
+	case decimals <= 0:
+		intPart = digits + strings.Repeat("0", -decimals)
+	default:
+		if len(digits) <= decimals {
+			digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+		}
+		intPart = digits[:len(digits)-decimals]
+		fracPart = strings.TrimRight(digits[len(digits)-decimals:], "0")
+	}
+
+	var b strings.Builder
+	b.WriteString(sign)
+	for i := 0; i < len(intPart); i++ {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteByte(intPart[i])
+	}
+	if fracPart != "" {
+		b.WriteByte('.')
+		b.WriteString(fracPart)
+	}
+	b.WriteString(" ")
+	b.WriteString(u.String())
+	return b.String()
+}
+
 // String is the equivalent of calling Format with AmountBTC.
 func (a Amount) String() string {
 	return a.Format(AmountBTC)
 }
 
+// Round rounds a monetary amount counted in bitcoin base units to the nearest
+// whole multiple of the passed unit, such as 100 satoshi for AmountMicroBTC.
+// Amounts exactly halfway between two multiples are rounded away from zero
+// rather than to the nearest even multiple.  The amount is returned unchanged
+// for units smaller than a satoshi, and units too large to be represented in
+// satoshi, which are far larger than any valid amount, round it to zero.
+func (a Amount) Round(u AmountUnit) Amount {
+	exp := int(u + 8)
+	if exp <= 0 {
+		return a
+	}
+	if exp > 18 {
+		return 0
+	}
+
+	step := int64(1)
+	for i := 0; i < exp; i++ {
+		step *= 10
+	}
+	q, r := int64(a)/step, int64(a)%step
+	if r < 0 {
+		r = -r
+	}
+	if r >= step-r {
+		if a < 0 {
+			q--
+		} else {
+			q++
+		}
+	}
+	return Amount(q * step)
+}
+
+// IsValid returns whether the amount is within the range of values a
+// transaction output may hold, which is 0 through MaxSatoshi inclusive.
+// Negative amounts are never valid output values, so they are reported as
+// invalid even though they are meaningful elsewhere, such as for fee deltas.
+// Callers accepting such deltas should instead check the magnitude of the
+// amount against MaxSatoshi.
+func (a Amount) IsValid() bool {
+	return a >= 0 && a <= MaxSatoshi
+}
+
+// Clamp returns the amount limited to the range min through max inclusive.
+// Amounts below min are returned as min and amounts above max are returned as
+// max.  When min is greater than max, max is returned.
+func (a Amount) Clamp(min, max Amount) Amount {
+	if a < min {
+		a = min
+	}
+	if a > max {
+		a = max
+	}
+	return a
+}
+
+// Abs returns the absolute value of the amount.  The negation of the minimum
+// int64 value cannot be represented, so math.MinInt64 is returned as
+// math.MaxInt64 rather than overflowing back to a negative amount.
+func (a Amount) Abs() Amount {
+	if a < 0 {
+		return a.Neg()
+	}
+	return a
+}
+
+// Neg returns the amount with its sign reversed.  The negation of the minimum
+// int64 value cannot be represented, so math.MinInt64 is returned as
+// math.MaxInt64 rather than overflowing back to math.MinInt64.
+func (a Amount) Neg() Amount {
+	if a == math.MinInt64 {
+		return math.MaxInt64
+	}
+	return -a
+}
+
+// Split divides the amount into n parts which differ by at most one satoshi
+// and sum to exactly the amount.  The satoshis remaining after the amount is
+// divided evenly are distributed one each to the first parts, so the parts
+// are in non-increasing order of magnitude.  An error is returned when n is
+// not positive.
+func (a Amount) Split(n int) ([]Amount, error) {
+	if n <= 0 {
+		return nil, errors.New("number of parts must be positive")
+	}
+
+	// The remainder has the same sign as the amount, so each of the first
+	// parts is moved one satoshi further from zero.
+	q, r := a/Amount(n), a%Amount(n)
+	one := Amount(1)
+	if r < 0 {
+		one, r = -1, -r
+	}
+	parts := make([]Amount, n)
+	for i := range parts {
+		parts[i] = q
+		if Amount(i) < r {
+			parts[i] += one
+		}
+	}
+	return parts, nil
+}
+
+// formatBTC formats the amount as a decimal string denominated in bitcoin
+// with exactly 8 fractional digits, such as "-0.12345678".  Integer
+// arithmetic is used so the result is exact for all amounts.
+func (a Amount) formatBTC() string {
+	magnitude := uint64(a)
+	sign := ""
+	if a < 0 {
+		magnitude = -magnitude
+		sign = "-"
+	}
+	return fmt.Sprintf("%s%d.%08d", sign, magnitude/SatoshiPerBitcoin,
+		magnitude%SatoshiPerBitcoin)
+}
+
+// MarshalJSON satisfies the json.Marshaler interface by encoding the amount
+// as a JSON string containing the exact amount in bitcoin with 8 fractional
+// digits, such as "0.12345678".
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + a.formatBTC() + `"`), nil
+}
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface by decoding an
+// amount in bitcoin from either a JSON string or a JSON number.  The value is
+// parsed with ParseAmount rather than as a floating point number, so it must
+// not have more than 8 fractional digits or use exponent notation.  A JSON
+// null leaves the amount unchanged.
+func (a *Amount) UnmarshalJSON(b []byte) error {
+	s := strings.TrimSpace(string(b))
+	if s == "null" {
+		return nil
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		var err error
+		s, err = strconv.Unquote(s)
+		if err != nil {
+			return fmt.Errorf("invalid amount %s: %v", b, err)
+		}
+	}
+	amt, err := ParseAmount(s)
+	if err != nil {
+		return err
+	}
+	*a = amt
+	return nil
+}
+
 // MulF64 multiplies an Amount by a floating point value.  While this is not
 // an operation that must typically be done by a full node or wallet, it is
 // useful for services that build on top of bitcoin (for example, calculating
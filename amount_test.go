@@ -5,7 +5,10 @@
 package btcutil_test
 
 import (
+	"encoding/json"
 	"math"
+	"reflect"
+	"strings"
 	"testing"
 
 	. "github.com/btcsuite/btcutil"
@@ -307,3 +310,718 @@ func TestAmountMulF64(t *testing.T) {
 		}
 	}
 }
+
+// TestAmountFormatGrouped ensures the integer part of formatted amounts is
+// grouped in thousands for every unit, including negative amounts.
+func TestAmountFormatGrouped(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   Amount
+		unit     AmountUnit
+		expected string
+	}{
+		{"zero", 0, AmountBTC, "0 BTC"},
+		{"one satoshi", 1, AmountBTC, "0.00000001 BTC"},
+		{"sub-one", 56789012, AmountBTC, "0.56789012 BTC"},
+		{"one BTC", 100000000, AmountBTC, "1 BTC"},
+		{"no grouping needed", 99956789012, AmountBTC, "999.56789012 BTC"},
+		{"thousands", 123456789012, AmountBTC, "1,234.56789012 BTC"},
+		{"trailing zeros", 123450000000, AmountBTC, "1,234.5 BTC"},
+		{"max", MaxSatoshi, AmountBTC, "21,000,000 BTC"},
+		{"negative sub-one", -56789012, AmountBTC, "-0.56789012 BTC"},
+		{"negative thousands", -123456789012, AmountBTC, "-1,234.56789012 BTC"},
+		{"negative millions", -123456789012345, AmountBTC, "-1,234,567.89012345 BTC"},
+		{"min int64", math.MinInt64, AmountBTC, "-92,233,720,368.54775808 BTC"},
+		{"satoshi", 1234567, AmountSatoshi, "1,234,567 Satoshi"},
+		{"satoshi no grouping", 123, AmountSatoshi, "123 Satoshi"},
+		{"mBTC", 123456789012, AmountMilliBTC, "1,234,567.89012 mBTC"},
+		{"μBTC", 123456789012, AmountMicroBTC, "1,234,567,890.12 μBTC"},
+		{"kBTC", 123456789012, AmountKiloBTC, "1.23456789012 kBTC"},
+		{"MBTC", MaxSatoshi, AmountMegaBTC, "21 MBTC"},
+		{"sub-satoshi unit", 1234, AmountUnit(-11), "1,234,000 1e-11 BTC"},
+	}
+
+	for _, test := range tests {
+		if got := test.amount.FormatGrouped(test.unit); got != test.expected {
+			t.Errorf("%v: got %q, want %q", test.name, got, test.expected)
+		}
+	}
+
+	// Without the separators the result must match Format for amounts
+	// which are exactly representable as floats in the unit.
+	units := []AmountUnit{AmountMegaBTC, AmountKiloBTC, AmountBTC,
+		AmountMilliBTC, AmountMicroBTC, AmountSatoshi}
+	amounts := []Amount{0, 1, -1, 5000, 2100000000, -123456789012, MaxSatoshi}
+	for _, u := range units {
+		for _, a := range amounts {
+			got := strings.Replace(a.FormatGrouped(u), ",", "", -1)
+			if want := a.Format(u); got != want {
+				t.Errorf("FormatGrouped(%d, %v) without separators: "+
+					"got %q, want %q", int64(a), u, got, want)
+			}
+		}
+	}
+}
+
+// TestAmountRound ensures amounts are rounded to the nearest multiple of a
+// unit with halfway values rounded away from zero.
+func TestAmountRound(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   Amount
+		unit     AmountUnit
+		expected Amount
+	}{
+		{"12345 satoshi to μBTC", 12345, AmountMicroBTC, 12300},
+		{"12345 satoshi to mBTC", 12345, AmountMilliBTC, 0},
+		{"123456 satoshi to mBTC", 123456, AmountMilliBTC, 100000},
+		{"exact μBTC multiple", 12300, AmountMicroBTC, 12300},
+		{"exact mBTC multiple", 200000, AmountMilliBTC, 200000},
+		{"halfway rounds up", 12350, AmountMicroBTC, 12400},
+		{"halfway rounds away from zero", -12350, AmountMicroBTC, -12400},
+		{"negative rounds toward zero", -12349, AmountMicroBTC, -12300},
+		{"just below halfway", 49999999, AmountBTC, 0},
+		{"halfway BTC", 50000000, AmountBTC, 100000000},
+		{"max to MBTC", MaxSatoshi, AmountMegaBTC, MaxSatoshi},
+		{"half MBTC", 50e12, AmountMegaBTC, 100e12},
+		{"max to kBTC", MaxSatoshi, AmountKiloBTC, MaxSatoshi},
+		{"satoshi unit", 12345, AmountSatoshi, 12345},
+		{"sub-satoshi unit", 12345, AmountUnit(-9), 12345},
+		{"huge unit", MaxSatoshi, AmountUnit(11), 0},
+	}
+
+	for _, test := range tests {
+		if got := test.amount.Round(test.unit); got != test.expected {
+			t.Errorf("%v: got %d, want %d", test.name, int64(got),
+				int64(test.expected))
+		}
+	}
+}
+
+// TestAmountIsValid ensures only amounts between zero and MaxSatoshi
+// inclusive are reported as valid.
+func TestAmountIsValid(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount Amount
+		valid  bool
+	}{
+		{"zero", 0, true},
+		{"one satoshi", 1, true},
+		{"max", MaxSatoshi, true},
+		{"max plus one", MaxSatoshi + 1, false},
+		{"negative one", -1, false},
+		{"negative max", -MaxSatoshi, false},
+		{"max int64", math.MaxInt64, false},
+		{"min int64", math.MinInt64, false},
+	}
+
+	for _, test := range tests {
+		if got := test.amount.IsValid(); got != test.valid {
+			t.Errorf("%v: got %v, want %v", test.name, got, test.valid)
+		}
+	}
+}
+
+// TestAmountClamp ensures amounts are limited to the passed range, including
+// for the extremes of int64 and for empty or inverted ranges.
+func TestAmountClamp(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   Amount
+		min      Amount
+		max      Amount
+		expected Amount
+	}{
+		{"zero", 0, 0, MaxSatoshi, 0},
+		{"max", MaxSatoshi, 0, MaxSatoshi, MaxSatoshi},
+		{"max plus one", MaxSatoshi + 1, 0, MaxSatoshi, MaxSatoshi},
+		{"negative", -1, 0, MaxSatoshi, 0},
+		{"within range", 12345, 0, MaxSatoshi, 12345},
+		{"negative within delta range", -12345, -MaxSatoshi, MaxSatoshi, -12345},
+		{"below delta range", -MaxSatoshi - 1, -MaxSatoshi, MaxSatoshi, -MaxSatoshi},
+		{"max int64", math.MaxInt64, 0, MaxSatoshi, MaxSatoshi},
+		{"min int64", math.MinInt64, 0, MaxSatoshi, 0},
+		{"empty range", 5, 10, 10, 10},
+		{"inverted range", 5, 10, 0, 0},
+	}
+
+	for _, test := range tests {
+		got := test.amount.Clamp(test.min, test.max)
+		if got != test.expected {
+			t.Errorf("%v: got %d, want %d", test.name, int64(got),
+				int64(test.expected))
+		}
+		if test.min <= test.max && test.min >= 0 &&
+			test.max <= MaxSatoshi && !got.IsValid() {
+			t.Errorf("%v: clamped amount %d is not valid", test.name,
+				int64(got))
+		}
+	}
+}
+
+func TestAmountAbsNeg(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount Amount
+		abs    Amount
+		neg    Amount
+	}{
+		{"zero", 0, 0, 0},
+		{"one", 1, 1, -1},
+		{"negative one", -1, 1, 1},
+		{"max satoshi", MaxSatoshi, MaxSatoshi, -MaxSatoshi},
+		{"negative max satoshi", -MaxSatoshi, MaxSatoshi, MaxSatoshi},
+		{"max int64", math.MaxInt64, math.MaxInt64, -math.MaxInt64},
+		{"min int64 plus one", math.MinInt64 + 1, math.MaxInt64, math.MaxInt64},
+		{"min int64", math.MinInt64, math.MaxInt64, math.MaxInt64},
+	}
+
+	for _, test := range tests {
+		if got := test.amount.Abs(); got != test.abs {
+			t.Errorf("%v: Abs: got %d, want %d", test.name, int64(got),
+				int64(test.abs))
+		}
+		if got := test.amount.Neg(); got != test.neg {
+			t.Errorf("%v: Neg: got %d, want %d", test.name, int64(got),
+				int64(test.neg))
+		}
+	}
+}
+
+func TestAmountSplit(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount Amount
+		n      int
+		parts  []Amount
+		valid  bool
+	}{
+		{"even", 300, 3, []Amount{100, 100, 100}, true},
+		{"remainder of one", 100, 3, []Amount{34, 33, 33}, true},
+		{"remainder of two", 101, 3, []Amount{34, 34, 33}, true},
+		{"fewer satoshi than parts", 2, 5, []Amount{1, 1, 0, 0, 0}, true},
+		{"one part", 12345, 1, []Amount{12345}, true},
+		{"zero", 0, 2, []Amount{0, 0}, true},
+		{"negative", -7, 3, []Amount{-3, -2, -2}, true},
+		{"max satoshi plus one", MaxSatoshi + 1, 2, []Amount{1050000000000001, 1050000000000000}, true},
+		{"min int64", math.MinInt64, 2, []Amount{math.MinInt64 / 2, math.MinInt64 / 2}, true},
+		{"zero parts", 100, 0, nil, false},
+		{"negative parts", 100, -1, nil, false},
+	}
+
+	for _, test := range tests {
+		parts, err := test.amount.Split(test.n)
+		switch {
+		case test.valid && err != nil:
+			t.Errorf("%v: Split failed with: %v", test.name, err)
+			continue
+		case !test.valid && err == nil:
+			t.Errorf("%v: Split succeeded (parts %v) when should fail",
+				test.name, parts)
+			continue
+		}
+		if !reflect.DeepEqual(parts, test.parts) {
+			t.Errorf("%v: Split: got %v, want %v", test.name, parts,
+				test.parts)
+			continue
+		}
+
+		// The parts must sum back to the original amount exactly.
+		if !test.valid {
+			continue
+		}
+		var sum Amount
+		for _, part := range parts {
+			sum += part
+		}
+		if sum != test.amount {
+			t.Errorf("%v: Split: parts sum to %d, want %d", test.name,
+				int64(sum), int64(test.amount))
+		}
+	}
+}
+
+// TestParseAmount ensures ParseAmount parses decimal strings exactly and
+// rejects malformed input.
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		valid    bool
+		expected Amount
+	}{
+		{
+			name:     "max",
+			s:        "21000000",
+			valid:    true,
+			expected: MaxSatoshi,
+		},
+		{
+			name:     "one satoshi",
+			s:        "0.00000001",
+			valid:    true,
+			expected: 1,
+		},
+		{
+			name:     "negative fraction",
+			s:        "-1.5",
+			valid:    true,
+			expected: -150000000,
+		},
+		{
+			name:     "integer",
+			s:        "1",
+			valid:    true,
+			expected: 100000000,
+		},
+		{
+			name:     "all fractional digits",
+			s:        "0.12345678",
+			valid:    true,
+			expected: 12345678,
+		},
+		{
+			name:     "short fraction",
+			s:        "3.1",
+			valid:    true,
+			expected: 310000000,
+		},
+		{
+			name:     "surrounding whitespace",
+			s:        " 	0.5\n",
+			valid:    true,
+			expected: 50000000,
+		},
+		{
+			name:     "negative zero",
+			s:        "-0",
+			valid:    true,
+			expected: 0,
+		},
+		{
+			name: "over-precision",
+			s:    "0.000000001",
+		},
+		{
+			name: "over-precision trailing zero",
+			s:    "1.000000000",
+		},
+		{
+			name: "multiple dots",
+			s:    "1.2.3",
+		},
+		{
+			name: "non-numeric",
+			s:    "1a",
+		},
+		{
+			name: "exponent",
+			s:    "1e8",
+		},
+		{
+			name: "plus sign",
+			s:    "+1",
+		},
+		{
+			name: "missing integer part",
+			s:    ".5",
+		},
+		{
+			name: "missing fraction",
+			s:    "1.",
+		},
+		{
+			name: "lone minus",
+			s:    "-",
+		},
+		{
+			name: "inner whitespace",
+			s:    "1 000",
+		},
+		{
+			name: "empty",
+			s:    "",
+		},
+		{
+			name: "overflow",
+			s:    "92233720368.54775808",
+		},
+	}
+
+	for _, test := range tests {
+		a, err := ParseAmount(test.s)
+		switch {
+		case test.valid && err != nil:
+			t.Errorf("%v: ParseAmount failed with: %v", test.name, err)
+			continue
+		case !test.valid && err == nil:
+			t.Errorf("%v: ParseAmount succeeded (value %v) when "+
+				"should fail", test.name, a)
+			continue
+		}
+
+		if a != test.expected {
+			t.Errorf("%v: Parsed amount %v does not match expected %v",
+				test.name, a, test.expected)
+			continue
+		}
+	}
+}
+
+func TestParseAmountWithUnit(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		valid    bool
+		expected Amount
+	}{
+		{
+			name:     "default unit",
+			s:        "0.01",
+			valid:    true,
+			expected: 1000000,
+		},
+		{
+			name:     "BTC",
+			s:        "0.01 BTC",
+			valid:    true,
+			expected: 1000000,
+		},
+		{
+			name:     "lowercase BTC without space",
+			s:        "1btc",
+			valid:    true,
+			expected: 100000000,
+		},
+		{
+			name:     "all fractional digits BTC",
+			s:        "0.00000001 BTC",
+			valid:    true,
+			expected: 1,
+		},
+		{
+			name:     "mBTC",
+			s:        "1.5 mBTC",
+			valid:    true,
+			expected: 150000,
+		},
+		{
+			name:     "negative mBTC",
+			s:        "-2 mbtc",
+			valid:    true,
+			expected: -200000,
+		},
+		{
+			name:     "μBTC greek mu",
+			s:        "1 μBTC",
+			valid:    true,
+			expected: 100,
+		},
+		{
+			name:     "μBTC micro sign",
+			s:        "1 µBTC",
+			valid:    true,
+			expected: 100,
+		},
+		{
+			name:     "uBTC",
+			s:        "2 uBTC",
+			valid:    true,
+			expected: 200,
+		},
+		{
+			name:     "μBTC fraction",
+			s:        "0.01 uBTC",
+			valid:    true,
+			expected: 1,
+		},
+		{
+			name:     "sat",
+			s:        "1000 sat",
+			valid:    true,
+			expected: 1000,
+		},
+		{
+			name:     "Satoshi",
+			s:        "1 Satoshi",
+			valid:    true,
+			expected: 1,
+		},
+		{
+			name:     "sats",
+			s:        "5 sats",
+			valid:    true,
+			expected: 5,
+		},
+		{
+			name: "over-precision satoshi",
+			s:    "1.5 sat",
+		},
+		{
+			name: "over-precision mBTC",
+			s:    "1.000001 mBTC",
+		},
+		{
+			name: "over-precision μBTC",
+			s:    "0.001 uBTC",
+		},
+		{
+			name: "megabitcoin",
+			s:    "1 MBTC",
+		},
+		{
+			name: "unknown unit",
+			s:    "1 kBTC",
+		},
+		{
+			name: "unit without number",
+			s:    "BTC",
+		},
+		{
+			name: "multiple dots",
+			s:    "1.2.3 BTC",
+		},
+		{
+			name: "non-numeric",
+			s:    "abc",
+		},
+		{
+			name: "empty",
+			s:    "",
+		},
+	}
+
+	for _, test := range tests {
+		a, err := ParseAmountWithUnit(test.s)
+		switch {
+		case test.valid && err != nil:
+			t.Errorf("%v: ParseAmountWithUnit failed with: %v",
+				test.name, err)
+			continue
+		case !test.valid && err == nil:
+			t.Errorf("%v: ParseAmountWithUnit succeeded (value %v) "+
+				"when should fail", test.name, a)
+			continue
+		}
+
+		if a != test.expected {
+			t.Errorf("%v: Parsed amount %v does not match expected %v",
+				test.name, a, test.expected)
+			continue
+		}
+	}
+}
+
+func TestParseAmountLenient(t *testing.T) {
+	tests := []struct {
+		name        string
+		s           string
+		strictValid bool
+		valid       bool
+		expected    Amount
+	}{
+		{
+			name:        "ascii",
+			s:           "-1.5",
+			strictValid: true,
+			valid:       true,
+			expected:    -150000000,
+		},
+		{
+			name:     "unicode minus",
+			s:        "\u22121.5",
+			valid:    true,
+			expected: -150000000,
+		},
+		{
+			name:     "no-break space separator",
+			s:        "1\u00a0000.5",
+			valid:    true,
+			expected: 100050000000,
+		},
+		{
+			name:     "thin space separator",
+			s:        "21\u2009000\u2009000",
+			valid:    true,
+			expected: MaxSatoshi,
+		},
+		{
+			name:     "narrow no-break space before minus",
+			s:        "\u202f\u22120.00000001",
+			valid:    true,
+			expected: -1,
+		},
+		{
+			name:     "figure space",
+			s:        "0.123\u20074",
+			valid:    true,
+			expected: 12340000,
+		},
+		{
+			name: "other unicode",
+			s:    "1\u20135",
+		},
+		{
+			name: "only spaces",
+			s:    "\u2009\u00a0",
+		},
+	}
+
+	for _, test := range tests {
+		a, err := ParseAmount(test.s)
+		if (err == nil) != test.strictValid {
+			t.Errorf("%v: ParseAmount unexpected result (value %v, "+
+				"err %v)", test.name, a, err)
+			continue
+		}
+
+		a, err = ParseAmountLenient(test.s)
+		switch {
+		case test.valid && err != nil:
+			t.Errorf("%v: ParseAmountLenient failed with: %v",
+				test.name, err)
+			continue
+		case !test.valid && err == nil:
+			t.Errorf("%v: ParseAmountLenient succeeded (value %v) "+
+				"when should fail", test.name, a)
+			continue
+		}
+
+		if a != test.expected {
+			t.Errorf("%v: Parsed amount %v does not match expected %v",
+				test.name, a, test.expected)
+			continue
+		}
+	}
+}
+
+func TestNewAmountFromUnit(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   float64
+		unit     AmountUnit
+		valid    bool
+		expected Amount
+	}{
+		{"MBTC", 1.5, AmountMegaBTC, true, 150000000000000},
+		{"kBTC", 1.5, AmountKiloBTC, true, 150000000000},
+		{"BTC", 1.5, AmountBTC, true, 150000000},
+		{"mBTC", 1.5, AmountMilliBTC, true, 150000},
+		{"μBTC", 1.5, AmountMicroBTC, true, 150},
+		{"satoshi", 1.5, AmountSatoshi, true, 2},
+		{"negative mBTC", -1.5, AmountMilliBTC, true, -150000},
+		{"rounded μBTC", 0.123456, AmountMicroBTC, true, 12},
+		{"rounded mBTC", 0.123456789, AmountMilliBTC, true, 12346},
+		{"zero", 0, AmountBTC, true, 0},
+		{"max", 21, AmountMegaBTC, true, MaxSatoshi},
+		{"min", -21, AmountMegaBTC, true, -MaxSatoshi},
+		{"max satoshi", float64(MaxSatoshi), AmountSatoshi, true, MaxSatoshi},
+		{"exceeds max", 21000000.00000001, AmountBTC, false, 0},
+		{"exceeds min", -21000.1, AmountKiloBTC, false, 0},
+		{"overflow", 1e300, AmountBTC, false, 0},
+		{"NaN", math.NaN(), AmountBTC, false, 0},
+		{"positive infinity", math.Inf(1), AmountMilliBTC, false, 0},
+		{"negative infinity", math.Inf(-1), AmountMilliBTC, false, 0},
+	}
+
+	for _, test := range tests {
+		a, err := NewAmountFromUnit(test.amount, test.unit)
+		switch {
+		case test.valid && err != nil:
+			t.Errorf("%v: unexpected error: %v", test.name, err)
+			continue
+		case !test.valid:
+			if err == nil {
+				t.Errorf("%v: expected error", test.name)
+			}
+			continue
+		}
+		if a != test.expected {
+			t.Errorf("%v: got %d, want %d", test.name, a, test.expected)
+		}
+	}
+}
+
+func TestAmountJSON(t *testing.T) {
+	// Amounts must round trip through their JSON encoding exactly.
+	roundTrip := []struct {
+		amount  Amount
+		encoded string
+	}{
+		{0, `"0.00000000"`},
+		{1, `"0.00000001"`},
+		{-1, `"-0.00000001"`},
+		{12345678, `"0.12345678"`},
+		{150000000, `"1.50000000"`},
+		{-2099999999999999, `"-20999999.99999999"`},
+		{MaxSatoshi, `"21000000.00000000"`},
+		{math.MinInt64, `"-92233720368.54775808"`},
+	}
+	for _, test := range roundTrip {
+		b, err := json.Marshal(test.amount)
+		if err != nil {
+			t.Errorf("Marshal %d: unexpected error: %v", test.amount, err)
+			continue
+		}
+		if string(b) != test.encoded {
+			t.Errorf("Marshal %d: got %s, want %s", test.amount, b,
+				test.encoded)
+			continue
+		}
+		var decoded Amount
+		if err := json.Unmarshal(b, &decoded); err != nil {
+			t.Errorf("Unmarshal %s: unexpected error: %v", b, err)
+			continue
+		}
+		if decoded != test.amount {
+			t.Errorf("Unmarshal %s: got %d, want %d", b, decoded,
+				test.amount)
+		}
+	}
+
+	// Both strings and numbers are accepted, and values with 8 fractional
+	// digits are decoded exactly.
+	unmarshal := []struct {
+		name     string
+		encoded  string
+		valid    bool
+		expected Amount
+	}{
+		{"string", `"0.29"`, true, 29000000},
+		{"number", `0.29`, true, 29000000},
+		{"8 fractional digits", `"20999999.99999999"`, true, 2099999999999999},
+		{"number with 8 fractional digits", `20999999.99999999`, true, 2099999999999999},
+		{"negative number", `-1.00000001`, true, -100000001},
+		{"integer", `21`, true, 2100000000},
+		{"null", `null`, true, 12345},
+		{"too precise", `"0.000000001"`, false, 0},
+		{"exponent", `1e-8`, false, 0},
+		{"not a number", `"one"`, false, 0},
+		{"boolean", `true`, false, 0},
+	}
+	for _, test := range unmarshal {
+		amt := Amount(12345)
+		err := json.Unmarshal([]byte(test.encoded), &amt)
+		switch {
+		case test.valid && err != nil:
+			t.Errorf("%v: unexpected error: %v", test.name, err)
+		case !test.valid && err == nil:
+			t.Errorf("%v: expected error", test.name)
+		case test.valid && amt != test.expected:
+			t.Errorf("%v: got %d, want %d", test.name, amt, test.expected)
+		}
+	}
+
+	// Amounts nested within other values use the same encoding.
+	type wallet struct {
+		Balance Amount `json:"balance"`
+	}
+	b, err := json.Marshal(wallet{Balance: 100000000})
+	if err != nil {
+		t.Fatalf("Marshal: unexpected error: %v", err)
+	}
+	if string(b) != `{"balance":"1.00000000"}` {
+		t.Errorf("Marshal: got %s", b)
+	}
+}
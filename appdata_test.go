@@ -131,3 +131,48 @@ func TestAppDataDir(t *testing.T) {
 		}
 	}
 }
+
+// TestAppDataDirWindowsEnv ensures the roaming flag selects the expected
+// Windows environment variable, including the fallback to %APPDATA% when
+// %LOCALAPPDATA% is not set.
+func TestAppDataDirWindowsEnv(t *testing.T) {
+	// Restore the original environment when the test is done.
+	for _, name := range []string{"LOCALAPPDATA", "APPDATA"} {
+		orig, ok := os.LookupEnv(name)
+		defer func(name, orig string, ok bool) {
+			if ok {
+				os.Setenv(name, orig)
+			} else {
+				os.Unsetenv(name)
+			}
+		}(name, orig, ok)
+	}
+
+	localDir := filepath.Join("local", "appdata")
+	roamingDir := filepath.Join("roaming", "appdata")
+	tests := []struct {
+		name         string
+		localAppData string
+		appData      string
+		roaming      bool
+		want         string
+	}{
+		{"local", localDir, roamingDir, false, filepath.Join(localDir, "Myapp")},
+		{"roaming", localDir, roamingDir, true, filepath.Join(roamingDir, "Myapp")},
+		{"no local fallback", "", roamingDir, false, filepath.Join(roamingDir, "Myapp")},
+		{"roaming no local", "", roamingDir, true, filepath.Join(roamingDir, "Myapp")},
+		{"roaming unset", localDir, "", true, "."},
+		{"none set", "", "", false, "."},
+	}
+
+	for _, test := range tests {
+		os.Setenv("LOCALAPPDATA", test.localAppData)
+		os.Setenv("APPDATA", test.appData)
+
+		ret := btcutil.TstAppDataDir("windows", "myapp", test.roaming)
+		if ret != test.want {
+			t.Errorf("%s: appDataDir mismatch - got %s, want %s",
+				test.name, ret, test.want)
+		}
+	}
+}
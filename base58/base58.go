@@ -5,24 +5,108 @@
 package base58
 
 import (
+	"crypto/subtle"
+	"errors"
 	"math/big"
 )
 
 //go:generate go run genalphabet.go
 
 var bigRadix = big.NewInt(58)
-var bigZero = big.NewInt(0)
+
+// radix58Pow5 is 58^5, which is the largest power of 58 that fits in a
+// uint32.  Encoding treats the result as a number in this base so that each
+// division yields five base58 digits at once.
+const radix58Pow5 = 58 * 58 * 58 * 58 * 58
+
+const (
+	// bitcoinAlphabet is the modified base58 alphabet used by Bitcoin.  It
+	// allows the generated alphabet to be referenced where a parameter
+	// shadows it.
+	bitcoinAlphabet = alphabet
+
+	// Alphabet is the modified base58 alphabet used by Bitcoin, which is
+	// the alphabet used by Encode and Decode.
+	Alphabet = alphabet
+
+	// RippleAlphabet is the base58 alphabet used by Ripple.
+	RippleAlphabet = "rpshnaf39wBUDNEGHJKLM4PQRST7VWXYZ2bcdeCg65jkm8oFqi1tuvAxyz"
+
+	// FlickrAlphabet is the base58 alphabet used by Flickr for short URLs.
+	FlickrAlphabet = "123456789abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ"
+)
+
+var (
+	// ErrInvalidAlphabet indicates that an alphabet passed to DecodeAlphabet
+	// does not consist of exactly 58 unique characters.
+	ErrInvalidAlphabet = errors.New("alphabet must contain 58 unique characters")
+
+	// ErrInvalidCharacter indicates that a string passed to DecodeAlphabet
+	// contains a character which is not part of the alphabet.
+	ErrInvalidCharacter = errors.New("invalid character for alphabet")
+)
+
+// decodeTable returns the table mapping each byte to its value in the passed
+// alphabet, or 255 for bytes which are not part of it.  The precomputed table
+// is returned for the Bitcoin alphabet.
+func decodeTable(alphabet string) (*[256]byte, error) {
+	if alphabet == bitcoinAlphabet {
+		return &b58, nil
+	}
+	if len(alphabet) != 58 {
+		return nil, ErrInvalidAlphabet
+	}
+
+	var table [256]byte
+	for i := range table {
+		table[i] = 255
+	}
+	for i := 0; i < len(alphabet); i++ {
+		if table[alphabet[i]] != 255 {
+			return nil, ErrInvalidAlphabet
+		}
+		table[alphabet[i]] = byte(i)
+	}
+	return &table, nil
+}
+
+// IndexOf returns the value of the passed character in the modified base58
+// alphabet used by Bitcoin, which is its index in Alphabet, or -1 when the
+// character is not in the alphabet.
+func IndexOf(c byte) int {
+	if b58[c] == 255 {
+		return -1
+	}
+	return int(b58[c])
+}
 
 // Decode decodes a modified base58 string to a byte slice.
 func Decode(b string) []byte {
+	val, err := DecodeAlphabet(b, bitcoinAlphabet)
+	if err != nil {
+		return []byte("")
+	}
+	return val
+}
+
+// DecodeAlphabet decodes a base58 string encoded with the passed alphabet to
+// a byte slice.  The alphabet must consist of exactly 58 unique characters,
+// otherwise ErrInvalidAlphabet is returned.  ErrInvalidCharacter is returned
+// if the string contains a character which is not part of the alphabet.
+func DecodeAlphabet(b string, alphabet string) ([]byte, error) {
+	table, err := decodeTable(alphabet)
+	if err != nil {
+		return nil, err
+	}
+
 	answer := big.NewInt(0)
 	j := big.NewInt(1)
 
 	scratch := new(big.Int)
 	for i := len(b) - 1; i >= 0; i-- {
-		tmp := b58[b[i]]
+		tmp := table[b[i]]
 		if tmp == 255 {
-			return []byte("")
+			return nil, ErrInvalidCharacter
 		}
 		scratch.SetInt64(int64(tmp))
 		scratch.Mul(j, scratch)
@@ -34,7 +118,7 @@ func Decode(b string) []byte {
 
 	var numZeros int
 	for numZeros = 0; numZeros < len(b); numZeros++ {
-		if b[numZeros] != alphabetIdx0 {
+		if b[numZeros] != alphabet[0] {
 			break
 		}
 	}
@@ -42,27 +126,144 @@ func Decode(b string) []byte {
 	val := make([]byte, flen)
 	copy(val[numZeros:], tmpval)
 
-	return val
+	return val, nil
+}
+
+// DecodeConstantTime decodes a modified base58 string to a byte slice in the
+// same way as Decode, but in time which depends only on the length of the
+// string and of the decoded result, rather than on the characters of the
+// string.  Each character is looked up by comparing it with every character
+// of the alphabet, every digit is multiplied through the entire working
+// buffer, and invalid characters are only reported once the whole string has
+// been processed.  This makes it suitable for decoding sensitive data such as
+// WIF-encoded private keys, at the cost of being considerably slower than
+// Decode.  ErrInvalidCharacter is returned if the string contains a character
+// which is not part of the alphabet.
+func DecodeConstantTime(s string) ([]byte, error) {
+	// Each base58 digit carries log(58)/log(256) bytes, which is just
+	// under 0.733 bytes.
+	buf := make([]byte, len(s)*733/1000+1)
+
+	invalid := 0
+	leading := 1
+	numZeros := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		digit, found := 0, 0
+		for j := 0; j < len(bitcoinAlphabet); j++ {
+			eq := subtle.ConstantTimeByteEq(c, bitcoinAlphabet[j])
+			digit |= j & -eq
+			found |= eq
+		}
+		invalid |= found ^ 1
+
+		// Leading '1' characters each represent a leading zero byte.
+		leading &= subtle.ConstantTimeByteEq(c, bitcoinAlphabet[0])
+		numZeros += leading
+
+		// Multiply the little-endian buffer by 58 and add the digit.
+		carry := digit
+		for j := range buf {
+			carry += int(buf[j]) * 58
+			buf[j] = byte(carry)
+			carry >>= 8
+		}
+	}
+
+	// The most significant zero bytes of the buffer are not part of the
+	// decoded value.
+	unused := 0
+	stillZero := 1
+	for j := len(buf) - 1; j >= 0; j-- {
+		stillZero &= subtle.ConstantTimeByteEq(buf[j], 0)
+		unused += stillZero
+	}
+	numBytes := len(buf) - unused
+
+	var val []byte
+	if invalid == 0 {
+		val = make([]byte, numZeros+numBytes)
+		for i := 0; i < numBytes; i++ {
+			val[numZeros+i] = buf[numBytes-1-i]
+		}
+	}
+
+	// Clear the working buffer since it holds the decoded data.
+	for j := range buf {
+		buf[j] = 0
+	}
+
+	if invalid != 0 {
+		return nil, ErrInvalidCharacter
+	}
+	return val, nil
 }
 
 // Encode encodes a byte slice to a modified base58 string.
 func Encode(b []byte) string {
-	x := new(big.Int)
-	x.SetBytes(b)
+	return EncodeAlphabet(b, bitcoinAlphabet)
+}
 
-	answer := make([]byte, 0, len(b)*136/100)
-	for x.Cmp(bigZero) > 0 {
-		mod := new(big.Int)
-		x.DivMod(x, bigRadix, mod)
-		answer = append(answer, alphabet[mod.Int64()])
+// EncodeAlphabet encodes a byte slice to a base58 string using the passed
+// alphabet.  It panics if the alphabet does not consist of exactly 58 unique
+// characters since that indicates a programming error.
+func EncodeAlphabet(b []byte, alphabet string) string {
+	if _, err := decodeTable(alphabet); err != nil {
+		panic(err)
 	}
 
-	// leading zero bytes
-	for _, i := range b {
-		if i != 0 {
-			break
+	// Leading zero bytes are each encoded as the first character of the
+	// alphabet.
+	numZeros := 0
+	for numZeros < len(b) && b[numZeros] == 0 {
+		numZeros++
+	}
+	b = b[numZeros:]
+
+	// Convert the remaining big-endian bytes to a little-endian sequence
+	// of base 58^5 limbs.  The input is consumed 32 bits at a time, except
+	// for the first chunk which takes any bytes left over so the rest
+	// divide evenly.  Each chunk multiplies the existing limbs by 2^bits
+	// and adds the chunk value, which fits in a uint64 since each limb is
+	// less than 2^32.
+	limbs := make([]uint32, 0, len(b)*138/100/5+1)
+	for len(b) > 0 {
+		n := len(b) % 4
+		if n == 0 {
+			n = 4
+		}
+		var carry uint64
+		for _, c := range b[:n] {
+			carry = carry<<8 | uint64(c)
+		}
+		b = b[n:]
+
+		bits := uint(n * 8)
+		for i, limb := range limbs {
+			x := uint64(limb)<<bits + carry
+			limbs[i] = uint32(x % radix58Pow5)
+			carry = x / radix58Pow5
+		}
+		for carry > 0 {
+			limbs = append(limbs, uint32(carry%radix58Pow5))
+			carry /= radix58Pow5
 		}
-		answer = append(answer, alphabetIdx0)
+	}
+
+	// Write the digits of each limb, least significant first, and remove
+	// the zero digits written past the most significant digit.
+	answer := make([]byte, 0, numZeros+len(limbs)*5)
+	for _, limb := range limbs {
+		for i := 0; i < 5; i++ {
+			answer = append(answer, alphabet[limb%58])
+			limb /= 58
+		}
+	}
+	for len(answer) > 0 && answer[len(answer)-1] == alphabet[0] {
+		answer = answer[:len(answer)-1]
+	}
+	for i := 0; i < numZeros; i++ {
+		answer = append(answer, alphabet[0])
 	}
 
 	// reverse
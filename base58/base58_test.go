@@ -7,6 +7,9 @@ package base58_test
 import (
 	"bytes"
 	"encoding/hex"
+	"math/big"
+	"math/rand"
+	"strings"
 	"testing"
 
 	"github.com/btcsuite/btcutil/base58"
@@ -96,3 +99,263 @@ func TestBase58(t *testing.T) {
 		}
 	}
 }
+
+func TestBase58Alphabet(t *testing.T) {
+	// The Ripple genesis account, which is check encoded with a zero
+	// version byte.
+	rippleAccount := "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh"
+	wantAccount, _ := hex.DecodeString("00b5f762798a53d543a014caf8b297cff8" +
+		"f2f937e8bf32ba9f")
+	res, err := base58.DecodeAlphabet(rippleAccount, base58.RippleAlphabet)
+	if err != nil {
+		t.Fatalf("DecodeAlphabet: unexpected error: %v", err)
+	}
+	if !bytes.Equal(res, wantAccount) {
+		t.Errorf("DecodeAlphabet: got %x want %x", res, wantAccount)
+	}
+	if enc := base58.EncodeAlphabet(wantAccount, base58.RippleAlphabet); enc != rippleAccount {
+		t.Errorf("EncodeAlphabet: got %s want %s", enc, rippleAccount)
+	}
+
+	// Round trip the hex test vectors through each alphabet and ensure
+	// the Bitcoin alphabet matches Encode and Decode.
+	alphabets := []string{
+		"123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz",
+		base58.RippleAlphabet,
+		base58.FlickrAlphabet,
+	}
+	for x, test := range hexTests {
+		b, _ := hex.DecodeString(test.in)
+		for _, alphabet := range alphabets {
+			enc := base58.EncodeAlphabet(b, alphabet)
+			res, err := base58.DecodeAlphabet(enc, alphabet)
+			if err != nil || !bytes.Equal(res, b) {
+				t.Errorf("Alphabet test #%d (%s) failed: got: %x "+
+					"(err %v) want: %x", x, alphabet, res, err, b)
+			}
+		}
+		if enc := base58.EncodeAlphabet(b, alphabets[0]); enc != test.out {
+			t.Errorf("Bitcoin alphabet test #%d failed: got: %s "+
+				"want: %s", x, enc, test.out)
+		}
+	}
+
+	// Decoding with a different alphabet than was used to encode must
+	// not reproduce the data.
+	data := []byte("simply a long string")
+	enc := base58.EncodeAlphabet(data, base58.RippleAlphabet)
+	if res, err := base58.DecodeAlphabet(enc, base58.FlickrAlphabet); err == nil &&
+		bytes.Equal(res, data) {
+		t.Errorf("DecodeAlphabet with mismatched alphabet reproduced data")
+	}
+	if res := base58.Decode(enc); bytes.Equal(res, data) {
+		t.Errorf("Decode of Ripple encoding reproduced data")
+	}
+
+	// Characters absent from the alphabet are rejected.
+	_, err = base58.DecodeAlphabet("rpsh0", base58.RippleAlphabet)
+	if err != base58.ErrInvalidCharacter {
+		t.Errorf("DecodeAlphabet: got error %v want %v", err,
+			base58.ErrInvalidCharacter)
+	}
+
+	// Malformed alphabets are rejected.
+	badAlphabets := []string{
+		"",
+		base58.RippleAlphabet[:57],
+		base58.RippleAlphabet + "0",
+		"r" + base58.RippleAlphabet[1:57] + "r",
+	}
+	for x, alphabet := range badAlphabets {
+		_, err := base58.DecodeAlphabet("r", alphabet)
+		if err != base58.ErrInvalidAlphabet {
+			t.Errorf("Bad alphabet test #%d: got error %v want %v", x,
+				err, base58.ErrInvalidAlphabet)
+		}
+
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Bad alphabet test #%d: EncodeAlphabet "+
+						"did not panic", x)
+				}
+			}()
+			base58.EncodeAlphabet([]byte{1}, alphabet)
+		}()
+	}
+}
+
+// TestIndexOf ensures IndexOf returns the value of each character of the
+// Bitcoin alphabet and -1 for characters outside of it.
+func TestIndexOf(t *testing.T) {
+	tests := []struct {
+		c    byte
+		want int
+	}{
+		{'1', 0},
+		{'9', 8},
+		{'A', 9},
+		{'H', 16},
+		{'Z', 32},
+		{'a', 33},
+		{'k', 43},
+		{'z', 57},
+		// Characters excluded from the alphabet to avoid ambiguity.
+		{'0', -1},
+		{'O', -1},
+		{'I', -1},
+		{'l', -1},
+		{'+', -1},
+		{0xff, -1},
+	}
+
+	for _, test := range tests {
+		if got := base58.IndexOf(test.c); got != test.want {
+			t.Errorf("IndexOf(%q): got %d, want %d", test.c, got,
+				test.want)
+		}
+	}
+
+	// Every character of the alphabet maps back to its index.
+	for i := 0; i < len(base58.Alphabet); i++ {
+		if got := base58.IndexOf(base58.Alphabet[i]); got != i {
+			t.Errorf("IndexOf(%q): got %d, want %d",
+				base58.Alphabet[i], got, i)
+		}
+	}
+}
+
+// bigEncode is a straightforward big.Int based reference implementation of
+// base58 encoding with the Bitcoin alphabet.
+func bigEncode(b []byte) string {
+	const alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+	x := new(big.Int).SetBytes(b)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+	var answer []byte
+	for x.Sign() > 0 {
+		x.DivMod(x, radix, mod)
+		answer = append([]byte{alphabet[mod.Int64()]}, answer...)
+	}
+	for _, c := range b {
+		if c != 0 {
+			break
+		}
+		answer = append([]byte{alphabet[0]}, answer...)
+	}
+	return string(answer)
+}
+
+// TestEncodeMatchesBigInt ensures Encode produces the same result as a big.Int
+// based implementation for many inputs of varying lengths, including empty,
+// all-zero, and all 0xff inputs, and that the result decodes to the input.
+func TestEncodeMatchesBigInt(t *testing.T) {
+	inputs := [][]byte{nil, {}}
+	for n := 1; n <= 80; n++ {
+		inputs = append(inputs, make([]byte, n))
+		inputs = append(inputs, bytes.Repeat([]byte{0xff}, n))
+		leadingZeros := make([]byte, n)
+		leadingZeros[n-1] = 1
+		inputs = append(inputs, leadingZeros)
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		b := make([]byte, rng.Intn(300))
+		rng.Read(b)
+
+		// Give some of the inputs leading zeros.
+		if len(b) > 0 && i%4 == 0 {
+			for j := 0; j < rng.Intn(len(b)); j++ {
+				b[j] = 0
+			}
+		}
+		inputs = append(inputs, b)
+	}
+
+	for x, b := range inputs {
+		want := bigEncode(b)
+		got := base58.Encode(b)
+		if got != want {
+			t.Errorf("Encode #%d (%x): got %s want %s", x, b, got, want)
+			continue
+		}
+		if res := base58.Decode(got); !bytes.Equal(res, b) {
+			t.Errorf("Decode #%d (%s): got %x want %x", x, got, res, b)
+		}
+	}
+}
+
+// TestDecodeConstantTime ensures DecodeConstantTime produces the same result
+// as Decode for valid inputs and rejects invalid inputs.
+func TestDecodeConstantTime(t *testing.T) {
+	var inputs []string
+	for _, test := range stringTests {
+		inputs = append(inputs, test.out)
+	}
+	for _, test := range hexTests {
+		inputs = append(inputs, test.out)
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		b := make([]byte, rng.Intn(100))
+		rng.Read(b)
+		if len(b) > 0 && i%4 == 0 {
+			for j := 0; j < rng.Intn(len(b)); j++ {
+				b[j] = 0
+			}
+		}
+		inputs = append(inputs, base58.Encode(b))
+	}
+
+	for x, s := range inputs {
+		res, err := base58.DecodeConstantTime(s)
+		if err != nil {
+			t.Errorf("DecodeConstantTime #%d (%s): unexpected error: %v",
+				x, s, err)
+			continue
+		}
+		if want := base58.Decode(s); !bytes.Equal(res, want) {
+			t.Errorf("DecodeConstantTime #%d (%s): got %x want %x", x, s,
+				res, want)
+		}
+	}
+
+	for x, test := range invalidStringTests {
+		res, err := base58.DecodeConstantTime(test.in)
+		if err != base58.ErrInvalidCharacter || res != nil {
+			t.Errorf("DecodeConstantTime invalidString test #%d (%q): "+
+				"got %x (err %v) want err %v", x, test.in, res, err,
+				base58.ErrInvalidCharacter)
+		}
+	}
+
+	// The work performed must not depend on the characters of the input.
+	// Inputs of the same length which decode to results of the same length
+	// must allocate identically, and an invalid character is only reported
+	// after the whole input has been processed, wherever it appears.
+	wif := "5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTJ"
+	same := []string{
+		wif,
+		strings.Repeat("z", len(wif)-1) + "5",
+		"5" + strings.Repeat("z", len(wif)-1),
+	}
+	var wantAllocs float64
+	for i, s := range same {
+		allocs := testing.AllocsPerRun(10, func() {
+			base58.DecodeConstantTime(s)
+		})
+		if i == 0 {
+			wantAllocs = allocs
+		} else if allocs != wantAllocs {
+			t.Errorf("DecodeConstantTime (%s): got %v allocs want %v", s,
+				allocs, wantAllocs)
+		}
+	}
+	for _, s := range []string{"0" + wif[1:], wif[:len(wif)-1] + "0"} {
+		if _, err := base58.DecodeConstantTime(s); err != base58.ErrInvalidCharacter {
+			t.Errorf("DecodeConstantTime (%s): got err %v want %v", s, err,
+				base58.ErrInvalidCharacter)
+		}
+	}
+}
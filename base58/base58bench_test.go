@@ -22,6 +22,18 @@ func BenchmarkBase58Encode(b *testing.B) {
 	}
 }
 
+func BenchmarkBase58EncodeAddress(b *testing.B) {
+	// A version byte, RIPEMD160 hash, and checksum as encoded in a
+	// pay-to-pubkey-hash address.
+	data := append([]byte{0x00}, bytes.Repeat([]byte{0xa5}, 24)...)
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		base58.Encode(data)
+	}
+}
+
 func BenchmarkBase58Decode(b *testing.B) {
 	b.StopTimer()
 	data := bytes.Repeat([]byte{0xff}, 5000)
@@ -33,3 +45,15 @@ func BenchmarkBase58Decode(b *testing.B) {
 		base58.Decode(encoded)
 	}
 }
+
+func BenchmarkBase58DecodeConstantTime(b *testing.B) {
+	b.StopTimer()
+	data := bytes.Repeat([]byte{0xff}, 5000)
+	encoded := base58.Encode(data)
+	b.SetBytes(int64(len(encoded)))
+	b.StartTimer()
+
+	for i := 0; i < b.N; i++ {
+		base58.DecodeConstantTime(encoded)
+	}
+}
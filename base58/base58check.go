@@ -36,17 +36,39 @@ func CheckEncode(input []byte, version byte) string {
 
 // CheckDecode decodes a string that was encoded with CheckEncode and verifies the checksum.
 func CheckDecode(input string) (result []byte, version byte, err error) {
+	decoded, err := checkDecode(input)
+	if err != nil {
+		return nil, 0, err
+	}
+	version = decoded[0]
+	payload := decoded[1 : len(decoded)-4]
+	result = append(result, payload...)
+	return
+}
+
+// CheckPrefix returns the version byte of a string that was encoded with
+// CheckEncode without copying out its payload.  The checksum is still fully
+// verified, so no version is reported for corrupt input.
+func CheckPrefix(input string) (version byte, err error) {
+	decoded, err := checkDecode(input)
+	if err != nil {
+		return 0, err
+	}
+	return decoded[0], nil
+}
+
+// checkDecode decodes a string that was encoded with CheckEncode and verifies
+// the checksum, returning the decoded bytes including the version byte and
+// checksum.
+func checkDecode(input string) ([]byte, error) {
 	decoded := Decode(input)
 	if len(decoded) < 5 {
-		return nil, 0, ErrInvalidFormat
+		return nil, ErrInvalidFormat
 	}
-	version = decoded[0]
 	var cksum [4]byte
 	copy(cksum[:], decoded[len(decoded)-4:])
 	if checksum(decoded[:len(decoded)-4]) != cksum {
-		return nil, 0, ErrChecksum
+		return nil, ErrChecksum
 	}
-	payload := decoded[1 : len(decoded)-4]
-	result = append(result, payload...)
-	return
+	return decoded, nil
 }
@@ -64,3 +64,41 @@ func TestBase58Check(t *testing.T) {
 	}
 
 }
+
+func TestCheckPrefix(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		version byte
+		err     error
+	}{
+		{"mainnet p2pkh", "1MirQ9bwyQcGVJPwKUgapu5ouK2E2Ey4gX", 0x00, nil},
+		{"mainnet p2sh", "3QJmV3qfvL9SuYo34YihAf3sRCW3qSinyC", 0x05, nil},
+		{"testnet p2pkh", "mrX9vMRYLfVy1BnZbc5gZjuyaqH3ZW2ZHz", 0x6f, nil},
+		{"bad checksum", "1MirQ9bwyQcGVJPwKUgapu5ouK2E2Ey4gY", 0, base58.ErrChecksum},
+		{"too short", "3MNQE1", 0, base58.ErrInvalidFormat},
+		{"empty", "", 0, base58.ErrInvalidFormat},
+	}
+
+	for _, test := range tests {
+		version, err := base58.CheckPrefix(test.in)
+		if err != test.err {
+			t.Errorf("CheckPrefix %s: got error %v, want %v", test.name,
+				err, test.err)
+			continue
+		}
+		if version != test.version {
+			t.Errorf("CheckPrefix %s: got version %#02x, want %#02x",
+				test.name, version, test.version)
+		}
+	}
+
+	// The version must match the one reported by CheckDecode.
+	for x, test := range checkEncodingStringTests {
+		version, err := base58.CheckPrefix(test.out)
+		if err != nil || version != test.version {
+			t.Errorf("CheckPrefix test #%d failed: got version %d, "+
+				"err %v, want version %d", x, version, err, test.version)
+		}
+	}
+}
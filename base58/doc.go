@@ -15,7 +15,9 @@ excluded.  Due to this, there can be various base58 alphabets.
 
 The modified base58 alphabet used by Bitcoin, and hence this package, omits the
 0, O, I, and l characters that look the same in many fonts and are therefore
-hard to humans to distinguish.
+hard to humans to distinguish.  The EncodeAlphabet and DecodeAlphabet functions
+may be used to work with other alphabets, such as those used by Ripple and
+Flickr.
 
 Base58Check Encoding Scheme
 
@@ -6,6 +6,7 @@ package btcutil
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 
@@ -27,18 +28,50 @@ func (e OutOfRangeError) Error() string {
 	return string(e)
 }
 
+var (
+	// ErrNoTransactions describes an error where a block does not contain
+	// any transactions, so it is also missing the required coinbase.
+	ErrNoTransactions = errors.New("block does not contain any transactions")
+
+	// ErrFirstTxNotCoinBase describes an error where the first transaction
+	// in a block is not a coinbase.
+	ErrFirstTxNotCoinBase = errors.New("first transaction in block is not a coinbase")
+
+	// ErrMultipleCoinBases describes an error where a transaction other
+	// than the first in a block is a coinbase.
+	ErrMultipleCoinBases = errors.New("block contains a second coinbase")
+)
+
+// TxSanityError describes a transaction in a block which violates the
+// structure checked by CheckTransactionSanity.
+type TxSanityError struct {
+	Index int   // Index of the transaction within the block
+	Err   error // ErrFirstTxNotCoinBase or ErrMultipleCoinBases
+}
+
+// Error satisfies the error interface and prints human-readable errors.
+func (e *TxSanityError) Error() string {
+	return fmt.Sprintf("transaction %d: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the underlying error describing the violation.
+func (e *TxSanityError) Unwrap() error {
+	return e.Err
+}
+
 // Block defines a bitcoin block that provides easier and more efficient
 // manipulation of raw blocks.  It also memoizes hashes for the block and its
 // transactions on their first access so subsequent accesses don't have to
 // repeat the relatively expensive hashing operations.
 type Block struct {
-	msgBlock                 *wire.MsgBlock  // Underlying MsgBlock
-	serializedBlock          []byte          // Serialized bytes for the block
-	serializedBlockNoWitness []byte          // Serialized bytes for block w/o witness data
-	blockHash                *chainhash.Hash // Cached block hash
-	blockHeight              int32           // Height in the main block chain
-	transactions             []*Tx           // Transactions
-	txnsGenerated            bool            // ALL wrapped transactions generated
+	msgBlock                 *wire.MsgBlock         // Underlying MsgBlock
+	serializedBlock          []byte                 // Serialized bytes for the block
+	serializedBlockNoWitness []byte                 // Serialized bytes for block w/o witness data
+	blockHash                *chainhash.Hash        // Cached block hash
+	blockHeight              int32                  // Height in the main block chain
+	transactions             []*Tx                  // Transactions
+	txnsGenerated            bool                   // ALL wrapped transactions generated
+	txIndex                  map[chainhash.Hash]int // Cached transaction hash to index
 }
 
 // MsgBlock returns the underlying wire.MsgBlock for the Block.
@@ -92,7 +125,10 @@ func (b *Block) BytesNoWitness() ([]byte, error) {
 
 // Hash returns the block identifier hash for the Block.  This is equivalent to
 // calling BlockHash on the underlying wire.MsgBlock, however it caches the
-// result so subsequent calls are more efficient.
+// result so subsequent calls are more efficient and return the same hash.
+//
+// The Block is treated as immutable, so the cached hash is not recomputed if
+// the header of the underlying wire.MsgBlock is modified after the first call.
 func (b *Block) Hash() *chainhash.Hash {
 	// Return the cached block hash if it has already been generated.
 	if b.blockHash != nil {
@@ -168,6 +204,47 @@ func (b *Block) Transactions() []*Tx {
 	return b.transactions
 }
 
+// ForEachTx calls f with the index and wrapped transaction (btcutil.Tx) of
+// each transaction in the Block, in order.  Iteration stops as soon as f
+// returns a non-nil error, which is then returned to the caller.  Unlike
+// Transactions, the wrapped transactions are only generated as they are
+// visited, so stopping early avoids wrapping the remaining transactions.
+// They are cached in the same way as Tx, so subsequent calls to Tx and
+// Transactions reuse them.
+func (b *Block) ForEachTx(f func(i int, tx *Tx) error) error {
+	// Iterate the cached transactions directly when they have ALL already
+	// been generated.
+	if b.txnsGenerated {
+		for i, tx := range b.transactions {
+			if err := f(i, tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Generate slice to hold all of the wrapped transactions if needed.
+	if len(b.transactions) == 0 {
+		b.transactions = make([]*Tx, len(b.msgBlock.Transactions))
+	}
+
+	// Generate and cache the wrapped transactions that haven't already
+	// been done as they are visited.
+	for i, tx := range b.transactions {
+		if tx == nil {
+			tx = NewTx(b.msgBlock.Transactions[i])
+			tx.SetIndex(i)
+			b.transactions[i] = tx
+		}
+		if err := f(i, tx); err != nil {
+			return err
+		}
+	}
+
+	b.txnsGenerated = true
+	return nil
+}
+
 // TxHash returns the hash for the requested transaction number in the Block.
 // The supplied index is 0 based.  That is to say, the first transaction in the
 // block is txNum 0.  This is equivalent to calling TxHash on the underlying
@@ -187,6 +264,29 @@ func (b *Block) TxHash(txNum int) (*chainhash.Hash, error) {
 	return tx.Hash(), nil
 }
 
+// TxByHash returns the wrapped transaction (btcutil.Tx) in the Block with the
+// passed hash along with whether or not it was found.  A map of transaction
+// hashes to their index in the block is built and cached on the first call,
+// so subsequent lookups do not need to scan the transactions.  Should the
+// block contain more than one transaction with the same hash, the first is
+// returned.
+func (b *Block) TxByHash(hash *chainhash.Hash) (*Tx, bool) {
+	// Generate and cache the transaction index if needed.
+	if b.txIndex == nil {
+		transactions := b.Transactions()
+		b.txIndex = make(map[chainhash.Hash]int, len(transactions))
+		for i := len(transactions) - 1; i >= 0; i-- {
+			b.txIndex[*transactions[i].Hash()] = i
+		}
+	}
+
+	txNum, ok := b.txIndex[*hash]
+	if !ok {
+		return nil, false
+	}
+	return b.transactions[txNum], true
+}
+
 // TxLoc returns the offsets and lengths of each transaction in a raw block.
 // It is used to allow fast indexing into transactions within the raw byte
 // stream.
@@ -205,6 +305,108 @@ func (b *Block) TxLoc() ([]wire.TxLoc, error) {
 	return txLocs, err
 }
 
+// CalcMerkleRoot calculates the merkle root of the transactions in the Block.
+// The merkle tree is built from the transaction hashes in the order they
+// appear in the block.  When a level of the tree has an odd number of
+// hashes, the last hash is paired with itself as required by the consensus
+// rules.  The root of a block with a single transaction is the hash of that
+// transaction, while a block with no transactions has a zero root.
+func (b *Block) CalcMerkleRoot() *chainhash.Hash {
+	transactions := b.Transactions()
+	if len(transactions) == 0 {
+		return &chainhash.Hash{}
+	}
+
+	level := make([]chainhash.Hash, len(transactions))
+	for i, tx := range transactions {
+		level[i] = *tx.Hash()
+	}
+
+	var buf [chainhash.HashSize * 2]byte
+	for len(level) > 1 {
+		// Duplicate the last hash of a level with an odd number of
+		// hashes.
+		if len(level)%2 != 0 {
+			level = append(level, level[len(level)-1])
+		}
+
+		// Hash each pair of hashes into the next level.  The next level
+		// is built in place since each hash is only read once.
+		for i := 0; i < len(level)/2; i++ {
+			copy(buf[:chainhash.HashSize], level[2*i][:])
+			copy(buf[chainhash.HashSize:], level[2*i+1][:])
+			level[i] = chainhash.DoubleHashH(buf[:])
+		}
+		level = level[:len(level)/2]
+	}
+
+	root := level[0]
+	return &root
+}
+
+// CheckMerkleRoot returns whether the merkle root in the header of the Block
+// matches the merkle root calculated from its transactions.
+func (b *Block) CheckMerkleRoot() bool {
+	return b.CalcMerkleRoot().IsEqual(&b.msgBlock.Header.MerkleRoot)
+}
+
+// CheckTransactionSanity performs a quick check of the structure of the
+// transactions in the Block, which is useful before any further processing of
+// a block received from an untrusted source.  ErrNoTransactions is returned
+// when the block has no transactions.  Otherwise, a *TxSanityError
+// identifying the offending transaction is returned when the first
+// transaction is not a coinbase, or when any later transaction is a coinbase.
+// No other consensus rules are checked.
+func (b *Block) CheckTransactionSanity() error {
+	if len(b.msgBlock.Transactions) == 0 {
+		return ErrNoTransactions
+	}
+	return b.ForEachTx(func(i int, tx *Tx) error {
+		isCoinBase := tx.IsCoinBase()
+		if i == 0 && !isCoinBase {
+			return &TxSanityError{Index: i, Err: ErrFirstTxNotCoinBase}
+		}
+		if i != 0 && isCoinBase {
+			return &TxSanityError{Index: i, Err: ErrMultipleCoinBases}
+		}
+		return nil
+	})
+}
+
+// UTXORecord describes a single transaction output created by a block along
+// with the information needed to add it to a set of unspent transaction
+// outputs.
+type UTXORecord struct {
+	OutPoint wire.OutPoint
+	Amount   Amount
+	PkScript []byte
+	Height   int32
+}
+
+// NewOutputs returns a record for every transaction output created by the
+// Block in the order they appear in the block.  The passed height is
+// recorded as the height of each output since the height of the Block itself
+// may not be known.
+func (b *Block) NewOutputs(height int32) []UTXORecord {
+	numOutputs := 0
+	for _, msgTx := range b.msgBlock.Transactions {
+		numOutputs += len(msgTx.TxOut)
+	}
+
+	records := make([]UTXORecord, 0, numOutputs)
+	for _, tx := range b.Transactions() {
+		for i, txOut := range tx.MsgTx().TxOut {
+			records = append(records, UTXORecord{
+				OutPoint: *wire.NewOutPoint(tx.Hash(), uint32(i)),
+				Amount:   Amount(txOut.Value),
+				PkScript: txOut.PkScript,
+				Height:   height,
+			})
+		}
+	}
+	return records
+}
+
 // Height returns the saved height of the block in the block chain.  This value
 // will be BlockHeightUnknown if it hasn't already explicitly been set.
 func (b *Block) Height() int32 {
@@ -229,7 +431,7 @@ func NewBlock(msgBlock *wire.MsgBlock) *Block {
 // serialized bytes.  See Block.
 func NewBlockFromBytes(serializedBlock []byte) (*Block, error) {
 	br := bytes.NewReader(serializedBlock)
-	b, err := NewBlockFromReader(br)
+	b, err := newBlockFromReader(br)
 	if err != nil {
 		return nil, err
 	}
@@ -238,8 +440,23 @@ func NewBlockFromBytes(serializedBlock []byte) (*Block, error) {
 }
 
 // NewBlockFromReader returns a new instance of a bitcoin block given a
-// Reader to deserialize the block.  See Block.
+// Reader to deserialize the block.  Only the bytes of the block are read, so
+// the Reader may be used to read any data following it.  The bytes read are
+// retained so that Bytes does not need to serialize the block again.  See
+// Block.
 func NewBlockFromReader(r io.Reader) (*Block, error) {
+	var buf bytes.Buffer
+	b, err := newBlockFromReader(io.TeeReader(r, &buf))
+	if err != nil {
+		return nil, err
+	}
+	b.serializedBlock = buf.Bytes()
+	return b, nil
+}
+
+// newBlockFromReader returns a new instance of a bitcoin block deserialized
+// from the passed Reader without retaining its serialized bytes.
+func newBlockFromReader(r io.Reader) (*Block, error) {
 	// Deserialize the bytes into a MsgBlock.
 	var msgBlock wire.MsgBlock
 	err := msgBlock.Deserialize(r)
@@ -6,6 +6,8 @@ package btcutil_test
 
 import (
 	"bytes"
+	"crypto/rand"
+	"errors"
 	"io"
 	"reflect"
 	"testing"
@@ -200,6 +202,74 @@ func TestNewBlockFromBytes(t *testing.T) {
 	}
 }
 
+// TestNewBlockFromReader tests creation of a Block from a stream.
+func TestNewBlockFromReader(t *testing.T) {
+	// Serialize the test block twice followed by some trailing data.
+	var block100000Buf bytes.Buffer
+	err := Block100000.Serialize(&block100000Buf)
+	if err != nil {
+		t.Errorf("Serialize: %v", err)
+	}
+	block100000Bytes := block100000Buf.Bytes()
+	trailing := []byte{0xde, 0xad, 0xbe, 0xef}
+	stream := bytes.Join([][]byte{block100000Bytes, block100000Bytes,
+		trailing}, nil)
+	r := bytes.NewReader(stream)
+
+	wantHash, err := chainhash.NewHashFromStr("000000000003ba27aa200b1cecaad478d2b00432346c3f1f3986da1afd33e506")
+	if err != nil {
+		t.Fatalf("NewHashFromStr: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		b, err := btcutil.NewBlockFromReader(r)
+		if err != nil {
+			t.Fatalf("NewBlockFromReader #%d: %v", i, err)
+		}
+
+		// Ensure the raw bytes were retained.
+		serializedBytes, err := b.Bytes()
+		if err != nil {
+			t.Fatalf("Bytes #%d: %v", i, err)
+		}
+		if !bytes.Equal(serializedBytes, block100000Bytes) {
+			t.Errorf("Bytes #%d: wrong bytes - got %v, want %v", i,
+				spew.Sdump(serializedBytes),
+				spew.Sdump(block100000Bytes))
+		}
+
+		if hash := b.Hash(); !hash.IsEqual(wantHash) {
+			t.Errorf("Hash #%d: mismatched hash - got %v, want %v", i,
+				hash, wantHash)
+		}
+		transactions := b.Transactions()
+		if len(transactions) != len(Block100000.Transactions) {
+			t.Fatalf("Transactions #%d: got %d transactions, want %d",
+				i, len(transactions), len(Block100000.Transactions))
+		}
+		for j, tx := range transactions {
+			wantTxHash := Block100000.Transactions[j].TxHash()
+			if !tx.Hash().IsEqual(&wantTxHash) {
+				t.Errorf("Transactions #%d: mismatched hash for "+
+					"transaction %d - got %v, want %v", i, j,
+					tx.Hash(), wantTxHash)
+			}
+		}
+	}
+
+	// Ensure only the bytes of the blocks were read.
+	if r.Len() != len(trailing) {
+		t.Errorf("NewBlockFromReader: got %d unread bytes, want %d",
+			r.Len(), len(trailing))
+	}
+
+	// A truncated stream produces an error.
+	short := bytes.NewReader(block100000Bytes[:len(block100000Bytes)-1])
+	if _, err := btcutil.NewBlockFromReader(short); err != io.ErrUnexpectedEOF {
+		t.Errorf("NewBlockFromReader: did not get expected error - "+
+			"got %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
 // TestNewBlockFromBlockAndBytes tests creation of a Block from a MsgBlock and
 // raw bytes.
 func TestNewBlockFromBlockAndBytes(t *testing.T) {
@@ -299,6 +369,346 @@ func TestBlockErrors(t *testing.T) {
 	}
 }
 
+// TestBlockMerkleRoot ensures the merkle root of a block is calculated and
+// validated properly.
+func TestBlockMerkleRoot(t *testing.T) {
+	// Merkle root for block 100,000 which has four transactions.
+	b := btcutil.NewBlock(&Block100000)
+	wantRoot, err := chainhash.NewHashFromStr("f3e94742aca4b5ef85488dc37c" +
+		"06c3282295ffec960994b2c0d5ac2a25a95766")
+	if err != nil {
+		t.Fatalf("NewHashFromStr: %v", err)
+	}
+	if root := b.CalcMerkleRoot(); !root.IsEqual(wantRoot) {
+		t.Errorf("CalcMerkleRoot: mismatched root - got %v, want %v",
+			root, wantRoot)
+	}
+	if !b.CheckMerkleRoot() {
+		t.Error("CheckMerkleRoot: valid block failed validation")
+	}
+
+	// The root of a block with a single transaction is the hash of the
+	// transaction.
+	single := btcutil.NewBlock(&wire.MsgBlock{
+		Header:       Block100000.Header,
+		Transactions: Block100000.Transactions[:1],
+	})
+	if root := single.CalcMerkleRoot(); !root.IsEqual(single.Transactions()[0].Hash()) {
+		t.Errorf("CalcMerkleRoot: mismatched single transaction root - "+
+			"got %v, want %v", root, single.Transactions()[0].Hash())
+	}
+	if single.CheckMerkleRoot() {
+		t.Error("CheckMerkleRoot: block with removed transactions " +
+			"passed validation")
+	}
+
+	// The last hash is duplicated when there are an odd number of hashes.
+	odd := btcutil.NewBlock(&wire.MsgBlock{
+		Header:       Block100000.Header,
+		Transactions: Block100000.Transactions[:3],
+	})
+	hashPair := func(a, b *chainhash.Hash) *chainhash.Hash {
+		h := chainhash.DoubleHashH(append(a[:], b[:]...))
+		return &h
+	}
+	txHashes := make([]*chainhash.Hash, 3)
+	for i, tx := range odd.Transactions() {
+		txHashes[i] = tx.Hash()
+	}
+	wantOddRoot := hashPair(hashPair(txHashes[0], txHashes[1]),
+		hashPair(txHashes[2], txHashes[2]))
+	if root := odd.CalcMerkleRoot(); !root.IsEqual(wantOddRoot) {
+		t.Errorf("CalcMerkleRoot: mismatched odd root - got %v, want %v",
+			root, wantOddRoot)
+	}
+
+	// Tampering with a transaction must invalidate the merkle root.
+	tamperedTxns := make([]*wire.MsgTx, len(Block100000.Transactions))
+	copy(tamperedTxns, Block100000.Transactions)
+	tamperedTxns[2] = tamperedTxns[2].Copy()
+	tamperedTxns[2].TxOut[0].Value++
+	tampered := btcutil.NewBlock(&wire.MsgBlock{
+		Header:       Block100000.Header,
+		Transactions: tamperedTxns,
+	})
+	if tampered.CheckMerkleRoot() {
+		t.Error("CheckMerkleRoot: tampered block passed validation")
+	}
+
+	// A block without any transactions has a zero merkle root.
+	empty := btcutil.NewBlock(&wire.MsgBlock{Header: Block100000.Header})
+	if root := empty.CalcMerkleRoot(); !root.IsEqual(&chainhash.Hash{}) {
+		t.Errorf("CalcMerkleRoot: non-zero root for empty block - got %v",
+			root)
+	}
+}
+
+// TestBlockHashCache ensures the block hash is computed once and the same
+// cached hash is returned by subsequent calls.
+func TestBlockHashCache(t *testing.T) {
+	msgBlock := Block100000
+	b := btcutil.NewBlock(&msgBlock)
+
+	// Hash for block 100,000.
+	wantHash, err := chainhash.NewHashFromStr("000000000003ba27aa200b1cec" +
+		"aad478d2b00432346c3f1f3986da1afd33e506")
+	if err != nil {
+		t.Fatalf("NewHashFromStr: %v", err)
+	}
+
+	hash := b.Hash()
+	if !hash.IsEqual(wantHash) {
+		t.Fatalf("Hash: mismatched hash - got %v, want %v", hash, wantHash)
+	}
+	if again := b.Hash(); again != hash {
+		t.Errorf("Hash: cached hash was not reused - got %p, want %p",
+			again, hash)
+	}
+
+	// The block is treated as immutable, so modifying the header does not
+	// invalidate the cached hash.
+	msgBlock.Header.Nonce++
+	if again := b.Hash(); again != hash || !again.IsEqual(wantHash) {
+		t.Errorf("Hash: cached hash changed after header modification "+
+			"- got %v, want %v", again, wantHash)
+	}
+	if newHash := btcutil.NewBlock(&msgBlock).Hash(); newHash.IsEqual(wantHash) {
+		t.Errorf("Hash: modified header has unchanged hash %v", newHash)
+	}
+}
+
+// TestBlockForEachTx ensures ForEachTx visits every transaction in order with
+// the correct index, stops as soon as the callback returns an error, and
+// shares the wrapped transactions with Tx and Transactions.
+func TestBlockForEachTx(t *testing.T) {
+	b := btcutil.NewBlock(&Block100000)
+	numTx := len(Block100000.Transactions)
+
+	// Stop after the second transaction.
+	errStop := errors.New("stop")
+	visited := make([]*btcutil.Tx, 0, numTx)
+	err := b.ForEachTx(func(i int, tx *btcutil.Tx) error {
+		if i != len(visited) {
+			t.Errorf("ForEachTx: unexpected index - got %d, want %d",
+				i, len(visited))
+		}
+		visited = append(visited, tx)
+		if i == 1 {
+			return errStop
+		}
+		return nil
+	})
+	if err != errStop {
+		t.Fatalf("ForEachTx: unexpected error - got %v, want %v", err,
+			errStop)
+	}
+	if len(visited) != 2 {
+		t.Fatalf("ForEachTx: iteration did not stop - visited %d "+
+			"transactions, want 2", len(visited))
+	}
+
+	// The visited transactions are cached and shared with Tx.
+	for i, tx := range visited {
+		cachedTx, err := b.Tx(i)
+		if err != nil {
+			t.Fatalf("Tx #%d: unexpected error: %v", i, err)
+		}
+		if cachedTx != tx {
+			t.Errorf("Tx #%d: wrapped transaction was not cached", i)
+		}
+		if tx.Index() != i {
+			t.Errorf("ForEachTx #%d: wrong transaction index - got %d",
+				i, tx.Index())
+		}
+	}
+
+	// A full iteration visits all transactions with the same wrapped
+	// transactions returned by Transactions.
+	var all []*btcutil.Tx
+	err = b.ForEachTx(func(i int, tx *btcutil.Tx) error {
+		if tx.MsgTx() != Block100000.Transactions[i] {
+			t.Errorf("ForEachTx #%d: wrong transaction", i)
+		}
+		if tx.Index() != i {
+			t.Errorf("ForEachTx #%d: wrong transaction index - got %d",
+				i, tx.Index())
+		}
+		all = append(all, tx)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ForEachTx: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(all, b.Transactions()) {
+		t.Errorf("ForEachTx: visited transactions do not match " +
+			"Transactions")
+	}
+	for i := range all {
+		if all[i] != b.Transactions()[i] {
+			t.Errorf("Transactions #%d: wrapped transaction was not "+
+				"reused", i)
+		}
+	}
+
+	// Iteration after all transactions are generated also stops early.
+	count := 0
+	err = b.ForEachTx(func(i int, tx *btcutil.Tx) error {
+		count++
+		return errStop
+	})
+	if err != errStop || count != 1 {
+		t.Errorf("ForEachTx: got error %v after %d transactions, want "+
+			"%v after 1", err, count, errStop)
+	}
+}
+
+// TestBlockTxByHash ensures TxByHash returns the wrapped transaction with the
+// requested hash, shares it with Tx, and reports a miss for unknown hashes.
+func TestBlockTxByHash(t *testing.T) {
+	b := btcutil.NewBlock(&Block100000)
+
+	for i, msgTx := range Block100000.Transactions {
+		hash := msgTx.TxHash()
+		tx, ok := b.TxByHash(&hash)
+		if !ok {
+			t.Errorf("TxByHash #%d: transaction %v not found", i, hash)
+			continue
+		}
+		if tx.MsgTx() != msgTx {
+			t.Errorf("TxByHash #%d: wrong transaction", i)
+		}
+		if tx.Index() != i {
+			t.Errorf("TxByHash #%d: wrong transaction index - got %d",
+				i, tx.Index())
+		}
+		cachedTx, err := b.Tx(i)
+		if err != nil {
+			t.Fatalf("Tx #%d: unexpected error: %v", i, err)
+		}
+		if cachedTx != tx {
+			t.Errorf("TxByHash #%d: wrapped transaction was not reused", i)
+		}
+	}
+
+	// A hash which is not in the block is not found.
+	var missing chainhash.Hash
+	if _, err := rand.Read(missing[:]); err != nil {
+		t.Fatalf("rand.Read: unexpected error: %v", err)
+	}
+	if tx, ok := b.TxByHash(&missing); ok {
+		t.Errorf("TxByHash: unexpected transaction %v for hash %v",
+			tx.Hash(), missing)
+	}
+}
+
+// TestBlockCheckTransactionSanity ensures CheckTransactionSanity accepts a
+// valid block and identifies the transaction violating each rule otherwise.
+func TestBlockCheckTransactionSanity(t *testing.T) {
+	coinbase := Block100000.Transactions[0]
+	regular := Block100000.Transactions[1:]
+	withTxs := func(txs ...*wire.MsgTx) *btcutil.Block {
+		msgBlock := wire.MsgBlock{Header: Block100000.Header}
+		msgBlock.Transactions = txs
+		return btcutil.NewBlock(&msgBlock)
+	}
+
+	tests := []struct {
+		name  string
+		block *btcutil.Block
+		err   error
+		index int
+	}{
+		{"valid block", btcutil.NewBlock(&Block100000), nil, 0},
+		{"coinbase only", withTxs(coinbase), nil, 0},
+		{"no transactions", withTxs(), btcutil.ErrNoTransactions, 0},
+		{"no coinbase", withTxs(regular...), btcutil.ErrFirstTxNotCoinBase, 0},
+		{"coinbase not first", withTxs(regular[0], coinbase),
+			btcutil.ErrFirstTxNotCoinBase, 0},
+		{"two coinbases", withTxs(coinbase, regular[0], coinbase),
+			btcutil.ErrMultipleCoinBases, 2},
+	}
+
+	for _, test := range tests {
+		err := test.block.CheckTransactionSanity()
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: mismatched error - got %v, want %v", test.name,
+				err, test.err)
+			continue
+		}
+		if test.err == nil || test.err == btcutil.ErrNoTransactions {
+			continue
+		}
+		var sanityErr *btcutil.TxSanityError
+		if !errors.As(err, &sanityErr) {
+			t.Errorf("%s: error %v is not a TxSanityError", test.name, err)
+			continue
+		}
+		if sanityErr.Index != test.index {
+			t.Errorf("%s: mismatched index - got %d, want %d", test.name,
+				sanityErr.Index, test.index)
+		}
+	}
+}
+
+// TestBlockNewOutputs ensures NewOutputs enumerates every output created by a
+// block with the correct outpoint, amount, script, and height.
+func TestBlockNewOutputs(t *testing.T) {
+	b := btcutil.NewBlock(&Block100000)
+
+	wantOutputs := []struct {
+		txHash string
+		index  uint32
+		amount btcutil.Amount
+	}{
+		{"8c14f0db3df150123e6f3dbbf30f8b955a8249b62ac1d1ff16284aefa3d06d87", 0, 5000000000},
+		{"fff2525b8931402dd09222c50775608f75787bd2b87e56995a7bdd30f79702c4", 0, 556000000},
+		{"fff2525b8931402dd09222c50775608f75787bd2b87e56995a7bdd30f79702c4", 1, 4444000000},
+		{"6359f0868171b1d194cbee1af2f16ea598ae8fad666d9b012c8ed2b79a236ec4", 0, 1000000},
+		{"6359f0868171b1d194cbee1af2f16ea598ae8fad666d9b012c8ed2b79a236ec4", 1, 299000000},
+		{"e9a66845e05d5abc0ad04ec80f774a7e585c6e8db975962d069a522137b80c1d", 0, 1000000},
+	}
+
+	height := int32(100000)
+	records := b.NewOutputs(height)
+	if len(records) != len(wantOutputs) {
+		t.Fatalf("NewOutputs: mismatched number of outputs - got %d, "+
+			"want %d", len(records), len(wantOutputs))
+	}
+
+	txNum := -1
+	var prevHash string
+	for i, want := range wantOutputs {
+		if want.txHash != prevHash {
+			txNum++
+			prevHash = want.txHash
+		}
+		record := records[i]
+
+		wantHash, err := chainhash.NewHashFromStr(want.txHash)
+		if err != nil {
+			t.Fatalf("NewHashFromStr: %v", err)
+		}
+		wantOutPoint := wire.NewOutPoint(wantHash, want.index)
+		if record.OutPoint != *wantOutPoint {
+			t.Errorf("NewOutputs #%d: mismatched outpoint - got %v, "+
+				"want %v", i, record.OutPoint, wantOutPoint)
+		}
+		if record.Amount != want.amount {
+			t.Errorf("NewOutputs #%d: mismatched amount - got %v, "+
+				"want %v", i, record.Amount, want.amount)
+		}
+		wantScript := Block100000.Transactions[txNum].TxOut[want.index].PkScript
+		if !bytes.Equal(record.PkScript, wantScript) {
+			t.Errorf("NewOutputs #%d: mismatched script - got %x, "+
+				"want %x", i, record.PkScript, wantScript)
+		}
+		if record.Height != height {
+			t.Errorf("NewOutputs #%d: mismatched height - got %d, "+
+				"want %d", i, record.Height, height)
+		}
+	}
+}
+
 // Block100000 defines block 100,000 of the block chain.  It is used to
 // test Block operations.
 var Block100000 = wire.MsgBlock{
@@ -6,9 +6,11 @@ package btcutil
 
 import (
 	"bytes"
+	"crypto"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/rsa"
 	_ "crypto/sha512" // Needed for RegisterHash in init
 	"crypto/x509"
 	"crypto/x509/pkix"
@@ -21,17 +23,162 @@ import (
 	"time"
 )
 
+// KeyType describes the type of private key generated for a certificate.
+type KeyType int
+
+// These constants define the supported private key types.
+const (
+	// KeyTypeECDSA generates an ECDSA private key on the curve specified
+	// by KeyParams.Curve.
+	KeyTypeECDSA KeyType = iota
+
+	// KeyTypeRSA generates an RSA private key with the modulus size
+	// specified by KeyParams.Bits.
+	KeyTypeRSA
+)
+
+const (
+	// minRSABits and maxRSABits define the range of modulus sizes allowed
+	// for generated RSA keys.
+	minRSABits = 2048
+	maxRSABits = 8192
+)
+
+// KeyParams describes the private key to generate for a certificate.  Curve
+// must only be set for ECDSA keys and must be one of P-256, P-384, or P-521.
+// Bits must only be set for RSA keys and must be between 2048 and 8192.
+type KeyParams struct {
+	Type  KeyType
+	Curve elliptic.Curve
+	Bits  int
+}
+
+// generateKey generates a new private key according to the key parameters
+// and returns it along with the signature algorithm to use for certificates
+// signed by it and its PEM encoding.
+func (p *KeyParams) generateKey() (crypto.Signer, x509.SignatureAlgorithm, *pem.Block, error) {
+	switch p.Type {
+	case KeyTypeECDSA:
+		if p.Bits != 0 {
+			return nil, 0, nil, errors.New("bits may not be " +
+				"specified for an ECDSA key")
+		}
+
+		var sigAlgo x509.SignatureAlgorithm
+		switch p.Curve {
+		case elliptic.P256():
+			sigAlgo = x509.ECDSAWithSHA256
+		case elliptic.P384():
+			sigAlgo = x509.ECDSAWithSHA384
+		case elliptic.P521():
+			sigAlgo = x509.ECDSAWithSHA512
+		default:
+			return nil, 0, nil, errors.New("unsupported ECDSA curve")
+		}
+
+		priv, err := ecdsa.GenerateKey(p.Curve, rand.Reader)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		keybytes, err := x509.MarshalECPrivateKey(priv)
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("failed to marshal "+
+				"private key: %v", err)
+		}
+		block := &pem.Block{Type: "EC PRIVATE KEY", Bytes: keybytes}
+		return priv, sigAlgo, block, nil
+
+	case KeyTypeRSA:
+		if p.Curve != nil {
+			return nil, 0, nil, errors.New("a curve may not be " +
+				"specified for an RSA key")
+		}
+		if p.Bits < minRSABits || p.Bits > maxRSABits {
+			return nil, 0, nil, fmt.Errorf("RSA key size must be "+
+				"between %d and %d bits", minRSABits, maxRSABits)
+		}
+
+		priv, err := rsa.GenerateKey(rand.Reader, p.Bits)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		keybytes := x509.MarshalPKCS1PrivateKey(priv)
+		block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: keybytes}
+		return priv, x509.SHA256WithRSA, block, nil
+
+	default:
+		return nil, 0, nil, fmt.Errorf("unsupported key type %d", p.Type)
+	}
+}
+
 // NewTLSCertPair returns a new PEM-encoded x.509 certificate pair
 // based on a 521-bit ECDSA private key.  The machine's local interface
 // addresses and all variants of IPv4 and IPv6 localhost are included as
 // valid IP addresses.
 func NewTLSCertPair(organization string, validUntil time.Time, extraHosts []string) (cert, key []byte, err error) {
-	now := time.Now()
-	if validUntil.Before(now) {
+	keyParams := KeyParams{Type: KeyTypeECDSA, Curve: elliptic.P521()}
+	return NewTLSCertPairEx(organization, validUntil, extraHosts, keyParams)
+}
+
+// NewTLSCertPairEx returns a new PEM-encoded x.509 certificate pair based on
+// a private key generated according to the passed key parameters.  It is
+// otherwise identical to NewTLSCertPair.  An error is returned if the key
+// parameters describe an unsupported key type, curve, or size.
+func NewTLSCertPairEx(organization string, validUntil time.Time, extraHosts []string, keyParams KeyParams) (cert, key []byte, err error) {
+	return NewTLSCertPairWithOptions(&CertOptions{
+		Organization: organization,
+		NotBefore:    time.Now().Add(-time.Hour * 24),
+		NotAfter:     validUntil,
+		ExtraHosts:   extraHosts,
+		KeyParams:    keyParams,
+	})
+}
+
+// CertOptions houses the parameters used to generate a certificate pair with
+// NewTLSCertPairWithOptions.
+type CertOptions struct {
+	// Organization is the organization of the certificate subject.
+	Organization string
+
+	// CommonName is the common name of the certificate subject.  It is
+	// also included as the first DNS name of the certificate.  The
+	// hostname of the machine is used when it is empty.
+	CommonName string
+
+	// NotBefore and NotAfter define the validity window of the
+	// certificate.  Both must be set, NotAfter must be after NotBefore,
+	// and NotAfter must not already be in the past.  NotAfter is limited
+	// to the end of ASN.1 time.
+	NotBefore time.Time
+	NotAfter  time.Time
+
+	// ExtraHosts are additional hostnames and IP addresses the
+	// certificate is valid for.
+	ExtraHosts []string
+
+	// KeyParams describes the private key to generate.
+	KeyParams KeyParams
+}
+
+// NewTLSCertPairWithOptions returns a new PEM-encoded x.509 certificate pair
+// generated according to the passed options.  The machine's local interface
+// addresses and all variants of IPv4 and IPv6 localhost are included as valid
+// IP addresses.
+func NewTLSCertPairWithOptions(opts *CertOptions) (cert, key []byte, err error) {
+	if opts.NotBefore.IsZero() || opts.NotAfter.IsZero() {
+		return nil, nil, errors.New("certificate validity window must " +
+			"have both a start and end time")
+	}
+	if !opts.NotAfter.After(opts.NotBefore) {
+		return nil, nil, errors.New("certificate validity window must " +
+			"end after it starts")
+	}
+	validUntil := opts.NotAfter
+	if validUntil.Before(time.Now()) {
 		return nil, nil, errors.New("validUntil would create an already-expired certificate")
 	}
 
-	priv, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	priv, sigAlgo, keyBlock, err := opts.KeyParams.generateKey()
 	if err != nil {
 		return nil, nil, err
 	}
@@ -48,9 +195,12 @@ func NewTLSCertPair(organization string, validUntil time.Time, extraHosts []stri
 		return nil, nil, fmt.Errorf("failed to generate serial number: %s", err)
 	}
 
-	host, err := os.Hostname()
-	if err != nil {
-		return nil, nil, err
+	host := opts.CommonName
+	if host == "" {
+		host, err = os.Hostname()
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
 	ipAddresses := []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")}
@@ -59,23 +209,6 @@ func NewTLSCertPair(organization string, validUntil time.Time, extraHosts []stri
 		dnsNames = append(dnsNames, "localhost")
 	}
 
-	addIP := func(ipAddr net.IP) {
-		for _, ip := range ipAddresses {
-			if bytes.Equal(ip, ipAddr) {
-				return
-			}
-		}
-		ipAddresses = append(ipAddresses, ipAddr)
-	}
-	addHost := func(host string) {
-		for _, dnsName := range dnsNames {
-			if host == dnsName {
-				return
-			}
-		}
-		dnsNames = append(dnsNames, host)
-	}
-
 	addrs, err := interfaceAddrs()
 	if err != nil {
 		return nil, nil, err
@@ -83,29 +216,19 @@ func NewTLSCertPair(organization string, validUntil time.Time, extraHosts []stri
 	for _, a := range addrs {
 		ipAddr, _, err := net.ParseCIDR(a.String())
 		if err == nil {
-			addIP(ipAddr)
+			ipAddresses = addIP(ipAddresses, ipAddr)
 		}
 	}
 
-	for _, hostStr := range extraHosts {
-		host, _, err := net.SplitHostPort(hostStr)
-		if err != nil {
-			host = hostStr
-		}
-		if ip := net.ParseIP(host); ip != nil {
-			addIP(ip)
-		} else {
-			addHost(host)
-		}
-	}
+	dnsNames, ipAddresses = addHosts(dnsNames, ipAddresses, opts.ExtraHosts)
 
 	template := x509.Certificate{
 		SerialNumber: serialNumber,
 		Subject: pkix.Name{
-			Organization: []string{organization},
+			Organization: []string{opts.Organization},
 			CommonName:   host,
 		},
-		NotBefore: now.Add(-time.Hour * 24),
+		NotBefore: opts.NotBefore,
 		NotAfter:  validUntil,
 
 		KeyUsage: x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature |
@@ -115,10 +238,12 @@ func NewTLSCertPair(organization string, validUntil time.Time, extraHosts []stri
 
 		DNSNames:    dnsNames,
 		IPAddresses: ipAddresses,
+
+		SignatureAlgorithm: sigAlgo,
 	}
 
 	derBytes, err := x509.CreateCertificate(rand.Reader, &template,
-		&template, &priv.PublicKey, priv)
+		&template, priv.Public(), priv)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create certificate: %v", err)
 	}
@@ -129,13 +254,150 @@ func NewTLSCertPair(organization string, validUntil time.Time, extraHosts []stri
 		return nil, nil, fmt.Errorf("failed to encode certificate: %v", err)
 	}
 
-	keybytes, err := x509.MarshalECPrivateKey(priv)
+	keyBuf := &bytes.Buffer{}
+	err = pem.Encode(keyBuf, keyBlock)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to marshal private key: %v", err)
+		return nil, nil, fmt.Errorf("failed to encode private key: %v", err)
+	}
+
+	return certBuf.Bytes(), keyBuf.Bytes(), nil
+}
+
+// addIP appends the IP address to ipAddresses unless it is already present.
+func addIP(ipAddresses []net.IP, ipAddr net.IP) []net.IP {
+	for _, ip := range ipAddresses {
+		if bytes.Equal(ip, ipAddr) {
+			return ipAddresses
+		}
+	}
+	return append(ipAddresses, ipAddr)
+}
+
+// addHosts appends each of the hosts, which may include a port, to either
+// the DNS names or IP addresses depending on whether it parses as an IP
+// address.  Hosts which are already present are skipped.
+func addHosts(dnsNames []string, ipAddresses []net.IP, hosts []string) ([]string, []net.IP) {
+	for _, hostStr := range hosts {
+		host, _, err := net.SplitHostPort(hostStr)
+		if err != nil {
+			host = hostStr
+		}
+		if ip := net.ParseIP(host); ip != nil {
+			ipAddresses = addIP(ipAddresses, ip)
+			continue
+		}
+		duplicate := false
+		for _, dnsName := range dnsNames {
+			if host == dnsName {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			dnsNames = append(dnsNames, host)
+		}
+	}
+	return dnsNames, ipAddresses
+}
+
+// NewSignedCertPair returns a new PEM-encoded x.509 certificate pair for a
+// server which is signed by the passed certificate authority rather than
+// self-signed.  This allows building a small private PKI for RPC servers
+// where clients only need to trust the CA certificate.  The certificate is
+// based on a 256-bit ECDSA private key, is valid for the passed hosts, which
+// may be hostnames or IP addresses, and may only be used for server
+// authentication.  The first hostname, or the first IP address if there are
+// no hostnames, is used as the common name.
+//
+// An error is returned if there are no hosts, if caKey is not the private
+// key for caCert, if caCert is not a certificate authority, or if validUntil
+// is already past or after caCert expires.
+func NewSignedCertPair(hosts []string, validUntil time.Time, caCert *x509.Certificate, caKey crypto.PrivateKey) (certPEM, keyPEM []byte, err error) {
+	if len(hosts) == 0 {
+		return nil, nil, errors.New("at least one host is required")
+	}
+	if caCert == nil {
+		return nil, nil, errors.New("no CA certificate")
+	}
+	if !caCert.IsCA || !caCert.BasicConstraintsValid ||
+		caCert.KeyUsage&x509.KeyUsageCertSign == 0 {
+		return nil, nil, errors.New("CA certificate can not be used to " +
+			"sign certificates")
+	}
+	signer, ok := caKey.(crypto.Signer)
+	if !ok {
+		return nil, nil, errors.New("CA private key can not sign")
+	}
+	caPub, ok := signer.Public().(interface {
+		Equal(crypto.PublicKey) bool
+	})
+	if !ok || !caPub.Equal(caCert.PublicKey) {
+		return nil, nil, errors.New("CA private key does not match the " +
+			"CA certificate")
+	}
+	if validUntil.Before(time.Now()) {
+		return nil, nil, errors.New("validUntil would create an already-expired certificate")
+	}
+	if validUntil.After(caCert.NotAfter) {
+		return nil, nil, errors.New("validUntil is after the CA " +
+			"certificate expires")
+	}
+
+	keyParams := KeyParams{Type: KeyTypeECDSA, Curve: elliptic.P256()}
+	priv, _, keyBlock, err := keyParams.generateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
+	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate serial number: %s", err)
+	}
+
+	dnsNames, ipAddresses := addHosts(nil, nil, hosts)
+	commonName := ""
+	switch {
+	case len(dnsNames) > 0:
+		commonName = dnsNames[0]
+	case len(ipAddresses) > 0:
+		commonName = ipAddresses[0].String()
+	}
+
+	// The issuer is set from the CA certificate subject when the
+	// certificate is created.
+	template := x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			Organization: caCert.Subject.Organization,
+			CommonName:   commonName,
+		},
+		NotBefore: time.Now().Add(-time.Hour * 24),
+		NotAfter:  validUntil,
+
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		AuthorityKeyId:        caCert.SubjectKeyId,
+
+		DNSNames:    dnsNames,
+		IPAddresses: ipAddresses,
+	}
+
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, caCert,
+		priv.Public(), signer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create certificate: %v", err)
+	}
+
+	certBuf := &bytes.Buffer{}
+	err = pem.Encode(certBuf, &pem.Block{Type: "CERTIFICATE", Bytes: derBytes})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode certificate: %v", err)
 	}
 
 	keyBuf := &bytes.Buffer{}
-	err = pem.Encode(keyBuf, &pem.Block{Type: "EC PRIVATE KEY", Bytes: keybytes})
+	err = pem.Encode(keyBuf, keyBlock)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to encode private key: %v", err)
 	}
@@ -5,6 +5,11 @@
 package btcutil_test
 
 import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
 	"net"
@@ -121,3 +126,366 @@ func TestNewTLSCertPair(t *testing.T) {
 		t.Fatal("generated cert does not have valid basic constraints")
 	}
 }
+
+// TestNewTLSCertPairEx ensures the NewTLSCertPairEx function generates the
+// requested key types and rejects invalid key parameters.
+func TestNewTLSCertPairEx(t *testing.T) {
+	validUntil := time.Unix(time.Now().Add(24*time.Hour).Unix(), 0)
+	org := "test autogenerated cert"
+	extraHosts := []string{"testtlscert.bogus", "127.0.0.2"}
+
+	tests := []struct {
+		name      string
+		keyParams btcutil.KeyParams
+		valid     bool
+		pubKeyAlg x509.PublicKeyAlgorithm
+		sigAlg    x509.SignatureAlgorithm
+		curve     elliptic.Curve
+		bits      int
+	}{
+		{
+			name:      "ecdsa p256",
+			keyParams: btcutil.KeyParams{Type: btcutil.KeyTypeECDSA, Curve: elliptic.P256()},
+			valid:     true,
+			pubKeyAlg: x509.ECDSA,
+			sigAlg:    x509.ECDSAWithSHA256,
+			curve:     elliptic.P256(),
+		},
+		{
+			name:      "ecdsa p384",
+			keyParams: btcutil.KeyParams{Type: btcutil.KeyTypeECDSA, Curve: elliptic.P384()},
+			valid:     true,
+			pubKeyAlg: x509.ECDSA,
+			sigAlg:    x509.ECDSAWithSHA384,
+			curve:     elliptic.P384(),
+		},
+		{
+			name:      "ecdsa p521",
+			keyParams: btcutil.KeyParams{Type: btcutil.KeyTypeECDSA, Curve: elliptic.P521()},
+			valid:     true,
+			pubKeyAlg: x509.ECDSA,
+			sigAlg:    x509.ECDSAWithSHA512,
+			curve:     elliptic.P521(),
+		},
+		{
+			name:      "rsa 2048",
+			keyParams: btcutil.KeyParams{Type: btcutil.KeyTypeRSA, Bits: 2048},
+			valid:     true,
+			pubKeyAlg: x509.RSA,
+			sigAlg:    x509.SHA256WithRSA,
+			bits:      2048,
+		},
+		{
+			name:      "ecdsa unsupported curve",
+			keyParams: btcutil.KeyParams{Type: btcutil.KeyTypeECDSA, Curve: elliptic.P224()},
+		},
+		{
+			name:      "ecdsa no curve",
+			keyParams: btcutil.KeyParams{Type: btcutil.KeyTypeECDSA},
+		},
+		{
+			name:      "ecdsa with bits",
+			keyParams: btcutil.KeyParams{Type: btcutil.KeyTypeECDSA, Curve: elliptic.P256(), Bits: 2048},
+		},
+		{
+			name:      "rsa too small",
+			keyParams: btcutil.KeyParams{Type: btcutil.KeyTypeRSA, Bits: 1024},
+		},
+		{
+			name:      "rsa with curve",
+			keyParams: btcutil.KeyParams{Type: btcutil.KeyTypeRSA, Curve: elliptic.P256(), Bits: 2048},
+		},
+		{
+			name:      "unknown key type",
+			keyParams: btcutil.KeyParams{Type: btcutil.KeyType(99)},
+		},
+	}
+
+	for _, test := range tests {
+		cert, key, err := btcutil.NewTLSCertPairEx(org, validUntil,
+			extraHosts, test.keyParams)
+		if !test.valid {
+			if err == nil {
+				t.Errorf("%s: expected error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+
+		pemCert, _ := pem.Decode(cert)
+		if pemCert == nil {
+			t.Errorf("%s: unable to decode the certificate", test.name)
+			continue
+		}
+		x509Cert, err := x509.ParseCertificate(pemCert.Bytes)
+		if err != nil {
+			t.Errorf("%s: unable to parse certificate: %v", test.name, err)
+			continue
+		}
+		pemKey, _ := pem.Decode(key)
+		if pemKey == nil {
+			t.Errorf("%s: unable to decode the key", test.name)
+			continue
+		}
+
+		if x509Cert.PublicKeyAlgorithm != test.pubKeyAlg {
+			t.Errorf("%s: public key algorithm mismatch - got %v, "+
+				"want %v", test.name, x509Cert.PublicKeyAlgorithm,
+				test.pubKeyAlg)
+			continue
+		}
+		if x509Cert.SignatureAlgorithm != test.sigAlg {
+			t.Errorf("%s: signature algorithm mismatch - got %v, "+
+				"want %v", test.name, x509Cert.SignatureAlgorithm,
+				test.sigAlg)
+			continue
+		}
+
+		switch pub := x509Cert.PublicKey.(type) {
+		case *ecdsa.PublicKey:
+			if pub.Curve != test.curve {
+				t.Errorf("%s: curve mismatch - got %v, want %v",
+					test.name, pub.Curve.Params().Name,
+					test.curve.Params().Name)
+				continue
+			}
+			if _, err := x509.ParseECPrivateKey(pemKey.Bytes); err != nil {
+				t.Errorf("%s: unable to parse key: %v", test.name, err)
+				continue
+			}
+
+		case *rsa.PublicKey:
+			if pub.N.BitLen() != test.bits {
+				t.Errorf("%s: key size mismatch - got %d, want %d",
+					test.name, pub.N.BitLen(), test.bits)
+				continue
+			}
+			if _, err := x509.ParsePKCS1PrivateKey(pemKey.Bytes); err != nil {
+				t.Errorf("%s: unable to parse key: %v", test.name, err)
+				continue
+			}
+
+		default:
+			t.Errorf("%s: unexpected public key type %T", test.name, pub)
+			continue
+		}
+
+		// Ensure the specified extra hosts are present.
+		for _, host := range extraHosts {
+			if err := x509Cert.VerifyHostname(host); err != nil {
+				t.Errorf("%s: failed to verify extra host '%s'",
+					test.name, host)
+			}
+		}
+	}
+}
+
+// TestNewTLSCertPairWithOptions ensures the validity window and subject of
+// generated certificates match the provided options and that invalid
+// validity windows are rejected.
+func TestNewTLSCertPairWithOptions(t *testing.T) {
+	// Certs don't support sub-second precision, so truncate it now to
+	// ensure the checks later don't fail due to nanosecond precision
+	// differences.
+	notBefore := time.Unix(time.Now().Add(-time.Hour).Unix(), 0)
+	notAfter := time.Unix(time.Now().Add(30*24*time.Hour).Unix(), 0)
+	opts := btcutil.CertOptions{
+		Organization: "regulated org",
+		CommonName:   "node.example.com",
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		ExtraHosts:   []string{"rpc.example.com"},
+		KeyParams: btcutil.KeyParams{
+			Type:  btcutil.KeyTypeECDSA,
+			Curve: elliptic.P256(),
+		},
+	}
+	cert, _, err := btcutil.NewTLSCertPairWithOptions(&opts)
+	if err != nil {
+		t.Fatalf("failed with unexpected error: %v", err)
+	}
+
+	pemCert, _ := pem.Decode(cert)
+	if pemCert == nil {
+		t.Fatalf("pem.Decode was unable to decode the certificate")
+	}
+	x509Cert, err := x509.ParseCertificate(pemCert.Bytes)
+	if err != nil {
+		t.Fatalf("failed with unexpected error: %v", err)
+	}
+
+	if !x509Cert.NotBefore.Equal(notBefore) {
+		t.Errorf("generated cert not before field mismatch, got %v, "+
+			"want %v", x509Cert.NotBefore, notBefore)
+	}
+	if !x509Cert.NotAfter.Equal(notAfter) {
+		t.Errorf("generated cert not after field mismatch, got %v, "+
+			"want %v", x509Cert.NotAfter, notAfter)
+	}
+	x509Orgs := x509Cert.Subject.Organization
+	if len(x509Orgs) != 1 || x509Orgs[0] != opts.Organization {
+		t.Errorf("generated cert organization field mismatch, got "+
+			"%v, want %v", x509Orgs, opts.Organization)
+	}
+	if cn := x509Cert.Subject.CommonName; cn != opts.CommonName {
+		t.Errorf("generated cert common name mismatch, got %v, "+
+			"want %v", cn, opts.CommonName)
+	}
+	for _, host := range []string{opts.CommonName, "rpc.example.com"} {
+		if err := x509Cert.VerifyHostname(host); err != nil {
+			t.Errorf("failed to verify host '%s'", host)
+		}
+	}
+
+	// Ensure invalid validity windows are rejected.
+	invalidTests := []struct {
+		name      string
+		notBefore time.Time
+		notAfter  time.Time
+	}{
+		{"zero not before", time.Time{}, notAfter},
+		{"zero not after", notBefore, time.Time{}},
+		{"ends before start", notAfter, notBefore},
+		{"empty window", notBefore, notBefore},
+		{"already expired", notBefore.Add(-time.Hour), notBefore},
+	}
+	for _, test := range invalidTests {
+		invalidOpts := opts
+		invalidOpts.NotBefore = test.notBefore
+		invalidOpts.NotAfter = test.notAfter
+		_, _, err := btcutil.NewTLSCertPairWithOptions(&invalidOpts)
+		if err == nil {
+			t.Errorf("%s: expected error", test.name)
+		}
+	}
+}
+
+// TestNewSignedCertPair ensures certificates signed by a CA verify against it
+// and that invalid CAs are rejected.
+func TestNewSignedCertPair(t *testing.T) {
+	// Certs don't support sub-second precision, so truncate it now to
+	// ensure the checks later don't fail due to nanosecond precision
+	// differences.
+	caValidUntil := time.Unix(time.Now().Add(365*24*time.Hour).Unix(), 0)
+	caKeyParams := btcutil.KeyParams{Type: btcutil.KeyTypeECDSA, Curve: elliptic.P256()}
+	caCertPEM, caKeyPEM, err := btcutil.NewTLSCertPairEx("test ca", caValidUntil,
+		nil, caKeyParams)
+	if err != nil {
+		t.Fatalf("NewTLSCertPairEx: unexpected error: %v", err)
+	}
+	caCert, caKey := parseCertPair(t, caCertPEM, caKeyPEM)
+
+	validUntil := time.Unix(time.Now().Add(30*24*time.Hour).Unix(), 0)
+	hosts := []string{"rpc.example.com", "10.0.0.1", "node.example.com:8334"}
+	certPEM, keyPEM, err := btcutil.NewSignedCertPair(hosts, validUntil,
+		caCert, caKey)
+	if err != nil {
+		t.Fatalf("NewSignedCertPair: unexpected error: %v", err)
+	}
+	cert, _ := parseCertPair(t, certPEM, keyPEM)
+
+	// Ensure the certificate verifies against the CA for each host when
+	// used for server authentication.
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+	for _, host := range []string{"rpc.example.com", "10.0.0.1", "node.example.com"} {
+		_, err := cert.Verify(x509.VerifyOptions{
+			DNSName:   host,
+			Roots:     roots,
+			KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		})
+		if err != nil {
+			t.Errorf("failed to verify certificate for host '%s': %v",
+				host, err)
+		}
+	}
+
+	// Ensure the certificate does not verify without the CA.
+	_, err = cert.Verify(x509.VerifyOptions{
+		DNSName: "rpc.example.com",
+		Roots:   x509.NewCertPool(),
+	})
+	if err == nil {
+		t.Error("certificate verified without the CA")
+	}
+
+	// Ensure the certificate identifies the CA and is a server leaf.
+	if cert.Issuer.String() != caCert.Subject.String() {
+		t.Errorf("issuer mismatch, got %v, want %v", cert.Issuer,
+			caCert.Subject)
+	}
+	if len(caCert.SubjectKeyId) == 0 ||
+		!bytes.Equal(cert.AuthorityKeyId, caCert.SubjectKeyId) {
+		t.Errorf("authority key id mismatch, got %x, want %x",
+			cert.AuthorityKeyId, caCert.SubjectKeyId)
+	}
+	if cert.IsCA {
+		t.Error("signed cert is a certificate authority")
+	}
+	if len(cert.ExtKeyUsage) != 1 || cert.ExtKeyUsage[0] != x509.ExtKeyUsageServerAuth {
+		t.Errorf("unexpected extended key usage %v", cert.ExtKeyUsage)
+	}
+	if cert.Subject.CommonName != "rpc.example.com" {
+		t.Errorf("common name mismatch, got %v, want %v",
+			cert.Subject.CommonName, "rpc.example.com")
+	}
+	if !cert.NotAfter.Equal(validUntil) {
+		t.Errorf("not after field mismatch, got %v, want %v",
+			cert.NotAfter, validUntil)
+	}
+
+	// Ensure invalid parameters are rejected.
+	otherCertPEM, otherKeyPEM, err := btcutil.NewTLSCertPairEx("other ca",
+		caValidUntil, nil, caKeyParams)
+	if err != nil {
+		t.Fatalf("NewTLSCertPairEx: unexpected error: %v", err)
+	}
+	_, otherKey := parseCertPair(t, otherCertPEM, otherKeyPEM)
+	_, leafKey := parseCertPair(t, certPEM, keyPEM)
+	invalidTests := []struct {
+		name       string
+		hosts      []string
+		validUntil time.Time
+		caCert     *x509.Certificate
+		caKey      crypto.PrivateKey
+	}{
+		{"no hosts", nil, validUntil, caCert, caKey},
+		{"no CA certificate", hosts, validUntil, nil, caKey},
+		{"mismatched CA key", hosts, validUntil, caCert, otherKey},
+		{"no CA key", hosts, validUntil, caCert, nil},
+		{"not a CA", hosts, validUntil, cert, leafKey},
+		{"already expired", hosts, time.Now().Add(-time.Hour), caCert, caKey},
+		{"outlives CA", hosts, caValidUntil.Add(time.Hour), caCert, caKey},
+	}
+	for _, test := range invalidTests {
+		_, _, err := btcutil.NewSignedCertPair(test.hosts, test.validUntil,
+			test.caCert, test.caKey)
+		if err == nil {
+			t.Errorf("%s: expected error", test.name)
+		}
+	}
+}
+
+// parseCertPair parses a PEM-encoded certificate and ECDSA private key.
+func parseCertPair(t *testing.T, certPEM, keyPEM []byte) (*x509.Certificate, crypto.PrivateKey) {
+	pemCert, _ := pem.Decode(certPEM)
+	if pemCert == nil {
+		t.Fatalf("pem.Decode was unable to decode the certificate")
+	}
+	cert, err := x509.ParseCertificate(pemCert.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	pemKey, _ := pem.Decode(keyPEM)
+	if pemKey == nil {
+		t.Fatalf("pem.Decode was unable to decode the key")
+	}
+	key, err := x509.ParseECPrivateKey(pemKey.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse key: %v", err)
+	}
+	return cert, key
+}
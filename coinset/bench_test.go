@@ -0,0 +1,35 @@
+// Copyright (c) 2014-2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package coinset_test
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcutil/coinset"
+)
+
+// benchmarkSelector benchmarks how long it takes the selector to select a
+// small number of coins from a set of 100,000 coins.
+func benchmarkSelector(b *testing.B, selector coinset.CoinSelector) {
+	b.StopTimer()
+	coins := largeCoinSet(100000)
+	b.StartTimer()
+
+	for i := 0; i < b.N; i++ {
+		selector.CoinSelect(5000000, coins)
+	}
+}
+
+// BenchmarkMinNumberSelector benchmarks the MinNumberCoinSelector, which
+// sorts all of the coins.
+func BenchmarkMinNumberSelector(b *testing.B) {
+	benchmarkSelector(b, coinset.MinNumberCoinSelector{MaxInputs: 10})
+}
+
+// BenchmarkTopKSelector benchmarks the TopKCoinSelector, which only keeps
+// track of the largest coins.
+func BenchmarkTopKSelector(b *testing.B) {
+	benchmarkSelector(b, coinset.TopKCoinSelector{MaxInputs: 10})
+}
@@ -5,11 +5,15 @@
 package coinset
 
 import (
+	"bytes"
+	"container/heap"
 	"container/list"
 	"errors"
+	"fmt"
 	"sort"
 
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 )
@@ -29,6 +33,116 @@ type Coins interface {
 	Coins() []Coin
 }
 
+// AddressCoin is an optional interface which may be implemented by a Coin
+// to expose the address its output pays to.  It is used by the
+// privacy-related helpers in this package.
+type AddressCoin interface {
+	Coin
+	Address() btcutil.Address
+}
+
+// LabeledCoin is an optional interface which may be implemented by a Coin
+// to expose a label grouping it with other coins, such as the name of the
+// account it belongs to.  It is used by LabelCoinSelector to avoid linking
+// coins with different labels in a single transaction.
+type LabeledCoin interface {
+	Coin
+	Label() string
+}
+
+// SpendableCoin is an optional interface which may be implemented by a Coin
+// whose output can not be spent until some block height, such as an output
+// locked by an absolute or relative lock time.  It is used by
+// SpendableCoinSelector to avoid selecting coins which can not yet be spent.
+// Coins which do not implement it are always spendable.
+type SpendableCoin interface {
+	Coin
+	SpendableAtHeight(height int32) bool
+}
+
+// DefaultInputSize is the estimated number of bytes used to spend a coin
+// which does not implement SizedCoin.  It is the size of an input
+// redeeming a pay-to-pubkey-hash output with an uncompressed public key:
+// 32 byte previous hash, 4 byte index, 1 byte script length, 107 byte
+// signature script and 4 byte sequence number.
+const DefaultInputSize = 148
+
+// BreakEvenFeeRate returns the fee rate, in satoshis per byte, at which the
+// fee to spend an input of inputSize bytes consumes the entire amount.
+// Spending a coin of the given amount at any fee rate above the returned
+// value costs more than the coin is worth.  Zero is returned if inputSize is
+// not positive.
+func BreakEvenFeeRate(amount btcutil.Amount, inputSize int) btcutil.Amount {
+	if inputSize <= 0 {
+		return 0
+	}
+	return amount / btcutil.Amount(inputSize)
+}
+
+// PartitionByEconomics splits coins into those which are worth more than
+// the fee required to spend them at feePerByte and those which are not,
+// returning the indexes of each in their original order.  The size of each
+// input is determined by inputSize, or by EstimatedInputSize when inputSize
+// is nil.  A coin is economic only if its value strictly exceeds its fee.
+func PartitionByEconomics(coins []Coin, feePerByte btcutil.Amount, inputSize func(Coin) int) (economic, dust []int) {
+	if inputSize == nil {
+		inputSize = EstimatedInputSize
+	}
+	for i, coin := range coins {
+		fee := btcutil.Amount(inputSize(coin)) * feePerByte
+		if coin.Value() > fee {
+			economic = append(economic, i)
+		} else {
+			dust = append(dust, i)
+		}
+	}
+	return economic, dust
+}
+
+// SizedCoin is an optional interface which may be implemented by a Coin
+// to report the estimated number of bytes the input spending it will add to
+// a transaction.  This allows fee-aware selectors to account for coins
+// which are more expensive to spend, such as multisig outputs.
+type SizedCoin interface {
+	Coin
+	EstimatedSize() int
+}
+
+// EstimatedInputSize returns the estimated number of bytes required to
+// spend the coin.  Coins which do not implement SizedCoin are assumed to
+// require DefaultInputSize bytes.
+func EstimatedInputSize(c Coin) int {
+	if sc, ok := c.(SizedCoin); ok {
+		return sc.EstimatedSize()
+	}
+	return DefaultInputSize
+}
+
+// SameCluster returns whether the coins a and b are likely to belong to the
+// same wallet cluster according to the passed heuristic.  The heuristic is
+// consulted in both directions so it does not need to be symmetric.  A coin
+// is always considered to be in the same cluster as itself.
+func SameCluster(a, b AddressCoin, coClusteredBy func(x, y AddressCoin) bool) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	if a.Index() == b.Index() && a.Hash().IsEqual(b.Hash()) {
+		return true
+	}
+	return coClusteredBy(a, b) || coClusteredBy(b, a)
+}
+
+// SameAddress is a clustering heuristic for use with SameCluster which
+// considers two coins to be in the same cluster when they pay to the same
+// address.
+func SameAddress(x, y AddressCoin) bool {
+	xAddr, yAddr := x.Address(), y.Address()
+	if xAddr == nil || yAddr == nil {
+		return false
+	}
+	return xAddr.EncodeAddress() == yAddr.EncodeAddress()
+}
+
 // CoinSet is a utility struct for the modifications of a set of
 // Coins that implements the Coins interface.  To create a CoinSet,
 // you must call NewCoinSet with nil for an empty set or a slice of
@@ -144,9 +258,129 @@ func NewMsgTxWithInputCoins(txVersion int32, inputCoins Coins) *wire.MsgTx {
 var (
 	// ErrCoinsNoSelectionAvailable is returned when a CoinSelector believes there is no
 	// possible combination of coins which can meet the requirements provided to the selector.
+	// Selectors may instead return a *SelectionError describing why, which
+	// matches this error when compared with errors.Is.
 	ErrCoinsNoSelectionAvailable = errors.New("no coin selection possible")
+
+	// ErrOutputIndexOutOfRange is returned by NewSimpleCoin when the
+	// output index does not refer to an output of the transaction.
+	ErrOutputIndexOutOfRange = errors.New("output index out of range")
+
+	// ErrNoOutputs is returned by SelectForOutputs when there are no
+	// outputs to select coins for.
+	ErrNoOutputs = errors.New("no outputs to pay")
+
+	// ErrDustOutput is returned by SelectForOutputs when the amount of an
+	// output is at or below the dust threshold.
+	ErrDustOutput = errors.New("output amount is dust")
+
+	// ErrTooManyInputs is returned by ValidateSelection when a selection
+	// has more than the maximum number of inputs.
+	ErrTooManyInputs = errors.New("selection has too many inputs")
+
+	// ErrDustInput is returned by ValidateSelection when a selection spends
+	// a coin whose value is at or below the dust threshold.
+	ErrDustInput = errors.New("selection spends a dust coin")
+
+	// ErrBelowTarget is returned by ValidateSelection when the total value
+	// of a selection is less than the target value.
+	ErrBelowTarget = errors.New("selection total is below the target value")
+
+	// ErrInsufficientChange is returned by ValidateSelection when a
+	// selection exceeds the target value by less than the minimum change
+	// amount.
+	ErrInsufficientChange = errors.New("selection change is below the minimum change amount")
+
+	// ErrUnknownStrategy is returned by SelectStable when the strategy is
+	// not one of the defined Strategy values.
+	ErrUnknownStrategy = errors.New("unknown selection strategy")
+)
+
+// SelectionFailure describes the reason a CoinSelector was unable to find a
+// selection of coins.
+type SelectionFailure int
+
+const (
+	// InsufficientFunds indicates the coins which could be considered do
+	// not have enough value to meet the target value.
+	InsufficientFunds SelectionFailure = iota
+
+	// MaxInputsExceeded indicates the coins have enough value to meet the
+	// target value, but not within the maximum number of inputs.
+	MaxInputsExceeded
+
+	// AllDust indicates every coin was excluded from the selection as
+	// dust.
+	AllDust
+
+	// ExcessiveChange indicates the coins have enough value to meet the
+	// target value within the maximum number of inputs, but no selection
+	// was found whose total is also within the maximum allowed.
+	ExcessiveChange
+
+	// MaxWeightExceeded indicates the coins have enough value to meet the
+	// target value within the maximum number of inputs, but no selection
+	// was found whose inputs are also within the maximum weight.
+	MaxWeightExceeded
 )
 
+// selectionFailureStrings is a map of selection failure reasons back to
+// their constant names for pretty printing.
+var selectionFailureStrings = map[SelectionFailure]string{
+	InsufficientFunds: "InsufficientFunds",
+	MaxInputsExceeded: "MaxInputsExceeded",
+	AllDust:           "AllDust",
+	ExcessiveChange:   "ExcessiveChange",
+	MaxWeightExceeded: "MaxWeightExceeded",
+}
+
+// String returns the SelectionFailure as the name of the constant.
+func (f SelectionFailure) String() string {
+	if s := selectionFailureStrings[f]; s != "" {
+		return s
+	}
+	return fmt.Sprintf("Unknown SelectionFailure (%d)", int(f))
+}
+
+// SelectionError describes why a CoinSelector was unable to find a
+// selection of coins along with the largest total value it was able to
+// reach.  It matches ErrCoinsNoSelectionAvailable when used with errors.Is
+// so callers which only care that no selection is possible do not need to
+// inspect it.
+type SelectionError struct {
+	Reason    SelectionFailure
+	BestTotal btcutil.Amount
+}
+
+// Error satisfies the error interface and prints human-readable errors.
+func (e *SelectionError) Error() string {
+	return fmt.Sprintf("%v: %v (best total %v)", ErrCoinsNoSelectionAvailable,
+		e.Reason, e.BestTotal)
+}
+
+// Is returns whether target is ErrCoinsNoSelectionAvailable so that
+// errors.Is reports a SelectionError as that error.
+func (e *SelectionError) Is(target error) bool {
+	return target == ErrCoinsNoSelectionAvailable
+}
+
+// selectionFailure returns a SelectionError for a selector which was only
+// able to reach bestTotal by accumulating at most maxInputs of the passed
+// coins, whose values are given by value.
+func selectionFailure(targetValue btcutil.Amount, maxInputs int, bestTotal btcutil.Amount,
+	coins []Coin, value func(Coin) btcutil.Amount) error {
+
+	var total btcutil.Amount
+	for _, coin := range coins {
+		total += value(coin)
+	}
+	reason := InsufficientFunds
+	if len(coins) > maxInputs && total >= targetValue {
+		reason = MaxInputsExceeded
+	}
+	return &SelectionError{Reason: reason, BestTotal: bestTotal}
+}
+
 // satisfiesTargetValue checks that the totalValue is either exactly the targetValue
 // or is greater than the targetValue by at least the minChange amount.
 func satisfiesTargetValue(targetValue, minChange, totalValue btcutil.Amount) bool {
@@ -161,6 +395,10 @@ func satisfiesTargetValue(targetValue, minChange, totalValue btcutil.Amount) boo
 // than the target value.
 //
 // The exact choice of coins in the subset will be implementation specific.
+// The selectors of this package all select no coins for a target value of
+// zero, even when given no coins, and a selection error matching
+// ErrCoinsNoSelectionAvailable is returned for any other target when no
+// coins are given.
 //
 // It is important to note that the Coins being used as inputs need to have
 // a constant ValueAge() during the execution of CoinSelect.
@@ -168,33 +406,115 @@ type CoinSelector interface {
 	CoinSelect(targetValue btcutil.Amount, coins []Coin) (Coins, error)
 }
 
+// Ensure each of the selection algorithms implements the CoinSelector
+// interface so they may be used interchangeably.
+var (
+	_ CoinSelector = MinIndexCoinSelector{}
+	_ CoinSelector = MinNumberCoinSelector{}
+	_ CoinSelector = TopKCoinSelector{}
+	_ CoinSelector = SmallestFirstCoinSelector{}
+	_ CoinSelector = MaxValueAgeCoinSelector{}
+	_ CoinSelector = MaxConfsCoinSelector{}
+	_ CoinSelector = SortedCoinSelector{}
+	_ CoinSelector = RandomCoinSelector{}
+	_ CoinSelector = WeightedRandomCoinSelector{}
+	_ CoinSelector = PinnedCoinSelector{}
+	_ CoinSelector = MinFeeCoinSelector{}
+	_ CoinSelector = MaxWeightCoinSelector{}
+	_ CoinSelector = MinPriorityCoinSelector{}
+	_ CoinSelector = UniformScriptCoinSelector{}
+	_ CoinSelector = RangeCoinSelector{}
+	_ CoinSelector = MaxTotalCoinSelector{}
+	_ CoinSelector = NoChangeCoinSelector{}
+	_ CoinSelector = MinChangeCoinSelector{}
+	_ CoinSelector = FirstFitDecreasingCoinSelector{}
+	_ CoinSelector = FallbackCoinSelector{}
+	_ CoinSelector = DustFilterCoinSelector{}
+	_ CoinSelector = MinConfCoinSelector{}
+	_ CoinSelector = LockedCoinSelector{}
+	_ CoinSelector = SpendableCoinSelector{}
+	_ CoinSelector = LabelCoinSelector{}
+	_ CoinSelector = DistinctAddressCoinSelector{}
+	_ CoinSelector = AuditCoinSelector{}
+)
+
+// Events passed to the Trace function of a MinIndexCoinSelector or
+// MinNumberCoinSelector.
+const (
+	// TraceAdd is the event for a coin which was added to the selection.
+	// The total is that of the selection including the coin.
+	TraceAdd = "add"
+
+	// TraceSkip is the event for a coin which was not considered since
+	// the selection already had MaxInputs coins.
+	TraceSkip = "skip"
+
+	// TraceSelect is the event for a selection which satisfied the target
+	// value.  The index is -1.
+	TraceSelect = "select"
+
+	// TraceFail is the event for a failure to satisfy the target value.
+	// The index is -1.
+	TraceFail = "fail"
+)
+
 // MinIndexCoinSelector is a CoinSelector that attempts to construct a
 // selection of coins whose total value is at least targetValue and prefers
 // any number of lower indexes (as in the ordered array) over higher ones.
+//
+// Trace, when not nil, is called with each of the Trace events along with
+// the total value of the selection and the index of the coin the event is
+// for, which may be used to diagnose why a selection was made.
 type MinIndexCoinSelector struct {
 	MaxInputs       int
 	MinChangeAmount btcutil.Amount
+	Trace           func(event string, total btcutil.Amount, idx int)
 }
 
 // CoinSelect will attempt to select coins using the algorithm described
 // in the MinIndexCoinSelector struct.
 func (s MinIndexCoinSelector) CoinSelect(targetValue btcutil.Amount, coins []Coin) (Coins, error) {
+	// No coins are needed to pay a target value of zero.
+	if targetValue == 0 {
+		if s.Trace != nil {
+			s.Trace(TraceSelect, 0, -1)
+		}
+		return NewCoinSet(nil), nil
+	}
+
 	cs := NewCoinSet(nil)
 	for n := 0; n < len(coins) && n < s.MaxInputs; n++ {
 		cs.PushCoin(coins[n])
+		if s.Trace != nil {
+			s.Trace(TraceAdd, cs.TotalValue(), n)
+		}
 		if satisfiesTargetValue(targetValue, s.MinChangeAmount, cs.TotalValue()) {
+			if s.Trace != nil {
+				s.Trace(TraceSelect, cs.TotalValue(), -1)
+			}
 			return cs, nil
 		}
 	}
-	return nil, ErrCoinsNoSelectionAvailable
+	if s.Trace != nil {
+		for n := cs.Num(); n < len(coins); n++ {
+			s.Trace(TraceSkip, cs.TotalValue(), n)
+		}
+		s.Trace(TraceFail, cs.TotalValue(), -1)
+	}
+	return nil, selectionFailure(targetValue, s.MaxInputs, cs.TotalValue(),
+		coins, Coin.Value)
 }
 
 // MinNumberCoinSelector is a CoinSelector that attempts to construct
 // a selection of coins whose total value is at least targetValue
 // that uses as few of the inputs as possible.
+//
+// Trace is used in the same way as for MinIndexCoinSelector, with indexes
+// referring to the coins in the order they were passed.
 type MinNumberCoinSelector struct {
 	MaxInputs       int
 	MinChangeAmount btcutil.Amount
+	Trace           func(event string, total btcutil.Amount, idx int)
 }
 
 // CoinSelect will attempt to select coins using the algorithm described
@@ -204,7 +524,122 @@ func (s MinNumberCoinSelector) CoinSelect(targetValue btcutil.Amount, coins []Co
 	sortedCoins = append(sortedCoins, coins...)
 	sort.Sort(sort.Reverse(byAmount(sortedCoins)))
 
-	return MinIndexCoinSelector(s).CoinSelect(targetValue, sortedCoins)
+	return MinIndexCoinSelector{
+		MaxInputs:       s.MaxInputs,
+		MinChangeAmount: s.MinChangeAmount,
+		Trace:           traceOriginalIndexes(s.Trace, coins, sortedCoins),
+	}.CoinSelect(targetValue, sortedCoins)
+}
+
+// traceOriginalIndexes returns a Trace function which calls trace with the
+// index in coins of each coin of sortedCoins it is called with, where
+// sortedCoins is a reordering of coins.  Coins are matched by outpoint in the
+// same way as by Select.  Nil is returned when trace is nil so that no work is
+// done for selections which are not traced.
+func traceOriginalIndexes(trace func(string, btcutil.Amount, int), coins, sortedCoins []Coin) func(string, btcutil.Amount, int) {
+	if trace == nil {
+		return nil
+	}
+
+	positions := make(map[wire.OutPoint][]int)
+	for i, coin := range coins {
+		op := *wire.NewOutPoint(coin.Hash(), coin.Index())
+		positions[op] = append(positions[op], i)
+	}
+	indexes := make([]int, len(sortedCoins))
+	for i, coin := range sortedCoins {
+		op := *wire.NewOutPoint(coin.Hash(), coin.Index())
+		indexes[i] = positions[op][0]
+		positions[op] = positions[op][1:]
+	}
+
+	return func(event string, total btcutil.Amount, idx int) {
+		if idx >= 0 {
+			idx = indexes[idx]
+		}
+		trace(event, total, idx)
+	}
+}
+
+// TopKCoinSelector is a CoinSelector that makes the same selection as the
+// MinNumberCoinSelector, but is more efficient for very large numbers of
+// coins.  Rather than sorting all of the coins, it finds the MaxInputs coins
+// with the largest values with a bounded heap, which requires O(n log k)
+// rather than O(n log n) time for n coins and a MaxInputs of k, and then only
+// accumulates those.
+type TopKCoinSelector struct {
+	MaxInputs       int
+	MinChangeAmount btcutil.Amount
+}
+
+// CoinSelect will attempt to select coins using the algorithm described
+// in the TopKCoinSelector struct.
+func (s TopKCoinSelector) CoinSelect(targetValue btcutil.Amount, coins []Coin) (Coins, error) {
+	if targetValue == 0 {
+		return NewCoinSet(nil), nil
+	}
+
+	if s.MaxInputs <= 0 {
+		return nil, selectionFailure(targetValue, s.MaxInputs, 0, coins,
+			Coin.Value)
+	}
+
+	// Keep the largest coins seen so far in a heap with the smallest of
+	// them at the root so it can be replaced by any larger coin.
+	k := s.MaxInputs
+	if len(coins) < k {
+		k = len(coins)
+	}
+	largest := make(coinHeap, 0, k)
+	for _, coin := range coins {
+		if len(largest) < s.MaxInputs {
+			heap.Push(&largest, coin)
+			continue
+		}
+		smallest := largest[0]
+		if coin.Value() > smallest.Value() ||
+			coin.Value() == smallest.Value() && lessOutPoint(smallest, coin) {
+			largest[0] = coin
+			heap.Fix(&largest, 0)
+		}
+	}
+	sortedCoins := []Coin(largest)
+	sort.Sort(sort.Reverse(byAmount(sortedCoins)))
+
+	cs, err := MinIndexCoinSelector{
+		MaxInputs:       s.MaxInputs,
+		MinChangeAmount: s.MinChangeAmount,
+	}.CoinSelect(targetValue, sortedCoins)
+	if err != nil {
+		return nil, selectionFailure(targetValue, s.MaxInputs,
+			NewCoinSet(sortedCoins).TotalValue(), coins, Coin.Value)
+	}
+	return cs, nil
+}
+
+// SmallestFirstCoinSelector is a CoinSelector that attempts to construct
+// a selection of coins whose total value is at least targetValue by
+// accumulating the coins with the smallest values first.  It is effectively
+// the inverse of MinNumberCoinSelector.
+//
+// This would be useful in the case where you want to consolidate many
+// small coins, for example during periods of low fees.
+type SmallestFirstCoinSelector struct {
+	MaxInputs       int
+	MinChangeAmount btcutil.Amount
+}
+
+// CoinSelect will attempt to select coins using the algorithm described
+// in the SmallestFirstCoinSelector struct.
+func (s SmallestFirstCoinSelector) CoinSelect(targetValue btcutil.Amount, coins []Coin) (Coins, error) {
+	sortedCoins := make([]Coin, 0, len(coins))
+	sortedCoins = append(sortedCoins, coins...)
+	sort.Sort(byAmount(sortedCoins))
+
+	return MinIndexCoinSelector{
+		MaxInputs:       s.MaxInputs,
+		MinChangeAmount: s.MinChangeAmount,
+	}.CoinSelect(targetValue, sortedCoins)
 }
 
 // MaxValueAgeCoinSelector is a CoinSelector that attempts to construct
@@ -214,133 +649,1580 @@ func (s MinNumberCoinSelector) CoinSelect(targetValue btcutil.Amount, coins []Co
 // This would be useful in the case where you want to maximize
 // likelihood of the inclusion of your transaction in the next mined
 // block.
+//
+// Coins with a value-age below MinCoinValueAge are never selected, so each
+// input meets the minimum on its own.  This differs from
+// MinPriorityCoinSelector, which only requires the selection as a whole to
+// meet a minimum priority.  A MinCoinValueAge of zero or less allows all
+// coins to be selected.
 type MaxValueAgeCoinSelector struct {
 	MaxInputs       int
 	MinChangeAmount btcutil.Amount
+	MinCoinValueAge int64
 }
 
 // CoinSelect will attempt to select coins using the algorithm described
 // in the MaxValueAgeCoinSelector struct.
 func (s MaxValueAgeCoinSelector) CoinSelect(targetValue btcutil.Amount, coins []Coin) (Coins, error) {
 	sortedCoins := make([]Coin, 0, len(coins))
-	sortedCoins = append(sortedCoins, coins...)
+	for _, coin := range coins {
+		if coin.ValueAge() >= s.MinCoinValueAge {
+			sortedCoins = append(sortedCoins, coin)
+		}
+	}
 	sort.Sort(sort.Reverse(byValueAge(sortedCoins)))
 
-	return MinIndexCoinSelector(s).CoinSelect(targetValue, sortedCoins)
+	return MinIndexCoinSelector{
+		MaxInputs:       s.MaxInputs,
+		MinChangeAmount: s.MinChangeAmount,
+	}.CoinSelect(targetValue, sortedCoins)
 }
 
-// MinPriorityCoinSelector is a CoinSelector that attempts to construct
-// a selection of coins whose total value is at least targetValue and
-// whose average value-age per input is greater than MinAvgValueAgePerInput.
-// If there is change, it must exceed MinChangeAmount to be a valid selection.
+// MaxConfsCoinSelector is a CoinSelector that attempts to construct a
+// selection of coins whose total value is at least targetValue that prefers
+// the coins with the most confirmations.
 //
-// When possible, MinPriorityCoinSelector will attempt to reduce the average
-// input priority over the threshold, but no guarantees will be made as to
-// minimality of the selection.  The selection below is almost certainly
-// suboptimal.
+// This would be useful in the case where you want to minimize the risk of
+// the inputs being invalidated by a chain reorganization.
+type MaxConfsCoinSelector struct {
+	MaxInputs       int
+	MinChangeAmount btcutil.Amount
+}
+
+// CoinSelect will attempt to select coins using the algorithm described
+// in the MaxConfsCoinSelector struct.
+func (s MaxConfsCoinSelector) CoinSelect(targetValue btcutil.Amount, coins []Coin) (Coins, error) {
+	sortedCoins := make([]Coin, 0, len(coins))
+	sortedCoins = append(sortedCoins, coins...)
+	sort.Sort(sort.Reverse(byNumConfs(sortedCoins)))
+
+	return MinIndexCoinSelector{
+		MaxInputs:       s.MaxInputs,
+		MinChangeAmount: s.MinChangeAmount,
+	}.CoinSelect(targetValue, sortedCoins)
+}
+
+// SortedCoinSelector is a CoinSelector that attempts to construct a
+// selection of coins whose total value is at least targetValue by
+// accumulating the coins in the order defined by Less.  This allows
+// selecting by any custom priority, such as confirmation count or script
+// type, without a dedicated selector.
 //
-type MinPriorityCoinSelector struct {
-	MaxInputs              int
-	MinChangeAmount        btcutil.Amount
-	MinAvgValueAgePerInput int64
+// Less reports whether coin a must be selected before coin b.  It must
+// define a strict weak ordering: it is irreflexive, transitive, and coins
+// which are not ordered relative to each other must be equivalent to the same
+// set of other coins.  Equivalent coins keep their relative input order.  A
+// nil Less accumulates the coins in their input order.
+type SortedCoinSelector struct {
+	MaxInputs       int
+	MinChangeAmount btcutil.Amount
+	Less            func(a, b Coin) bool
 }
 
 // CoinSelect will attempt to select coins using the algorithm described
-// in the MinPriorityCoinSelector struct.
-func (s MinPriorityCoinSelector) CoinSelect(targetValue btcutil.Amount, coins []Coin) (Coins, error) {
-	possibleCoins := make([]Coin, 0, len(coins))
-	possibleCoins = append(possibleCoins, coins...)
+// in the SortedCoinSelector struct.
+func (s SortedCoinSelector) CoinSelect(targetValue btcutil.Amount, coins []Coin) (Coins, error) {
+	sortedCoins := make([]Coin, 0, len(coins))
+	sortedCoins = append(sortedCoins, coins...)
+	if s.Less != nil {
+		sort.SliceStable(sortedCoins, func(i, j int) bool {
+			return s.Less(sortedCoins[i], sortedCoins[j])
+		})
+	}
 
-	sort.Sort(byValueAge(possibleCoins))
+	return MinIndexCoinSelector{
+		MaxInputs:       s.MaxInputs,
+		MinChangeAmount: s.MinChangeAmount,
+	}.CoinSelect(targetValue, sortedCoins)
+}
 
-	// find the first coin with sufficient valueAge
-	cutoffIndex := -1
-	for i := 0; i < len(possibleCoins); i++ {
-		if possibleCoins[i].ValueAge() >= s.MinAvgValueAgePerInput {
-			cutoffIndex = i
+// MinFeeCoinSelector is a CoinSelector that attempts to construct a
+// selection of coins whose total value covers both targetValue and the fee
+// required to spend the selected inputs at FeePerByte, while keeping that
+// fee as small as possible.  The size of each input is determined by
+// EstimatedInputSize.  If there is change, it must exceed MinChangeAmount
+// to be a valid selection.
+//
+// A selection which produces change also requires a change output, so when
+// ChangeOutputSize is set, such selections must additionally cover the fee
+// for ChangeOutputSize bytes at FeePerByte, while selections which exactly
+// pay targetValue and the input fees do not.  This mirrors the cost of change
+// considered by Bitcoin Core, and may lead to a selection without change
+// being preferred over one left with too little change to pay for its own
+// output.
+//
+// Unlike MinNumberCoinSelector, this prefers several inexpensive inputs over
+// a single input which is costly to spend.  Coins are accumulated in order of
+// their value per byte after deducting their own fee, and any inputs which
+// are no longer needed once the target is met are then removed, largest
+// first.  No guarantees are made as to the minimality of the fee.
+type MinFeeCoinSelector struct {
+	MaxInputs        int
+	MinChangeAmount  btcutil.Amount
+	FeePerByte       btcutil.Amount
+	ChangeOutputSize int
+}
+
+// CoinSelect will attempt to select coins using the algorithm described
+// in the MinFeeCoinSelector struct.
+func (s MinFeeCoinSelector) CoinSelect(targetValue btcutil.Amount, coins []Coin) (Coins, error) {
+	if targetValue == 0 {
+		return NewCoinSet(nil), nil
+	}
+
+	// Only consider coins which are worth more than the fee to spend them.
+	sortedCoins := make([]Coin, 0, len(coins))
+	for _, coin := range coins {
+		if s.effectiveValue(coin) > 0 {
+			sortedCoins = append(sortedCoins, coin)
+		}
+	}
+	sort.Sort(byEffectiveValuePerByte{sortedCoins, s.FeePerByte})
+
+	var selected []Coin
+	var totalValue btcutil.Amount
+	var totalSize int
+	satisfied := false
+	for n := 0; n < len(sortedCoins) && n < s.MaxInputs; n++ {
+		selected = append(selected, sortedCoins[n])
+		totalValue += sortedCoins[n].Value()
+		totalSize += EstimatedInputSize(sortedCoins[n])
+		if s.satisfies(targetValue, totalValue, totalSize) {
+			satisfied = true
 			break
 		}
 	}
-	if cutoffIndex < 0 {
-		return nil, ErrCoinsNoSelectionAvailable
+	if !satisfied {
+		if len(coins) > 0 && len(sortedCoins) == 0 {
+			return nil, &SelectionError{Reason: AllDust}
+		}
+		return nil, selectionFailure(targetValue, s.MaxInputs,
+			totalValue-btcutil.Amount(totalSize)*s.FeePerByte,
+			sortedCoins, s.effectiveValue)
 	}
 
-	// create sets of input coins that will obey minimum average valueAge
-	for i := cutoffIndex; i < len(possibleCoins); i++ {
-		possibleHighCoins := possibleCoins[cutoffIndex : i+1]
-
-		// choose a set of high-enough valueAge coins
-		highSelect, err := (&MinNumberCoinSelector{
-			MaxInputs:       s.MaxInputs,
-			MinChangeAmount: s.MinChangeAmount,
-		}).CoinSelect(targetValue, possibleHighCoins)
+	// Drop any inputs which are not required to meet the target, trying
+	// the most expensive ones first.
+	order := make([]int, len(selected))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return EstimatedInputSize(selected[order[i]]) >
+			EstimatedInputSize(selected[order[j]])
+	})
+	removed := make([]bool, len(selected))
+	for _, i := range order {
+		value := totalValue - selected[i].Value()
+		size := totalSize - EstimatedInputSize(selected[i])
+		if s.satisfies(targetValue, value, size) {
+			removed[i] = true
+			totalValue, totalSize = value, size
+		}
+	}
 
-		if err != nil {
-			// attempt to add available low priority to make a solution
+	cs := NewCoinSet(nil)
+	for i, coin := range selected {
+		if !removed[i] {
+			cs.PushCoin(coin)
+		}
+	}
+	return cs, nil
+}
 
-			for numLow := 1; numLow <= cutoffIndex && numLow+(i-cutoffIndex) <= s.MaxInputs; numLow++ {
-				allHigh := NewCoinSet(possibleCoins[cutoffIndex : i+1])
-				newTargetValue := targetValue - allHigh.TotalValue()
-				newMaxInputs := allHigh.Num() + numLow
-				if newMaxInputs > numLow {
-					newMaxInputs = numLow
-				}
-				newMinAvgValueAge := ((s.MinAvgValueAgePerInput * int64(allHigh.Num()+numLow)) - allHigh.TotalValueAge()) / int64(numLow)
+// effectiveValue returns the value of the coin less the fee to spend it.
+func (s MinFeeCoinSelector) effectiveValue(c Coin) btcutil.Amount {
+	return c.Value() - btcutil.Amount(EstimatedInputSize(c))*s.FeePerByte
+}
 
-				// find the minimum priority that can be added to set
-				lowSelect, err := (&MinPriorityCoinSelector{
-					MaxInputs:              newMaxInputs,
-					MinChangeAmount:        s.MinChangeAmount,
-					MinAvgValueAgePerInput: newMinAvgValueAge,
-				}).CoinSelect(newTargetValue, possibleCoins[0:cutoffIndex])
+// satisfies returns whether inputs with the given total value and size are
+// able to pay both the target value and their own fee, along with the fee for
+// the change output when there is change.
+func (s MinFeeCoinSelector) satisfies(targetValue, totalValue btcutil.Amount, totalSize int) bool {
+	fee := btcutil.Amount(totalSize) * s.FeePerByte
+	if totalValue == targetValue+fee {
+		return true
+	}
+	changeFee := btcutil.Amount(s.ChangeOutputSize) * s.FeePerByte
+	return totalValue >= targetValue+fee+changeFee+s.MinChangeAmount
+}
 
-				if err != nil {
-					continue
-				}
+// witnessScaleFactor is the number of weight units each byte of an input
+// without witness data adds to a transaction.
+const witnessScaleFactor = 4
 
-				for _, coin := range lowSelect.Coins() {
-					allHigh.PushCoin(coin)
-				}
+// EstimatedInputWeight returns the estimated weight the input spending the
+// coin will add to a transaction, which is witnessScaleFactor weight units for
+// each of the EstimatedInputSize bytes.  This assumes the input has no witness
+// data, so it overestimates the weight of inputs which do.
+func EstimatedInputWeight(c Coin) int {
+	return witnessScaleFactor * EstimatedInputSize(c)
+}
 
-				return allHigh, nil
-			}
-			// oh well, couldn't fix, try to add more high priority to the set.
-		} else {
-			extendedCoins := NewCoinSet(highSelect.Coins())
+// MaxWeightCoinSelector is a CoinSelector that attempts to construct a
+// selection of at most MaxInputs coins whose total value is at least
+// targetValue and whose inputs have a total weight, as determined by
+// EstimatedInputWeight, of at most MaxWeight.  This keeps the transaction
+// within the standard weight limit when spending coins with large inputs,
+// such as multisig outputs.  If there is change, it must exceed
+// MinChangeAmount to be a valid selection.
+//
+// Coins are first accumulated from largest to smallest value as with
+// MinNumberCoinSelector, skipping any which would exceed MaxWeight.  When
+// that fails to reach the target, the weight is the binding constraint, so
+// coins are accumulated again in order of their value per byte, which prefers
+// coins that are cheaper to spend.  A MaxWeight of zero or less does not
+// limit the weight.
+type MaxWeightCoinSelector struct {
+	MaxInputs       int
+	MinChangeAmount btcutil.Amount
+	MaxWeight       int
+}
 
-			// attempt to lower priority towards target with lowest ones first
-			for n := 0; n < cutoffIndex; n++ {
-				if extendedCoins.Num() >= s.MaxInputs {
-					break
-				}
-				if possibleCoins[n].ValueAge() == 0 {
-					continue
-				}
+// CoinSelect will attempt to select coins using the algorithm described
+// in the MaxWeightCoinSelector struct.
+func (s MaxWeightCoinSelector) CoinSelect(targetValue btcutil.Amount, coins []Coin) (Coins, error) {
+	if targetValue == 0 {
+		return NewCoinSet(nil), nil
+	}
 
-				extendedCoins.PushCoin(possibleCoins[n])
-				if extendedCoins.TotalValueAge()/int64(extendedCoins.Num()) < s.MinAvgValueAgePerInput {
-					extendedCoins.PopCoin()
-					continue
-				}
-			}
-			return extendedCoins, nil
-		}
+	if s.MaxWeight <= 0 {
+		return MinNumberCoinSelector{
+			MaxInputs:       s.MaxInputs,
+			MinChangeAmount: s.MinChangeAmount,
+		}.CoinSelect(targetValue, coins)
 	}
 
-	return nil, ErrCoinsNoSelectionAvailable
-}
+	byValue := make([]Coin, 0, len(coins))
+	byValue = append(byValue, coins...)
+	sort.Sort(sort.Reverse(byAmount(byValue)))
+	cs, bestTotal := s.accumulate(targetValue, byValue)
+	if cs != nil {
+		return cs, nil
+	}
 
-type byValueAge []Coin
+	byValuePerByte := make([]Coin, 0, len(coins))
+	byValuePerByte = append(byValuePerByte, coins...)
+	sort.Sort(byEffectiveValuePerByte{byValuePerByte, 0})
+	cs, total := s.accumulate(targetValue, byValuePerByte)
+	if cs != nil {
+		return cs, nil
+	}
+	if total > bestTotal {
+		bestTotal = total
+	}
 
-func (a byValueAge) Len() int           { return len(a) }
-func (a byValueAge) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
-func (a byValueAge) Less(i, j int) bool { return a[i].ValueAge() < a[j].ValueAge() }
+	// Determine whether the weight is what prevented a selection.
+	var allTotal, reachable btcutil.Amount
+	for n, coin := range byValue {
+		allTotal += coin.Value()
+		if n < s.MaxInputs {
+			reachable += coin.Value()
+		}
+	}
+	reason := MaxWeightExceeded
+	switch {
+	case allTotal < targetValue:
+		reason = InsufficientFunds
+	case reachable < targetValue:
+		reason = MaxInputsExceeded
+	}
+	return nil, &SelectionError{Reason: reason, BestTotal: bestTotal}
+}
+
+// accumulate selects coins in the order passed until the target value is
+// met, skipping any coins which would exceed the maximum weight.  A nil Coins
+// is returned along with the total value reached when the target value is not
+// met.
+func (s MaxWeightCoinSelector) accumulate(targetValue btcutil.Amount, coins []Coin) (Coins, btcutil.Amount) {
+	cs := NewCoinSet(nil)
+	var weight int
+	for _, coin := range coins {
+		if cs.Num() >= s.MaxInputs {
+			break
+		}
+		coinWeight := EstimatedInputWeight(coin)
+		if weight+coinWeight > s.MaxWeight {
+			continue
+		}
+		cs.PushCoin(coin)
+		weight += coinWeight
+		if satisfiesTargetValue(targetValue, s.MinChangeAmount, cs.TotalValue()) {
+			return cs, cs.TotalValue()
+		}
+	}
+	return nil, cs.TotalValue()
+}
+
+// PinnedCoinSelector is a CoinSelector that attempts to construct a
+// selection of coins whose total value is at least targetValue which always
+// includes every coin in Pinned, such as an output which must be
+// consolidated.  The pinned coins count toward targetValue and MaxInputs,
+// and any remaining value is covered with as few of the other coins as
+// possible by preferring those with the largest values.  Coins which are
+// also pinned are ignored when they appear in the coins being selected from,
+// and a coin which appears in Pinned more than once is only included once.
+type PinnedCoinSelector struct {
+	MaxInputs       int
+	MinChangeAmount btcutil.Amount
+	Pinned          []Coin
+}
+
+// CoinSelect will attempt to select coins using the algorithm described
+// in the PinnedCoinSelector struct.
+func (s PinnedCoinSelector) CoinSelect(targetValue btcutil.Amount, coins []Coin) (Coins, error) {
+	pinned := make(map[wire.OutPoint]struct{}, len(s.Pinned))
+	pinnedCoins := filterCoins(s.Pinned, func(c Coin) bool {
+		op := *wire.NewOutPoint(c.Hash(), c.Index())
+		if _, dup := pinned[op]; dup {
+			return false
+		}
+		pinned[op] = struct{}{}
+		return true
+	})
+
+	cs := NewCoinSet(pinnedCoins)
+	if len(pinnedCoins) > s.MaxInputs {
+		return nil, &SelectionError{
+			Reason:    MaxInputsExceeded,
+			BestTotal: cs.TotalValue(),
+		}
+	}
+	if satisfiesTargetValue(targetValue, s.MinChangeAmount, cs.TotalValue()) {
+		return cs, nil
+	}
+
+	unpinned := filterCoins(coins, func(c Coin) bool {
+		_, ok := pinned[*wire.NewOutPoint(c.Hash(), c.Index())]
+		return !ok
+	})
+	sort.Sort(sort.Reverse(byAmount(unpinned)))
+
+	for n := 0; n < len(unpinned) && cs.Num() < s.MaxInputs; n++ {
+		cs.PushCoin(unpinned[n])
+		if satisfiesTargetValue(targetValue, s.MinChangeAmount, cs.TotalValue()) {
+			return cs, nil
+		}
+	}
+	allCoins := append(pinnedCoins, unpinned...)
+	return nil, selectionFailure(targetValue, s.MaxInputs, cs.TotalValue(),
+		allCoins, Coin.Value)
+}
+
+// RandomCoinSelector is a CoinSelector that attempts to construct a
+// selection of coins whose total value is at least targetValue by
+// accumulating the coins in a uniformly random order.  Rand is used as the
+// source of randomness and defaults to CryptoRandSource when nil.
+//
+// Randomizing the selection makes it harder for an observer to infer
+// anything about the wallet from the inputs which were chosen.
+type RandomCoinSelector struct {
+	MaxInputs       int
+	MinChangeAmount btcutil.Amount
+	Rand            RandSource
+}
+
+// CoinSelect will attempt to select coins using the algorithm described
+// in the RandomCoinSelector struct.
+func (s RandomCoinSelector) CoinSelect(targetValue btcutil.Amount, coins []Coin) (Coins, error) {
+	if targetValue == 0 {
+		return NewCoinSet(nil), nil
+	}
+
+	rng := s.Rand
+	if rng == nil {
+		rng = CryptoRandSource
+	}
+
+	shuffledCoins := make([]Coin, 0, len(coins))
+	shuffledCoins = append(shuffledCoins, coins...)
+	rng.Shuffle(len(shuffledCoins), func(i, j int) {
+		shuffledCoins[i], shuffledCoins[j] = shuffledCoins[j], shuffledCoins[i]
+	})
+
+	return MinIndexCoinSelector{
+		MaxInputs:       s.MaxInputs,
+		MinChangeAmount: s.MinChangeAmount,
+	}.CoinSelect(targetValue, shuffledCoins)
+}
+
+// WeightedRandomCoinSelector is a CoinSelector that attempts to construct a
+// selection of coins whose total value is at least targetValue by
+// repeatedly choosing one of the remaining coins at random, with a
+// probability proportional to its value, until the target is met.  Larger
+// coins are therefore more likely to be selected than with the
+// RandomCoinSelector, which tends to require fewer inputs, while the
+// selection remains hard to predict.  Coins with no value are never
+// selected.  Rand is used as the source of randomness and defaults to
+// CryptoRandSource when nil.
+type WeightedRandomCoinSelector struct {
+	MaxInputs       int
+	MinChangeAmount btcutil.Amount
+	Rand            RandSource
+}
+
+// CoinSelect will attempt to select coins using the algorithm described
+// in the WeightedRandomCoinSelector struct.
+func (s WeightedRandomCoinSelector) CoinSelect(targetValue btcutil.Amount, coins []Coin) (Coins, error) {
+	if targetValue == 0 {
+		return NewCoinSet(nil), nil
+	}
+
+	rng := s.Rand
+	if rng == nil {
+		rng = CryptoRandSource
+	}
+
+	var remainingValue btcutil.Amount
+	remaining := filterCoins(coins, func(c Coin) bool {
+		if c.Value() <= 0 {
+			return false
+		}
+		remainingValue += c.Value()
+		return true
+	})
+
+	cs := NewCoinSet(nil)
+	for cs.Num() < s.MaxInputs && len(remaining) > 0 {
+		// Find the coin whose share of the remaining value contains the
+		// randomly chosen amount.
+		r := randAmount(rng, remainingValue)
+		i := 0
+		for ; r >= remaining[i].Value(); i++ {
+			r -= remaining[i].Value()
+		}
+
+		coin := remaining[i]
+		remaining = append(remaining[:i], remaining[i+1:]...)
+		remainingValue -= coin.Value()
+		cs.PushCoin(coin)
+		if satisfiesTargetValue(targetValue, s.MinChangeAmount, cs.TotalValue()) {
+			return cs, nil
+		}
+	}
+	return nil, selectionFailure(targetValue, s.MaxInputs, cs.TotalValue(),
+		coins, Coin.Value)
+}
+
+// MinPriorityCoinSelector is a CoinSelector that attempts to construct
+// a selection of coins whose total value is at least targetValue and
+// whose average value-age per input is greater than MinAvgValueAgePerInput.
+// If there is change, it must exceed MinChangeAmount to be a valid selection.
+//
+// When possible, MinPriorityCoinSelector will attempt to reduce the average
+// input priority over the threshold, but no guarantees will be made as to
+// minimality of the selection.  The selection below is almost certainly
+// suboptimal.
+//
+type MinPriorityCoinSelector struct {
+	MaxInputs              int
+	MinChangeAmount        btcutil.Amount
+	MinAvgValueAgePerInput int64
+}
+
+// CoinSelect will attempt to select coins using the algorithm described
+// in the MinPriorityCoinSelector struct.
+func (s MinPriorityCoinSelector) CoinSelect(targetValue btcutil.Amount, coins []Coin) (Coins, error) {
+	if targetValue == 0 {
+		return NewCoinSet(nil), nil
+	}
+
+	possibleCoins := make([]Coin, 0, len(coins))
+	possibleCoins = append(possibleCoins, coins...)
+
+	sort.Sort(byValueAge(possibleCoins))
+
+	// find the first coin with sufficient valueAge
+	cutoffIndex := -1
+	for i := 0; i < len(possibleCoins); i++ {
+		if possibleCoins[i].ValueAge() >= s.MinAvgValueAgePerInput {
+			cutoffIndex = i
+			break
+		}
+	}
+	if cutoffIndex < 0 {
+		return nil, &SelectionError{Reason: InsufficientFunds}
+	}
+
+	// create sets of input coins that will obey minimum average valueAge
+	var highErr error
+	for i := cutoffIndex; i < len(possibleCoins); i++ {
+		possibleHighCoins := possibleCoins[cutoffIndex : i+1]
+
+		// choose a set of high-enough valueAge coins
+		highSelect, err := (&MinNumberCoinSelector{
+			MaxInputs:       s.MaxInputs,
+			MinChangeAmount: s.MinChangeAmount,
+		}).CoinSelect(targetValue, possibleHighCoins)
+
+		if err != nil {
+			highErr = err
+
+			// attempt to add available low priority to make a solution
+
+			for numLow := 1; numLow <= cutoffIndex && numLow+(i-cutoffIndex) <= s.MaxInputs; numLow++ {
+				allHigh := NewCoinSet(possibleCoins[cutoffIndex : i+1])
+				newTargetValue := targetValue - allHigh.TotalValue()
+				newMaxInputs := allHigh.Num() + numLow
+				if newMaxInputs > numLow {
+					newMaxInputs = numLow
+				}
+				newMinAvgValueAge := ((s.MinAvgValueAgePerInput * int64(allHigh.Num()+numLow)) - allHigh.TotalValueAge()) / int64(numLow)
+
+				// find the minimum priority that can be added to set
+				lowSelect, err := (&MinPriorityCoinSelector{
+					MaxInputs:              newMaxInputs,
+					MinChangeAmount:        s.MinChangeAmount,
+					MinAvgValueAgePerInput: newMinAvgValueAge,
+				}).CoinSelect(newTargetValue, possibleCoins[0:cutoffIndex])
+
+				if err != nil {
+					continue
+				}
+
+				for _, coin := range lowSelect.Coins() {
+					allHigh.PushCoin(coin)
+				}
+
+				return allHigh, nil
+			}
+			// oh well, couldn't fix, try to add more high priority to the set.
+		} else {
+			extendedCoins := NewCoinSet(highSelect.Coins())
+
+			// attempt to lower priority towards target with lowest ones first
+			for n := 0; n < cutoffIndex; n++ {
+				if extendedCoins.Num() >= s.MaxInputs {
+					break
+				}
+				if possibleCoins[n].ValueAge() == 0 {
+					continue
+				}
+
+				extendedCoins.PushCoin(possibleCoins[n])
+				if extendedCoins.TotalValueAge()/int64(extendedCoins.Num()) < s.MinAvgValueAgePerInput {
+					extendedCoins.PopCoin()
+					continue
+				}
+			}
+			return extendedCoins, nil
+		}
+	}
+
+	// The last attempt considered every coin with sufficient valueAge, so
+	// its failure describes the best that could be done.
+	return nil, highErr
+}
+
+// UniformScriptCoinSelector is a CoinSelector that only selects coins which
+// share a single script class, as determined by their PkScript, so that the
+// resulting transaction does not mix input types.  Mixing input types is a
+// common fingerprint which can be used to link the inputs to a single wallet.
+//
+// The coins are grouped by script class and Selector is run against each
+// group.  Of the groups that are able to meet the targetValue, the selection
+// with the fewest inputs is chosen, with ties broken by the smallest total
+// value.  When no group is able to meet the targetValue, the error of the
+// group which came closest is returned.
+type UniformScriptCoinSelector struct {
+	Selector CoinSelector
+}
+
+// CoinSelect will attempt to select coins using the algorithm described
+// in the UniformScriptCoinSelector struct.
+func (s UniformScriptCoinSelector) CoinSelect(targetValue btcutil.Amount, coins []Coin) (Coins, error) {
+	if targetValue == 0 {
+		return NewCoinSet(nil), nil
+	}
+
+	// Group the coins by script class while retaining the order in which
+	// each class was first seen so the result is deterministic.
+	var classes []txscript.ScriptClass
+	groups := make(map[txscript.ScriptClass][]Coin)
+	for _, coin := range coins {
+		class := txscript.GetScriptClass(coin.PkScript())
+		if _, ok := groups[class]; !ok {
+			classes = append(classes, class)
+		}
+		groups[class] = append(groups[class], coin)
+	}
+
+	var best *CoinSet
+	var failure error = &SelectionError{Reason: InsufficientFunds}
+	var bestFailure btcutil.Amount
+	for _, class := range classes {
+		selected, err := s.Selector.CoinSelect(targetValue, groups[class])
+		if err != nil {
+			// Report the failure of the group which came closest to
+			// meeting the target.
+			var selErr *SelectionError
+			if !errors.As(err, &selErr) {
+				failure = err
+			} else if selErr.BestTotal >= bestFailure {
+				failure, bestFailure = err, selErr.BestTotal
+			}
+			continue
+		}
+
+		cs := NewCoinSet(selected.Coins())
+		if best == nil || cs.Num() < best.Num() ||
+			(cs.Num() == best.Num() && cs.TotalValue() < best.TotalValue()) {
+			best = cs
+		}
+	}
+	if best == nil {
+		return nil, failure
+	}
+	return best, nil
+}
+
+// BestSingleCoin returns the index of the single coin in coins which is able
+// to cover targetValue with the smallest overpayment, subject to the same
+// change rules used by the CoinSelectors: the coin's value must either equal
+// targetValue exactly or exceed it by at least minChange.  Ties are broken in
+// favor of the lower index.  The second return value is false if no single
+// coin is able to cover targetValue.
+func BestSingleCoin(targetValue, minChange btcutil.Amount, coins []Coin) (int, bool) {
+	best := -1
+	for i, coin := range coins {
+		value := coin.Value()
+		if !satisfiesTargetValue(targetValue, minChange, value) {
+			continue
+		}
+		if best == -1 || value < coins[best].Value() {
+			best = i
+		}
+	}
+	return best, best != -1
+}
+
+// rangeSearchTries is the maximum number of partial selections the
+// RangeCoinSelector will consider before settling for the best selection it
+// has found so far.
+const rangeSearchTries = 100000
+
+// RangeCoinSelector is a CoinSelector that attempts to construct a
+// selection of at most MaxInputs coins whose total value is between
+// targetValue and targetValue+MaxExcess inclusive, preferring the selection
+// with the smallest total.  This is useful when excessive change must be
+// avoided.  Unlike the accumulating selectors, it will backtrack over coins
+// which would overshoot the maximum total to find a subset within range.
+//
+// The search considers coins from largest to smallest value and gives up
+// after a bounded number of steps, so for large numbers of coins it may fail
+// to find the best selection, or any selection, even if one exists.
+type RangeCoinSelector struct {
+	MaxInputs int
+	MaxExcess btcutil.Amount
+}
+
+// CoinSelect will attempt to select coins using the algorithm described
+// in the RangeCoinSelector struct.
+func (s RangeCoinSelector) CoinSelect(targetValue btcutil.Amount, coins []Coin) (Coins, error) {
+	if targetValue == 0 {
+		return NewCoinSet(nil), nil
+	}
+
+	maxTotal := targetValue + s.MaxExcess
+
+	sortedCoins := make([]Coin, 0, len(coins))
+	sortedCoins = append(sortedCoins, coins...)
+	sort.Sort(sort.Reverse(byAmount(sortedCoins)))
+
+	// remaining[i] is the total value of sortedCoins[i:], which bounds the
+	// total any selection extending a partial one can still reach.
+	remaining := make([]btcutil.Amount, len(sortedCoins)+1)
+	for i := len(sortedCoins) - 1; i >= 0; i-- {
+		remaining[i] = remaining[i+1] + sortedCoins[i].Value()
+	}
+
+	var best []int
+	var bestTotal btcutil.Amount
+	selected := make([]int, 0, len(sortedCoins))
+	tries := 0
+	var search func(i int, total btcutil.Amount)
+	search = func(i int, total btcutil.Amount) {
+		if tries >= rangeSearchTries || (best != nil && total >= bestTotal) {
+			return
+		}
+		tries++
+
+		if total >= targetValue {
+			best = append(best[:0], selected...)
+			bestTotal = total
+			return
+		}
+		if i == len(sortedCoins) || len(selected) >= s.MaxInputs ||
+			total+remaining[i] < targetValue {
+			return
+		}
+
+		// Try the selection both with and without the coin, skipping the
+		// coin entirely if it would overshoot the maximum total.
+		if value := sortedCoins[i].Value(); total+value <= maxTotal {
+			selected = append(selected, i)
+			search(i+1, total+value)
+			selected = selected[:len(selected)-1]
+		}
+		search(i+1, total)
+	}
+	search(0, 0)
+
+	if best == nil {
+		// Report the largest total reachable within MaxInputs.
+		var reachable btcutil.Amount
+		for n := 0; n < len(sortedCoins) && n < s.MaxInputs; n++ {
+			reachable += sortedCoins[n].Value()
+		}
+		reason := ExcessiveChange
+		switch {
+		case remaining[0] < targetValue:
+			reason = InsufficientFunds
+		case reachable < targetValue:
+			reason = MaxInputsExceeded
+		}
+		return nil, &SelectionError{Reason: reason, BestTotal: reachable}
+	}
+
+	cs := NewCoinSet(nil)
+	for _, i := range best {
+		cs.PushCoin(sortedCoins[i])
+	}
+	return cs, nil
+}
+
+// MaxTotalCoinSelector is a CoinSelector that attempts to construct a
+// selection of at most MaxInputs coins whose total value is at least
+// targetValue but never more than MaxTotal, which limits the funds locked up
+// by the transaction regardless of the target.  It searches in the same way
+// as RangeCoinSelector, abandoning any partial selection which would exceed
+// MaxTotal, and so also prefers the selection with the smallest total.  A
+// MaxTotal of zero or less does not limit the total.
+type MaxTotalCoinSelector struct {
+	MaxInputs int
+	MaxTotal  btcutil.Amount
+}
+
+// CoinSelect will attempt to select coins using the algorithm described
+// in the MaxTotalCoinSelector struct.
+func (s MaxTotalCoinSelector) CoinSelect(targetValue btcutil.Amount, coins []Coin) (Coins, error) {
+	maxExcess := s.MaxTotal - targetValue
+	if s.MaxTotal <= 0 {
+		maxExcess = btcutil.MaxSatoshi
+	}
+	return RangeCoinSelector{
+		MaxInputs: s.MaxInputs,
+		MaxExcess: maxExcess,
+	}.CoinSelect(targetValue, coins)
+}
+
+// NoChangeCoinSelector is a CoinSelector that prefers a selection of at most
+// MaxInputs coins whose total value is exactly targetValue, so that no change
+// output is needed.  It searches for such a selection in the same way as a
+// RangeCoinSelector with a MaxExcess of zero, and when none is found, falls
+// back to the selection made by a MinNumberCoinSelector with the same
+// MaxInputs and MinChangeAmount.
+type NoChangeCoinSelector struct {
+	MaxInputs       int
+	MinChangeAmount btcutil.Amount
+}
+
+// CoinSelect will attempt to select coins using the algorithm described
+// in the NoChangeCoinSelector struct.
+func (s NoChangeCoinSelector) CoinSelect(targetValue btcutil.Amount, coins []Coin) (Coins, error) {
+	exact := RangeCoinSelector{MaxInputs: s.MaxInputs, MaxExcess: 0}
+	if cs, err := exact.CoinSelect(targetValue, coins); err == nil {
+		return cs, nil
+	}
+	return MinNumberCoinSelector{
+		MaxInputs:       s.MaxInputs,
+		MinChangeAmount: s.MinChangeAmount,
+	}.CoinSelect(targetValue, coins)
+}
+
+// DefaultMinChangeIterations is the number of random passes used by the
+// MinChangeCoinSelector when Iterations is not set.
+const DefaultMinChangeIterations = 1000
+
+// MinChangeCoinSelector is a CoinSelector that attempts to construct a
+// selection of coins whose total value is at least targetValue while leaving
+// as little change as possible.  It approximates the best subset in the same
+// way as the reference implementation's wallet: each of Iterations runs
+// includes every coin, from largest to smallest value, with a probability of
+// one half, and then makes a second pass over the coins which were left out
+// if the target was not yet reached.  The selection with the smallest total
+// which satisfies the change rules is returned, or a single coin if it
+// leaves less change than any subset found.
+//
+// Rand is used as the source of randomness and defaults to CryptoRandSource
+// when nil.  The selection is deterministic for a deterministic source.
+// Iterations defaults to DefaultMinChangeIterations when not positive.
+type MinChangeCoinSelector struct {
+	MaxInputs       int
+	MinChangeAmount btcutil.Amount
+	Iterations      int
+	Rand            RandSource
+}
+
+// CoinSelect will attempt to select coins using the algorithm described
+// in the MinChangeCoinSelector struct.
+func (s MinChangeCoinSelector) CoinSelect(targetValue btcutil.Amount, coins []Coin) (Coins, error) {
+	if targetValue == 0 {
+		return NewCoinSet(nil), nil
+	}
+
+	rng := s.Rand
+	if rng == nil {
+		rng = CryptoRandSource
+	}
+	iterations := s.Iterations
+	if iterations <= 0 {
+		iterations = DefaultMinChangeIterations
+	}
+
+	sortedCoins := make([]Coin, 0, len(coins))
+	sortedCoins = append(sortedCoins, coins...)
+	sort.Sort(sort.Reverse(byAmount(sortedCoins)))
+
+	var best []bool
+	var bestTotal btcutil.Amount
+	if s.MaxInputs > 0 {
+		if i, ok := BestSingleCoin(targetValue, s.MinChangeAmount, sortedCoins); ok {
+			best = make([]bool, len(sortedCoins))
+			best[i] = true
+			bestTotal = sortedCoins[i].Value()
+		}
+	}
+
+	included := make([]bool, len(sortedCoins))
+	for n := 0; n < iterations && (best == nil || bestTotal != targetValue); n++ {
+		for i := range included {
+			included[i] = false
+		}
+		var total btcutil.Amount
+		var numInputs int
+		reachedTarget := false
+		for pass := 0; pass < 2 && !reachedTarget; pass++ {
+			for i, coin := range sortedCoins {
+				if numInputs >= s.MaxInputs {
+					break
+				}
+				if pass == 0 && rng.Intn(2) == 0 || pass == 1 && included[i] {
+					continue
+				}
+				total += coin.Value()
+				included[i] = true
+				numInputs++
+				if total < targetValue {
+					continue
+				}
+
+				// Record the selection if it is an improvement and
+				// then remove the coin to look for a smaller total.
+				reachedTarget = true
+				if satisfiesTargetValue(targetValue, s.MinChangeAmount, total) &&
+					(best == nil || total < bestTotal) {
+					best = append(best[:0], included...)
+					bestTotal = total
+				}
+				total -= coin.Value()
+				included[i] = false
+				numInputs--
+			}
+		}
+	}
+
+	if best == nil {
+		var reachable btcutil.Amount
+		for n := 0; n < len(sortedCoins) && n < s.MaxInputs; n++ {
+			reachable += sortedCoins[n].Value()
+		}
+		return nil, selectionFailure(targetValue, s.MaxInputs, reachable,
+			coins, Coin.Value)
+	}
+
+	cs := NewCoinSet(nil)
+	for i, coin := range sortedCoins {
+		if best[i] {
+			cs.PushCoin(coin)
+		}
+	}
+	return cs, nil
+}
+
+// FirstFitDecreasingCoinSelector is a CoinSelector that attempts to construct
+// a selection of coins whose total value is at least targetValue while
+// leaving little change, as a cheaper middle ground between
+// MinNumberCoinSelector and MinChangeCoinSelector.  Like the first fit
+// decreasing heuristic for bin packing, it visits the coins from largest to
+// smallest value and includes each coin which does not take the total past
+// targetValue.  When this does not reach targetValue exactly, the selection
+// is completed with the smallest remaining coin which leaves at least
+// MinChangeAmount of change, so coins which would leave dust change are
+// skipped in favor of smaller ones.  The selection made by a
+// MinNumberCoinSelector with the same MaxInputs and MinChangeAmount is
+// returned instead when it leaves less change, or when no single coin can
+// complete the selection.
+type FirstFitDecreasingCoinSelector struct {
+	MaxInputs       int
+	MinChangeAmount btcutil.Amount
+}
+
+// CoinSelect will attempt to select coins using the algorithm described
+// in the FirstFitDecreasingCoinSelector struct.
+func (s FirstFitDecreasingCoinSelector) CoinSelect(targetValue btcutil.Amount, coins []Coin) (Coins, error) {
+	if targetValue == 0 {
+		return NewCoinSet(nil), nil
+	}
+
+	sortedCoins := make([]Coin, 0, len(coins))
+	sortedCoins = append(sortedCoins, coins...)
+	sort.Sort(sort.Reverse(byAmount(sortedCoins)))
+
+	// Include every coin which fits below the target, leaving room for
+	// one more input to complete the selection.
+	cs := NewCoinSet(nil)
+	var total btcutil.Amount
+	var unused []Coin
+	for _, coin := range sortedCoins {
+		if cs.Num() < s.MaxInputs-1 && total+coin.Value() <= targetValue {
+			cs.PushCoin(coin)
+			total += coin.Value()
+			continue
+		}
+		unused = append(unused, coin)
+	}
+	if total == targetValue && cs.Num() > 0 {
+		return cs, nil
+	}
+
+	// Every unused coin now takes the total past the target, so the
+	// last of them to leave enough change leaves the least change.
+	var best Coin
+	for _, coin := range unused {
+		if satisfiesTargetValue(targetValue, s.MinChangeAmount,
+			total+coin.Value()) {
+			best = coin
+		}
+	}
+	greedy, err := MinNumberCoinSelector{
+		MaxInputs:       s.MaxInputs,
+		MinChangeAmount: s.MinChangeAmount,
+	}.CoinSelect(targetValue, coins)
+	if best == nil || s.MaxInputs <= 0 {
+		return greedy, err
+	}
+	cs.PushCoin(best)
+	if err == nil && ChangeAmount(greedy, targetValue) < cs.TotalValue()-targetValue {
+		return greedy, nil
+	}
+	return cs, nil
+}
+
+// FallbackCoinSelector is a CoinSelector which tries each of Selectors in
+// order and returns the first successful selection.  This allows a
+// preferred but less reliable algorithm to be combined with one that is more
+// likely to find a selection.  If none of the selectors succeed, the error
+// returned by the last of them is returned, or a SelectionError when there
+// are no Selectors.
+type FallbackCoinSelector struct {
+	Selectors []CoinSelector
+}
+
+// CoinSelect will attempt to select coins using the algorithm described
+// in the FallbackCoinSelector struct.
+func (s FallbackCoinSelector) CoinSelect(targetValue btcutil.Amount, coins []Coin) (Coins, error) {
+	if targetValue == 0 {
+		return NewCoinSet(nil), nil
+	}
+	var err error = &SelectionError{Reason: InsufficientFunds}
+	for _, selector := range s.Selectors {
+		var selected Coins
+		selected, err = selector.CoinSelect(targetValue, coins)
+		if err == nil {
+			return selected, nil
+		}
+	}
+	return nil, err
+}
+
+// DustFilterCoinSelector is a CoinSelector which wraps another
+// CoinSelector and removes any coins whose value is less than or equal to
+// DustThreshold before running it.  Spending such coins typically costs more
+// in fees than they are worth, so they are never selected even when they
+// would help reach the target value.  A DustThreshold of zero leaves the
+// coins unfiltered.
+type DustFilterCoinSelector struct {
+	Selector      CoinSelector
+	DustThreshold btcutil.Amount
+}
+
+// CoinSelect will attempt to select coins using the algorithm described
+// in the DustFilterCoinSelector struct.
+func (s DustFilterCoinSelector) CoinSelect(targetValue btcutil.Amount, coins []Coin) (Coins, error) {
+	if targetValue == 0 {
+		return NewCoinSet(nil), nil
+	}
+	if s.DustThreshold <= 0 {
+		return s.Selector.CoinSelect(targetValue, coins)
+	}
+	filtered := filterCoins(coins, func(c Coin) bool {
+		return c.Value() > s.DustThreshold
+	})
+	if len(coins) > 0 && len(filtered) == 0 {
+		return nil, &SelectionError{Reason: AllDust}
+	}
+	return s.Selector.CoinSelect(targetValue, filtered)
+}
+
+// MaxAchievable returns the largest total value a selection of at most
+// maxInputs coins can reach, which is the total of the maxInputs most valuable
+// coins, ignoring any coins whose value is less than or equal to
+// dustThreshold as DustFilterCoinSelector does.  Callers may compare it with
+// a target value to determine whether the target can be met at all before
+// attempting a selection.
+func MaxAchievable(coins []Coin, maxInputs int, dustThreshold btcutil.Amount) btcutil.Amount {
+	if maxInputs <= 0 {
+		return 0
+	}
+	sortedCoins := filterCoins(coins, func(c Coin) bool {
+		return c.Value() > dustThreshold
+	})
+	sort.Sort(sort.Reverse(byAmount(sortedCoins)))
+
+	var total btcutil.Amount
+	for n := 0; n < len(sortedCoins) && n < maxInputs; n++ {
+		total += sortedCoins[n].Value()
+	}
+	return total
+}
+
+// SelectForOutputs selects coins with the passed selector to pay each of
+// the outputs of a transaction, along with perOutputFee for each output.  The
+// target value is the total of the outputs and their fees, so the
+// MinChangeAmount of the selector applies to the single change output of the
+// transaction.  ErrNoOutputs is returned when there are no outputs, and
+// ErrDustOutput is returned when any output amount is less than or equal to
+// dustThreshold.
+func SelectForOutputs(selector CoinSelector, outputs []btcutil.Amount,
+	perOutputFee, dustThreshold btcutil.Amount, coins []Coin) (Coins, error) {
+
+	if len(outputs) == 0 {
+		return nil, ErrNoOutputs
+	}
+	var targetValue btcutil.Amount
+	for _, amount := range outputs {
+		if amount <= dustThreshold {
+			return nil, ErrDustOutput
+		}
+		targetValue += amount + perOutputFee
+	}
+	return selector.CoinSelect(targetValue, coins)
+}
+
+// filterCoins returns a new slice containing the coins for which keep
+// returns true, preserving their order.
+func filterCoins(coins []Coin, keep func(Coin) bool) []Coin {
+	filtered := make([]Coin, 0, len(coins))
+	for _, coin := range coins {
+		if keep(coin) {
+			filtered = append(filtered, coin)
+		}
+	}
+	return filtered
+}
+
+// MinConfCoinSelector is a CoinSelector which wraps another CoinSelector
+// and removes any coins with fewer than MinConf confirmations before running
+// it.  This may be used to avoid spending outputs of transactions which could
+// still be double spent or reorganized out of the chain.  A MinConf of zero
+// or less leaves the coins unfiltered.
+type MinConfCoinSelector struct {
+	Selector CoinSelector
+	MinConf  int64
+}
+
+// CoinSelect will attempt to select coins using the wrapped selector from
+// only those coins which have at least MinConf confirmations.
+func (s MinConfCoinSelector) CoinSelect(targetValue btcutil.Amount, coins []Coin) (Coins, error) {
+	if s.MinConf <= 0 {
+		return s.Selector.CoinSelect(targetValue, coins)
+	}
+	confirmed := filterCoins(coins, func(c Coin) bool {
+		return c.NumConfs() >= s.MinConf
+	})
+	return s.Selector.CoinSelect(targetValue, confirmed)
+}
+
+// LockedCoinSelector is a CoinSelector which wraps another CoinSelector and
+// removes any coins whose outpoint is in Locked before running it.  This may
+// be used to ensure outputs which are reserved for another purpose, such as
+// funding a payment channel, are never spent.  A nil or empty Locked set
+// leaves the coins unfiltered.
+type LockedCoinSelector struct {
+	Selector CoinSelector
+	Locked   map[wire.OutPoint]struct{}
+}
+
+// CoinSelect will attempt to select coins using the wrapped selector from
+// only those coins which are not locked.
+func (s LockedCoinSelector) CoinSelect(targetValue btcutil.Amount, coins []Coin) (Coins, error) {
+	if len(s.Locked) == 0 {
+		return s.Selector.CoinSelect(targetValue, coins)
+	}
+	unlocked := filterCoins(coins, func(c Coin) bool {
+		_, locked := s.Locked[*wire.NewOutPoint(c.Hash(), c.Index())]
+		return !locked
+	})
+	return s.Selector.CoinSelect(targetValue, unlocked)
+}
+
+// SpendableCoinSelector is a CoinSelector which wraps another CoinSelector
+// and removes any coins implementing SpendableCoin which can not be spent in
+// a block at SpendHeight before running it.  This is normally the height of
+// the block following the current best block.
+type SpendableCoinSelector struct {
+	Selector    CoinSelector
+	SpendHeight int32
+}
+
+// CoinSelect will attempt to select coins using the wrapped selector from
+// only those coins which are spendable at SpendHeight.
+func (s SpendableCoinSelector) CoinSelect(targetValue btcutil.Amount, coins []Coin) (Coins, error) {
+	spendable := filterCoins(coins, func(c Coin) bool {
+		sc, ok := c.(SpendableCoin)
+		return !ok || sc.SpendableAtHeight(s.SpendHeight)
+	})
+	return s.Selector.CoinSelect(targetValue, spendable)
+}
+
+// LabelCoinSelector is a CoinSelector which wraps another CoinSelector and
+// removes any coins which do not implement LabeledCoin with a label equal to
+// Label before running it, so that all of the selected coins share the same
+// label.  An empty Label leaves the coins unfiltered.
+type LabelCoinSelector struct {
+	Selector CoinSelector
+	Label    string
+}
+
+// CoinSelect will attempt to select coins using the wrapped selector from
+// only those coins which have the selector's label.
+func (s LabelCoinSelector) CoinSelect(targetValue btcutil.Amount, coins []Coin) (Coins, error) {
+	if s.Label == "" {
+		return s.Selector.CoinSelect(targetValue, coins)
+	}
+	labeled := filterCoins(coins, func(c Coin) bool {
+		lc, ok := c.(LabeledCoin)
+		return ok && lc.Label() == s.Label
+	})
+	return s.Selector.CoinSelect(targetValue, labeled)
+}
+
+// DistinctAddressCoinSelector is a CoinSelector which wraps another
+// CoinSelector and prefers selections in which no two coins pay to the same
+// address, so that spending them does not further link outputs which were
+// sent to a reused address.  The wrapped selector is first run with only the
+// first coin paying each address, in the order passed, along with any coins
+// which do not implement AddressCoin or have no address.  This is a best
+// effort rather than a constraint: when no selection is possible from those
+// coins, the wrapped selector is run again with all of the coins.
+type DistinctAddressCoinSelector struct {
+	Selector CoinSelector
+}
+
+// CoinSelect will attempt to select coins using the algorithm described
+// in the DistinctAddressCoinSelector struct.
+func (s DistinctAddressCoinSelector) CoinSelect(targetValue btcutil.Amount, coins []Coin) (Coins, error) {
+	seen := make(map[string]struct{}, len(coins))
+	distinct := filterCoins(coins, func(c Coin) bool {
+		ac, ok := c.(AddressCoin)
+		if !ok || ac.Address() == nil {
+			return true
+		}
+		addr := ac.Address().EncodeAddress()
+		if _, dup := seen[addr]; dup {
+			return false
+		}
+		seen[addr] = struct{}{}
+		return true
+	})
+
+	selected, err := s.Selector.CoinSelect(targetValue, distinct)
+	if err == nil || len(distinct) == len(coins) {
+		return selected, err
+	}
+	return s.Selector.CoinSelect(targetValue, coins)
+}
+
+// ChangeAmount returns the amount of change left over when the selected coins
+// are spent to pay targetValue, which is their total value less targetValue.
+// Zero is returned when the selection does not exceed the target value,
+// including when selected is nil.
+func ChangeAmount(selected Coins, targetValue btcutil.Amount) btcutil.Amount {
+	if selected == nil {
+		return 0
+	}
+	var total btcutil.Amount
+	for _, coin := range selected.Coins() {
+		total += coin.Value()
+	}
+	if total <= targetValue {
+		return 0
+	}
+	return total - targetValue
+}
+
+// InputHeadroom returns the number of additional inputs which could be
+// added to the selected coins before the selection would have more than
+// maxInputs inputs, such as to increase the fee of a transaction which must
+// be bumped later.  Zero is returned when the selection already has
+// maxInputs or more inputs.  A nil selection has no inputs.
+func InputHeadroom(selected Coins, maxInputs int) int {
+	var numInputs int
+	if selected != nil {
+		numInputs = len(selected.Coins())
+	}
+	if numInputs >= maxInputs {
+		return 0
+	}
+	return maxInputs - numInputs
+}
+
+// ValidateSelection checks that the selected coins, which may have been chosen
+// by hand or by another implementation, satisfy the same rules the selectors
+// in this package enforce.  ErrTooManyInputs is returned if there are more
+// than maxInputs coins, and ErrDustInput is returned if any coin's value is
+// less than or equal to dustThreshold, where a dustThreshold of zero disables
+// that check.  ErrBelowTarget is returned if the total value is less than
+// targetValue, and ErrInsufficientChange is returned if the total exceeds
+// targetValue by less than minChange.
+func ValidateSelection(targetValue btcutil.Amount, selected []Coin, maxInputs int,
+	minChange, dustThreshold btcutil.Amount) error {
+
+	if len(selected) > maxInputs {
+		return ErrTooManyInputs
+	}
+	var totalValue btcutil.Amount
+	for _, coin := range selected {
+		if dustThreshold > 0 && coin.Value() <= dustThreshold {
+			return ErrDustInput
+		}
+		totalValue += coin.Value()
+	}
+	if totalValue < targetValue {
+		return ErrBelowTarget
+	}
+	if !satisfiesTargetValue(targetValue, minChange, totalValue) {
+		return ErrInsufficientChange
+	}
+	return nil
+}
+
+// Unselected returns the coins which are not part of the selection, in
+// their original order.  Since selectors return the selected coins rather
+// than their indexes, coins are matched by outpoint, so the result is
+// correct regardless of how the selector reordered the coins.  When coins
+// contains the same outpoint more than once, each selected coin accounts for
+// only one of them.
+func Unselected(coins []Coin, selected Coins) []Coin {
+	remaining := make(map[wire.OutPoint]int)
+	if selected != nil {
+		for _, coin := range selected.Coins() {
+			remaining[*wire.NewOutPoint(coin.Hash(), coin.Index())]++
+		}
+	}
+	return filterCoins(coins, func(c Coin) bool {
+		op := *wire.NewOutPoint(c.Hash(), c.Index())
+		if remaining[op] > 0 {
+			remaining[op]--
+			return false
+		}
+		return true
+	})
+}
+
+// Selection describes a successful coin selection made by Select.
+type Selection struct {
+	// Indexes holds the index of each of Coins in the coins passed to
+	// Select, or -1 for a coin the selector included which was not passed,
+	// such as a coin pinned by a PinnedCoinSelector.
+	Indexes []int
+
+	// Coins holds the selected coins in the order they were selected.
+	Coins []Coin
+
+	// Total is the total value of the selected coins.
+	Total btcutil.Amount
+
+	// Change is the amount by which Total exceeds the target value.
+	Change btcutil.Amount
+
+	// NumInputs is the number of selected coins.
+	NumInputs int
+}
+
+// Select selects coins to pay targetValue with the passed selector and
+// describes the result as a Selection, saving callers from recomputing the
+// indexes, total, and change of the selected coins.  As with Unselected,
+// the selected coins are matched with coins by outpoint, and when coins
+// contains the same outpoint more than once, each selected coin accounts for
+// only one of them.
+func Select(selector CoinSelector, targetValue btcutil.Amount, coins []Coin) (*Selection, error) {
+	selected, err := selector.CoinSelect(targetValue, coins)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make(map[wire.OutPoint][]int)
+	for i, coin := range coins {
+		op := *wire.NewOutPoint(coin.Hash(), coin.Index())
+		indexes[op] = append(indexes[op], i)
+	}
+
+	selectedCoins := selected.Coins()
+	s := &Selection{
+		Indexes:   make([]int, len(selectedCoins)),
+		Coins:     selectedCoins,
+		Change:    ChangeAmount(selected, targetValue),
+		NumInputs: len(selectedCoins),
+	}
+	for i, coin := range selectedCoins {
+		s.Total += coin.Value()
+		op := *wire.NewOutPoint(coin.Hash(), coin.Index())
+		if remaining := indexes[op]; len(remaining) > 0 {
+			s.Indexes[i] = remaining[0]
+			indexes[op] = remaining[1:]
+		} else {
+			s.Indexes[i] = -1
+		}
+	}
+	return s, nil
+}
+
+// Strategy identifies one of the coin selection algorithms of this package
+// for use with SelectStable.
+type Strategy int
+
+const (
+	// MinNumber selects as few coins as possible as with
+	// MinNumberCoinSelector.
+	MinNumber Strategy = iota
+
+	// MaxValueAge selects the coins with the most value-age first as with
+	// MaxValueAgeCoinSelector.
+	MaxValueAge
+
+	// SmallestFirst selects the coins with the smallest values first as
+	// with SmallestFirstCoinSelector.
+	SmallestFirst
+
+	// MinChange searches for the selection leaving the least change as
+	// with MinChangeCoinSelector, using CryptoRandSource.
+	MinChange
+)
+
+// selector returns the CoinSelector implementing the strategy, or false if
+// the strategy is unknown.
+func (s Strategy) selector(maxInputs int, minChangeAmount btcutil.Amount) (CoinSelector, bool) {
+	switch s {
+	case MinNumber:
+		return MinNumberCoinSelector{
+			MaxInputs:       maxInputs,
+			MinChangeAmount: minChangeAmount,
+		}, true
+	case MaxValueAge:
+		return MaxValueAgeCoinSelector{
+			MaxInputs:       maxInputs,
+			MinChangeAmount: minChangeAmount,
+		}, true
+	case SmallestFirst:
+		return SmallestFirstCoinSelector{maxInputs, minChangeAmount}, true
+	case MinChange:
+		return MinChangeCoinSelector{
+			MaxInputs:       maxInputs,
+			MinChangeAmount: minChangeAmount,
+		}, true
+	}
+	return nil, false
+}
+
+// SelectStable selects coins to pay targetValue using the passed strategy
+// with at most maxInputs inputs and, unless the selection is exact, at
+// least minChangeAmount of change.  The selector only ever sees a copy of
+// coins, so the caller's slice is never reordered even by a selector which
+// does not take care to preserve it, and the selected coins are returned in
+// a newly allocated slice which does not share memory with coins.
+func SelectStable(strategy Strategy, maxInputs int, minChangeAmount btcutil.Amount,
+	targetValue btcutil.Amount, coins []Coin) ([]Coin, error) {
+
+	selector, ok := strategy.selector(maxInputs, minChangeAmount)
+	if !ok {
+		return nil, ErrUnknownStrategy
+	}
+
+	input := make([]Coin, len(coins))
+	copy(input, coins)
+	selected, err := selector.CoinSelect(targetValue, input)
+	if err != nil {
+		return nil, err
+	}
+
+	selectedCoins := selected.Coins()
+	result := make([]Coin, len(selectedCoins))
+	copy(result, selectedCoins)
+	return result, nil
+}
+
+// SelectionAudit is a record describing a successful coin selection which
+// is passed to the Audit function of an AuditCoinSelector.
+type SelectionAudit struct {
+	// TargetValue is the value which was requested from the selector.
+	TargetValue btcutil.Amount
+
+	// TotalValue is the total value of the selected coins.
+	TotalValue btcutil.Amount
+
+	// NumInputs is the number of selected coins.
+	NumInputs int
+
+	// Change is the amount by which TotalValue exceeds TargetValue.
+	Change btcutil.Amount
+
+	// Waste is the fee required to spend the selected coins at the
+	// FeePerByte of the AuditCoinSelector, with the size of each input
+	// determined by EstimatedInputSize.
+	Waste btcutil.Amount
+
+	// PrivacyScore is the reciprocal of the number of distinct script
+	// classes among the selected coins.  A score of 1 means no input
+	// types were mixed, while lower scores indicate the inputs are easier
+	// to fingerprint.
+	PrivacyScore float64
+}
+
+// AuditCoinSelector is a CoinSelector which wraps another CoinSelector
+// and reports each successful selection to Audit.  This can be used for
+// logging and debugging the behavior of a selector without changing the
+// selection itself.  When Audit is nil the wrapped selector is called
+// directly.
+type AuditCoinSelector struct {
+	Selector   CoinSelector
+	FeePerByte btcutil.Amount
+	Audit      func(*SelectionAudit)
+}
+
+// CoinSelect will attempt to select coins using the algorithm described
+// in the AuditCoinSelector struct.
+func (s AuditCoinSelector) CoinSelect(targetValue btcutil.Amount, coins []Coin) (Coins, error) {
+	selected, err := s.Selector.CoinSelect(targetValue, coins)
+	if err != nil || s.Audit == nil {
+		return selected, err
+	}
+
+	audit := &SelectionAudit{TargetValue: targetValue}
+	classes := make(map[txscript.ScriptClass]struct{})
+	for _, coin := range selected.Coins() {
+		audit.TotalValue += coin.Value()
+		audit.NumInputs++
+		audit.Waste += btcutil.Amount(EstimatedInputSize(coin)) * s.FeePerByte
+		classes[txscript.GetScriptClass(coin.PkScript())] = struct{}{}
+	}
+	audit.Change = audit.TotalValue - targetValue
+	if len(classes) > 0 {
+		audit.PrivacyScore = 1 / float64(len(classes))
+	}
+	s.Audit(audit)
+
+	return selected, nil
+}
+
+// lessOutPoint orders coins by their outpoint, comparing the hash bytes and
+// then the output index.  It is used to break ties between coins which are
+// equal under the primary sort key so selections do not depend on the order
+// the coins were provided in.
+func lessOutPoint(a, b Coin) bool {
+	if cmp := bytes.Compare(a.Hash()[:], b.Hash()[:]); cmp != 0 {
+		return cmp < 0
+	}
+	return a.Index() < b.Index()
+}
+
+type byValueAge []Coin
+
+func (a byValueAge) Len() int      { return len(a) }
+func (a byValueAge) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byValueAge) Less(i, j int) bool {
+	if a[i].ValueAge() != a[j].ValueAge() {
+		return a[i].ValueAge() < a[j].ValueAge()
+	}
+	return lessOutPoint(a[i], a[j])
+}
+
+type byNumConfs []Coin
+
+func (a byNumConfs) Len() int      { return len(a) }
+func (a byNumConfs) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byNumConfs) Less(i, j int) bool {
+	if a[i].NumConfs() != a[j].NumConfs() {
+		return a[i].NumConfs() < a[j].NumConfs()
+	}
+	return lessOutPoint(a[i], a[j])
+}
+
+// byEffectiveValuePerByte sorts coins by descending value per byte after
+// deducting the fee required to spend them.
+type byEffectiveValuePerByte struct {
+	coins      []Coin
+	feePerByte btcutil.Amount
+}
+
+func (a byEffectiveValuePerByte) Len() int      { return len(a.coins) }
+func (a byEffectiveValuePerByte) Swap(i, j int) { a.coins[i], a.coins[j] = a.coins[j], a.coins[i] }
+func (a byEffectiveValuePerByte) Less(i, j int) bool {
+	vi, vj := a.valuePerByte(a.coins[i]), a.valuePerByte(a.coins[j])
+	if vi != vj {
+		return vi > vj
+	}
+	return lessOutPoint(a.coins[i], a.coins[j])
+}
+
+func (a byEffectiveValuePerByte) valuePerByte(c Coin) float64 {
+	size := EstimatedInputSize(c)
+	value := c.Value() - btcutil.Amount(size)*a.feePerByte
+	return float64(value) / float64(size)
+}
 
 type byAmount []Coin
 
-func (a byAmount) Len() int           { return len(a) }
-func (a byAmount) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
-func (a byAmount) Less(i, j int) bool { return a[i].Value() < a[j].Value() }
+func (a byAmount) Len() int      { return len(a) }
+func (a byAmount) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byAmount) Less(i, j int) bool {
+	if a[i].Value() != a[j].Value() {
+		return a[i].Value() < a[j].Value()
+	}
+	return lessOutPoint(a[i], a[j])
+}
+
+// coinHeap implements heap.Interface as a min-heap of coins ordered by
+// value.
+type coinHeap []Coin
+
+func (h coinHeap) Len() int           { return len(h) }
+func (h coinHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h coinHeap) Less(i, j int) bool { return byAmount(h).Less(i, j) }
+
+func (h *coinHeap) Push(x interface{}) {
+	*h = append(*h, x.(Coin))
+}
+
+func (h *coinHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	coin := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return coin
+}
 
 // SimpleCoin defines a concrete instance of Coin that is backed by a
 // btcutil.Tx, a specific outpoint index, and the number of confirmations
@@ -354,6 +2236,21 @@ type SimpleCoin struct {
 // Ensure that SimpleCoin is a Coin
 var _ Coin = &SimpleCoin{}
 
+// NewSimpleCoin returns a SimpleCoin for the output at index txIndex of the
+// passed transaction, which has numConfs confirmations.
+// ErrOutputIndexOutOfRange is returned if the transaction does not have an
+// output at that index.
+func NewSimpleCoin(tx *btcutil.Tx, txIndex uint32, numConfs int64) (*SimpleCoin, error) {
+	if tx == nil || uint64(txIndex) >= uint64(len(tx.MsgTx().TxOut)) {
+		return nil, ErrOutputIndexOutOfRange
+	}
+	return &SimpleCoin{
+		Tx:         tx,
+		TxIndex:    txIndex,
+		TxNumConfs: numConfs,
+	}, nil
+}
+
 // Hash returns the hash value of the transaction on which the Coin is an output
 func (c *SimpleCoin) Hash() *chainhash.Hash {
 	return c.Tx.Hash()
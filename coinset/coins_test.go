@@ -8,10 +8,15 @@ import (
 	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"math/rand"
+	"reflect"
 	"testing"
 
+	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcutil/coinset"
@@ -44,6 +49,40 @@ func NewCoin(index int64, value btcutil.Amount, numConfs int64) coinset.Coin {
 	return coinset.Coin(c)
 }
 
+// TestOptionCoin is a TestCoin which also implements each of the optional
+// coin interfaces, reporting the values of its fields.  A zero TxSize reports
+// the DefaultInputSize, a nil TxAddress reports no address and a zero
+// TxMaturity is spendable at any height, so only the fields a test is
+// concerned with need to be set.
+type TestOptionCoin struct {
+	TestCoin
+	TxPkScript []byte
+	TxAddress  btcutil.Address
+	TxLabel    string
+	TxSize     int
+	TxMaturity int32
+}
+
+func (c *TestOptionCoin) PkScript() []byte         { return c.TxPkScript }
+func (c *TestOptionCoin) Address() btcutil.Address { return c.TxAddress }
+func (c *TestOptionCoin) Label() string            { return c.TxLabel }
+
+func (c *TestOptionCoin) SpendableAtHeight(height int32) bool { return height >= c.TxMaturity }
+
+func (c *TestOptionCoin) EstimatedSize() int {
+	if c.TxSize == 0 {
+		return coinset.DefaultInputSize
+	}
+	return c.TxSize
+}
+
+// NewOptionCoin returns a copy of opts for the coin created by NewCoin with
+// the passed index, value and number of confirmations.
+func NewOptionCoin(index int64, value btcutil.Amount, numConfs int64, opts TestOptionCoin) *TestOptionCoin {
+	opts.TestCoin = *NewCoin(index, value, numConfs).(*TestCoin)
+	return &opts
+}
+
 type coinSelectTest struct {
 	selector      coinset.CoinSelector
 	inputCoins    []coinset.Coin
@@ -55,7 +94,7 @@ type coinSelectTest struct {
 func testCoinSelector(tests []coinSelectTest, t *testing.T) {
 	for testIndex, test := range tests {
 		cs, err := test.selector.CoinSelect(test.targetValue, test.inputCoins)
-		if err != test.expectedError {
+		if !errors.Is(err, test.expectedError) {
 			t.Errorf("[%d] expected a different error: got=%v, expected=%v", testIndex, err, test.expectedError)
 			continue
 		}
@@ -164,9 +203,138 @@ func TestMinNumberSelector(t *testing.T) {
 	testCoinSelector(minNumberTests, t)
 }
 
+// traceEvent is an event recorded from the Trace function of a selector.
+type traceEvent struct {
+	event string
+	total btcutil.Amount
+	idx   int
+}
+
+func TestMinNumberSelectorTrace(t *testing.T) {
+	tests := []struct {
+		maxInputs int
+		target    btcutil.Amount
+		events    []traceEvent
+	}{
+		{10, 120000000, []traceEvent{
+			{coinset.TraceAdd, 100000000, 0},
+			{coinset.TraceAdd, 150000000, 2},
+			{coinset.TraceSelect, 150000000, -1},
+		}},
+		{2, 200000000, []traceEvent{
+			{coinset.TraceAdd, 100000000, 0},
+			{coinset.TraceAdd, 150000000, 2},
+			{coinset.TraceSkip, 150000000, 3},
+			{coinset.TraceSkip, 150000000, 1},
+			{coinset.TraceFail, 150000000, -1},
+		}},
+	}
+
+	for i, test := range tests {
+		var events []traceEvent
+		selector := coinset.MinNumberCoinSelector{
+			MaxInputs: test.maxInputs,
+			Trace: func(event string, total btcutil.Amount, idx int) {
+				events = append(events, traceEvent{event, total, idx})
+			},
+		}
+		selector.CoinSelect(test.target, coins)
+		if !reflect.DeepEqual(events, test.events) {
+			t.Errorf("[%d] got trace %v, want %v", i, events,
+				test.events)
+		}
+	}
+}
+
+var smallestFirstSelectors = []coinset.SmallestFirstCoinSelector{
+	{MaxInputs: 10, MinChangeAmount: 10000},
+	{MaxInputs: 2, MinChangeAmount: 10000},
+}
+
+var smallestFirstTests = []coinSelectTest{
+	{smallestFirstSelectors[0], coins, 10000000, []coinset.Coin{coins[1]}, nil},
+	{smallestFirstSelectors[0], coins, 10000001, []coinset.Coin{coins[1], coins[3]}, nil},
+	{smallestFirstSelectors[0], coins, 35000000, []coinset.Coin{coins[1], coins[3]}, nil},
+	{smallestFirstSelectors[0], coins, 80000000, []coinset.Coin{coins[1], coins[3], coins[2]}, nil},
+	{smallestFirstSelectors[0], coins, 185000000, []coinset.Coin{coins[1], coins[3], coins[2], coins[0]}, nil},
+	{smallestFirstSelectors[0], coins, 185000001, nil, coinset.ErrCoinsNoSelectionAvailable},
+	{smallestFirstSelectors[1], coins, 35000000, []coinset.Coin{coins[1], coins[3]}, nil},
+	// MaxInputs is reached before the target even though the largest
+	// coin alone would cover it.
+	{smallestFirstSelectors[1], coins, 40000000, nil, coinset.ErrCoinsNoSelectionAvailable},
+}
+
+func TestSmallestFirstSelector(t *testing.T) {
+	testCoinSelector(smallestFirstTests, t)
+}
+
+var topKSelectors = []coinset.TopKCoinSelector{
+	{MaxInputs: 10, MinChangeAmount: 10000},
+	{MaxInputs: 2, MinChangeAmount: 10000},
+	{MaxInputs: 1, MinChangeAmount: 10000},
+	{MaxInputs: 0, MinChangeAmount: 10000},
+}
+
+var topKTests = []coinSelectTest{
+	{topKSelectors[0], coins, 100000, []coinset.Coin{coins[0]}, nil},
+	{topKSelectors[0], coins, 149990000, []coinset.Coin{coins[0], coins[2]}, nil},
+	{topKSelectors[0], coins, 185000000, []coinset.Coin{coins[0], coins[2], coins[3], coins[1]}, nil},
+	{topKSelectors[0], coins, 185000001, nil, coinset.ErrCoinsNoSelectionAvailable},
+	{topKSelectors[1], coins, 150000000, []coinset.Coin{coins[0], coins[2]}, nil},
+	{topKSelectors[1], coins, 150000001, nil, coinset.ErrCoinsNoSelectionAvailable},
+	{topKSelectors[2], coins, 100000000, []coinset.Coin{coins[0]}, nil},
+	{topKSelectors[3], coins, 1, nil, coinset.ErrCoinsNoSelectionAvailable},
+}
+
+func TestTopKSelector(t *testing.T) {
+	testCoinSelector(topKTests, t)
+
+	// The selection must match that of the MinNumberCoinSelector for a
+	// large set of coins, including many with equal values.
+	largeCoins := largeCoinSet(1000)
+	for _, maxInputs := range []int{1, 5, 50, 2000} {
+		topK := coinset.TopKCoinSelector{MaxInputs: maxInputs, MinChangeAmount: 10000}
+		minNumber := coinset.MinNumberCoinSelector{MaxInputs: maxInputs, MinChangeAmount: 10000}
+		for _, target := range []btcutil.Amount{1000, 5000000, 50000000, 500000000} {
+			cs, err := topK.CoinSelect(target, largeCoins)
+			want, wantErr := minNumber.CoinSelect(target, largeCoins)
+			if (err == nil) != (wantErr == nil) {
+				t.Errorf("MaxInputs %d target %v: got error %v, want %v",
+					maxInputs, target, err, wantErr)
+				continue
+			}
+			if err != nil {
+				if !reflect.DeepEqual(err, wantErr) {
+					t.Errorf("MaxInputs %d target %v: got error %v, "+
+						"want %v", maxInputs, target, err, wantErr)
+				}
+				continue
+			}
+			if !reflect.DeepEqual(cs.Coins(), want.Coins()) {
+				t.Errorf("MaxInputs %d target %v: selection does not "+
+					"match MinNumberCoinSelector", maxInputs, target)
+			}
+		}
+	}
+}
+
+// largeCoinSet returns n coins with pseudo-random values between 1 and
+// 1000000 satoshi, many of which are equal.
+func largeCoinSet(n int) []coinset.Coin {
+	rng := rand.New(rand.NewSource(0))
+	coins := make([]coinset.Coin, n)
+	for i := range coins {
+		value := btcutil.Amount(rng.Intn(100)+1) * 10000
+		coins[i] = NewCoin(int64(i), value, int64(rng.Intn(100)))
+	}
+	return coins
+}
+
 var maxValueAgeSelectors = []coinset.MaxValueAgeCoinSelector{
 	{MaxInputs: 10, MinChangeAmount: 10000},
 	{MaxInputs: 2, MinChangeAmount: 10000},
+	{MaxInputs: 10, MinChangeAmount: 10000, MinCoinValueAge: 100000000},
+	{MaxInputs: 10, MinChangeAmount: 10000, MinCoinValueAge: 100000001},
 }
 
 var maxValueAgeTests = []coinSelectTest{
@@ -180,12 +348,84 @@ var maxValueAgeTests = []coinSelectTest{
 	{maxValueAgeSelectors[1], coins, 40000000, nil, coinset.ErrCoinsNoSelectionAvailable},
 	{maxValueAgeSelectors[1], coins, 35000000, []coinset.Coin{coins[1], coins[3]}, nil},
 	{maxValueAgeSelectors[1], coins, 34990001, nil, coinset.ErrCoinsNoSelectionAvailable},
+	// Coins below the value-age floor are never selected, even when the
+	// target can only be reached by including them.
+	{maxValueAgeSelectors[2], coins, 135000000, []coinset.Coin{coins[1], coins[3], coins[0]}, nil},
+	{maxValueAgeSelectors[2], coins, 135000001, nil, coinset.ErrCoinsNoSelectionAvailable},
+	{maxValueAgeSelectors[3], coins, 35000000, []coinset.Coin{coins[1], coins[3]}, nil},
+	{maxValueAgeSelectors[3], coins, 35000001, nil, coinset.ErrCoinsNoSelectionAvailable},
 }
 
 func TestMaxValueAgeSelector(t *testing.T) {
 	testCoinSelector(maxValueAgeTests, t)
 }
 
+var maxConfsSelectors = []coinset.MaxConfsCoinSelector{
+	{MaxInputs: 10, MinChangeAmount: 10000},
+	{MaxInputs: 2, MinChangeAmount: 10000},
+}
+
+var maxConfsTests = []coinSelectTest{
+	{maxConfsSelectors[0], coins, 100000, []coinset.Coin{coins[1]}, nil},
+	{maxConfsSelectors[0], coins, 10000001, []coinset.Coin{coins[1], coins[3]}, nil},
+	{maxConfsSelectors[0], coins, 35000000, []coinset.Coin{coins[1], coins[3]}, nil},
+	{maxConfsSelectors[0], coins, 35000001, []coinset.Coin{coins[1], coins[3], coins[0]}, nil},
+	{maxConfsSelectors[0], coins, 185000000, []coinset.Coin{coins[1], coins[3], coins[0], coins[2]}, nil},
+	{maxConfsSelectors[0], coins, 200000000, nil, coinset.ErrCoinsNoSelectionAvailable},
+	{maxConfsSelectors[1], coins, 35000000, []coinset.Coin{coins[1], coins[3]}, nil},
+	{maxConfsSelectors[1], coins, 35000001, nil, coinset.ErrCoinsNoSelectionAvailable},
+}
+
+func TestMaxConfsSelector(t *testing.T) {
+	testCoinSelector(maxConfsTests, t)
+}
+
+// oldestFirst orders coins with more confirmations before those with fewer.
+func oldestFirst(a, b coinset.Coin) bool {
+	return a.NumConfs() > b.NumConfs()
+}
+
+// standardScriptFirst orders coins paying to a standard script before any
+// others.
+func standardScriptFirst(a, b coinset.Coin) bool {
+	return txscript.GetScriptClass(a.PkScript()) != txscript.NonStandardTy &&
+		txscript.GetScriptClass(b.PkScript()) == txscript.NonStandardTy
+}
+
+var sortedSelectors = []coinset.SortedCoinSelector{
+	{MaxInputs: 10, MinChangeAmount: 10000, Less: oldestFirst},
+	{MaxInputs: 2, MinChangeAmount: 10000, Less: oldestFirst},
+	{MaxInputs: 10, MinChangeAmount: 10000},
+	{MaxInputs: 10, MinChangeAmount: 10000, Less: standardScriptFirst},
+}
+
+var sortedTests = []coinSelectTest{
+	{sortedSelectors[0], coins, 100000, []coinset.Coin{coins[1]}, nil},
+	{sortedSelectors[0], coins, 35000000, []coinset.Coin{coins[1], coins[3]}, nil},
+	{sortedSelectors[0], coins, 35000001, []coinset.Coin{coins[1], coins[3], coins[0]}, nil},
+	{sortedSelectors[0], coins, 185000000, []coinset.Coin{coins[1], coins[3], coins[0], coins[2]}, nil},
+	{sortedSelectors[0], coins, 185000001, nil, coinset.ErrCoinsNoSelectionAvailable},
+	{sortedSelectors[1], coins, 35000001, nil, coinset.ErrCoinsNoSelectionAvailable},
+	// A nil Less accumulates in input order.
+	{sortedSelectors[2], coins, 100000001, []coinset.Coin{coins[0], coins[1]}, nil},
+}
+
+func TestSortedSelector(t *testing.T) {
+	testCoinSelector(sortedTests, t)
+
+	// Equivalent coins keep their input order.
+	standard := &coinset.SimpleCoin{Tx: testSimpleCoinTx, TxIndex: 1}
+	equivCoins := []coinset.Coin{coins[0], coins[1], standard, coins[2]}
+	cs, err := sortedSelectors[3].CoinSelect(185000000, equivCoins)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []coinset.Coin{standard, coins[0], coins[1], coins[2]}
+	if !reflect.DeepEqual(cs.Coins(), want) {
+		t.Errorf("unexpected order: got %v, want %v", cs.Coins(), want)
+	}
+}
+
 var minPrioritySelectors = []coinset.MinPriorityCoinSelector{
 	{MaxInputs: 10, MinChangeAmount: 10000, MinAvgValueAgePerInput: 100000000},
 	{MaxInputs: 02, MinChangeAmount: 10000, MinAvgValueAgePerInput: 200000000},
@@ -220,6 +460,207 @@ func TestMinPrioritySelector(t *testing.T) {
 	testCoinSelector(minPriorityTests, t)
 }
 
+var (
+	pinnedCoin = NewCoin(9, 5000000, 0)
+
+	pinnedSelectors = []coinset.PinnedCoinSelector{
+		{MaxInputs: 10, MinChangeAmount: 10000, Pinned: []coinset.Coin{coins[1]}},
+		{MaxInputs: 2, MinChangeAmount: 10000, Pinned: []coinset.Coin{coins[1], coins[3]}},
+		{MaxInputs: 2, MinChangeAmount: 10000, Pinned: []coinset.Coin{coins[1], coins[2], coins[3]}},
+		{MaxInputs: 10, MinChangeAmount: 10000, Pinned: []coinset.Coin{pinnedCoin}},
+		{MaxInputs: 10, MinChangeAmount: 10000},
+		{MaxInputs: 1, MinChangeAmount: 10000, Pinned: []coinset.Coin{coins[1], coins[1]}},
+	}
+)
+
+var pinnedTests = []coinSelectTest{
+	// The pinned coin alone covers the target.
+	{pinnedSelectors[0], coins, 5000000, []coinset.Coin{coins[1]}, nil},
+	// The remainder is covered by the largest coins, and the pinned coin
+	// is not selected a second time.
+	{pinnedSelectors[0], coins, 30000000, []coinset.Coin{coins[1], coins[0]}, nil},
+	{pinnedSelectors[0], coins, 150000000, []coinset.Coin{coins[1], coins[0], coins[2]}, nil},
+	{pinnedSelectors[0], coins, 185000000, []coinset.Coin{coins[1], coins[0], coins[2], coins[3]}, nil},
+	{pinnedSelectors[0], coins, 185000001, nil, coinset.ErrCoinsNoSelectionAvailable},
+	{pinnedSelectors[1], coins, 35000000, []coinset.Coin{coins[1], coins[3]}, nil},
+	{pinnedSelectors[1], coins, 40000000, nil, coinset.ErrCoinsNoSelectionAvailable},
+	// More pinned coins than MaxInputs can never be selected.
+	{pinnedSelectors[2], coins, 1, nil, coinset.ErrCoinsNoSelectionAvailable},
+	// Pinned coins need not be in the coins being selected from.
+	{pinnedSelectors[3], coins, 105000000, []coinset.Coin{pinnedCoin, coins[0]}, nil},
+	{pinnedSelectors[3], nil, 5000000, []coinset.Coin{pinnedCoin}, nil},
+	{pinnedSelectors[4], coins, 105000000, []coinset.Coin{coins[0], coins[2]}, nil},
+	// A coin pinned twice is only spent and counted once.
+	{pinnedSelectors[5], coins, 10000000, []coinset.Coin{coins[1]}, nil},
+	{pinnedSelectors[5], coins, 20000000, nil, coinset.ErrCoinsNoSelectionAvailable},
+}
+
+func TestPinnedSelector(t *testing.T) {
+	testCoinSelector(pinnedTests, t)
+
+	_, err := pinnedSelectors[2].CoinSelect(1, coins)
+	selErr, ok := err.(*coinset.SelectionError)
+	if !ok || selErr.Reason != coinset.MaxInputsExceeded {
+		t.Errorf("expected MaxInputsExceeded, got %v", err)
+	}
+}
+
+// reverseRandSource is a deterministic RandSource which always reverses the
+// order of the elements it shuffles.
+type reverseRandSource struct{}
+
+func (reverseRandSource) Intn(n int) int { return n - 1 }
+func (reverseRandSource) Shuffle(n int, swap func(i, j int)) {
+	for i := 0; i < n/2; i++ {
+		swap(i, n-1-i)
+	}
+}
+
+var randomSelectors = []coinset.RandomCoinSelector{
+	{MaxInputs: 10, MinChangeAmount: 10000, Rand: reverseRandSource{}},
+	{MaxInputs: 1, MinChangeAmount: 10000, Rand: reverseRandSource{}},
+}
+
+var randomTests = []coinSelectTest{
+	{randomSelectors[0], coins, 25000000, []coinset.Coin{coins[3]}, nil},
+	{randomSelectors[0], coins, 30000000, []coinset.Coin{coins[3], coins[2]}, nil},
+	{randomSelectors[0], coins, 185000000, []coinset.Coin{coins[3], coins[2], coins[1], coins[0]}, nil},
+	{randomSelectors[0], coins, 185000001, nil, coinset.ErrCoinsNoSelectionAvailable},
+	{randomSelectors[1], coins, 30000000, nil, coinset.ErrCoinsNoSelectionAvailable},
+}
+
+func TestRandomSelector(t *testing.T) {
+	testCoinSelector(randomTests, t)
+
+	// A seeded math/rand source must produce a reproducible selection.
+	for seed := int64(0); seed < 10; seed++ {
+		var selections [2][]coinset.Coin
+		for i := range selections {
+			selector := coinset.RandomCoinSelector{
+				MaxInputs:       10,
+				MinChangeAmount: 10000,
+				Rand:            rand.New(rand.NewSource(seed)),
+			}
+			cs, err := selector.CoinSelect(60000000, coins)
+			if err != nil {
+				t.Fatalf("seed %d: unexpected error: %v", seed, err)
+			}
+			selections[i] = cs.Coins()
+		}
+		if !reflect.DeepEqual(selections[0], selections[1]) {
+			t.Errorf("seed %d: selection is not reproducible", seed)
+		}
+	}
+
+	// The crypto source is used when no source is provided.
+	selector := coinset.RandomCoinSelector{MaxInputs: 10, MinChangeAmount: 10000}
+	cs, err := selector.CoinSelect(60000000, coins)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if total := coinset.NewCoinSet(cs.Coins()).TotalValue(); total < 60000000 {
+		t.Errorf("total value %v is less than target", total)
+	}
+}
+
+var weightedRandomSelectors = []coinset.WeightedRandomCoinSelector{
+	{MaxInputs: 10, MinChangeAmount: 10000, Rand: reverseRandSource{}},
+	{MaxInputs: 1, MinChangeAmount: 10000, Rand: reverseRandSource{}},
+}
+
+var weightedRandomTests = []coinSelectTest{
+	// The reverse source always chooses the last of the remaining coins.
+	{weightedRandomSelectors[0], coins, 25000000, []coinset.Coin{coins[3]}, nil},
+	{weightedRandomSelectors[0], coins, 30000000, []coinset.Coin{coins[3], coins[2]}, nil},
+	{weightedRandomSelectors[0], coins, 185000000, []coinset.Coin{coins[3], coins[2], coins[1], coins[0]}, nil},
+	{weightedRandomSelectors[0], coins, 185000001, nil, coinset.ErrCoinsNoSelectionAvailable},
+	{weightedRandomSelectors[1], coins, 30000000, nil, coinset.ErrCoinsNoSelectionAvailable},
+	// Coins without value are never chosen.
+	{weightedRandomSelectors[0], []coinset.Coin{coins[1], NewCoin(9, 0, 1)}, 5000000, []coinset.Coin{coins[1]}, nil},
+}
+
+func TestWeightedRandomSelector(t *testing.T) {
+	testCoinSelector(weightedRandomTests, t)
+
+	// A seeded math/rand source must produce a reproducible selection.
+	for seed := int64(0); seed < 10; seed++ {
+		var selections [2][]coinset.Coin
+		for i := range selections {
+			selector := coinset.WeightedRandomCoinSelector{
+				MaxInputs:       10,
+				MinChangeAmount: 10000,
+				Rand:            rand.New(rand.NewSource(seed)),
+			}
+			cs, err := selector.CoinSelect(60000000, coins)
+			if err != nil {
+				t.Fatalf("seed %d: unexpected error: %v", seed, err)
+			}
+			selections[i] = cs.Coins()
+		}
+		if !reflect.DeepEqual(selections[0], selections[1]) {
+			t.Errorf("seed %d: selection is not reproducible", seed)
+		}
+	}
+
+	// A single coin is chosen for a tiny target, so each coin must be
+	// chosen roughly in proportion to its value.  The expected counts are
+	// 5405, 540, 2703, and 1351 of 10000, so ordering the counts is a very
+	// loose check.
+	const trials = 10000
+	counts := make(map[coinset.Coin]int)
+	for seed := int64(0); seed < trials; seed++ {
+		selector := coinset.WeightedRandomCoinSelector{
+			MaxInputs: 10,
+			Rand:      rand.New(rand.NewSource(seed)),
+		}
+		cs, err := selector.CoinSelect(1, coins)
+		if err != nil {
+			t.Fatalf("seed %d: unexpected error: %v", seed, err)
+		}
+		counts[cs.Coins()[0]]++
+	}
+	if !(counts[coins[0]] > counts[coins[2]] && counts[coins[2]] > counts[coins[3]] &&
+		counts[coins[3]] > counts[coins[1]]) {
+
+		t.Errorf("selection counts %d, %d, %d, %d are not ordered by value",
+			counts[coins[0]], counts[coins[1]], counts[coins[2]],
+			counts[coins[3]])
+	}
+	if c := counts[coins[0]]; c < 4900 || c > 5900 {
+		t.Errorf("largest coin selected %d of %d times, want about 5405",
+			c, trials)
+	}
+
+	// The crypto source is used when no source is provided.
+	selector := coinset.WeightedRandomCoinSelector{MaxInputs: 10, MinChangeAmount: 10000}
+	cs, err := selector.CoinSelect(60000000, coins)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if total := coinset.NewCoinSet(cs.Coins()).TotalValue(); total < 60000000 {
+		t.Errorf("total value %v is less than target", total)
+	}
+}
+
+func TestCryptoRandSource(t *testing.T) {
+	r := coinset.CryptoRandSource
+	for i := 0; i < 100; i++ {
+		if n := r.Intn(5); n < 0 || n >= 5 {
+			t.Fatalf("Intn(5) returned out of range value %d", n)
+		}
+	}
+
+	perm := []int{0, 1, 2, 3, 4, 5, 6, 7}
+	r.Shuffle(len(perm), func(i, j int) { perm[i], perm[j] = perm[j], perm[i] })
+	seen := make(map[int]bool)
+	for _, v := range perm {
+		seen[v] = true
+	}
+	if len(seen) != len(perm) {
+		t.Errorf("Shuffle lost elements: %v", perm)
+	}
+}
+
 var (
 	// should be two outpoints, with 1st one having 0.035BTC value.
 	testSimpleCoinNumConfs            = int64(1)
@@ -238,6 +679,344 @@ var (
 	}
 )
 
+func TestCoinSelectorInterface(t *testing.T) {
+	selectors := []coinset.CoinSelector{
+		coinset.MinIndexCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.SmallestFirstCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.MaxValueAgeCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.MaxConfsCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.MinPriorityCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.UniformScriptCoinSelector{
+			Selector: coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		},
+		coinset.PinnedCoinSelector{MaxInputs: 10, MinChangeAmount: 10000, Pinned: coins[1:2]},
+		coinset.TopKCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+	}
+	targets := []btcutil.Amount{10000000, 35000000, 100000000}
+
+	for i, selector := range selectors {
+		for _, target := range targets {
+			cs, err := selector.CoinSelect(target, coins)
+			if err != nil {
+				t.Errorf("[%d] %T: unexpected error for target %v: %v",
+					i, selector, target, err)
+				continue
+			}
+			total := coinset.NewCoinSet(cs.Coins()).TotalValue()
+			if total < target {
+				t.Errorf("[%d] %T: total value %v is less than target %v",
+					i, selector, total, target)
+			}
+		}
+	}
+}
+
+var rangeSelectors = []coinset.RangeCoinSelector{
+	{MaxInputs: 10, MaxExcess: 5000000},
+	{MaxInputs: 10, MaxExcess: 10000000},
+	{MaxInputs: 10, MaxExcess: 0},
+	{MaxInputs: 1, MaxExcess: 100000000},
+	{MaxInputs: 1, MaxExcess: 5000000},
+}
+
+var rangeTests = []coinSelectTest{
+	// Accumulating from the largest coin overshoots the maximum total, so
+	// the search must backtrack to a subset of smaller coins.
+	{rangeSelectors[0], coins, 60000000, []coinset.Coin{coins[2], coins[1]}, nil},
+	// The smallest total within range is preferred.
+	{rangeSelectors[1], coins, 70000000, []coinset.Coin{coins[2], coins[3]}, nil},
+	{rangeSelectors[2], coins, 85000000, []coinset.Coin{coins[2], coins[3], coins[1]}, nil},
+	{rangeSelectors[2], coins, 80000000, nil, coinset.ErrCoinsNoSelectionAvailable},
+	{rangeSelectors[1], coins, 190000000, nil, coinset.ErrCoinsNoSelectionAvailable},
+	{rangeSelectors[3], coins, 60000000, []coinset.Coin{coins[0]}, nil},
+	{rangeSelectors[4], coins, 80000000, nil, coinset.ErrCoinsNoSelectionAvailable},
+}
+
+func TestRangeSelector(t *testing.T) {
+	testCoinSelector(rangeTests, t)
+
+	// Failures report why no selection in range was found.
+	failures := []struct {
+		selector coinset.RangeCoinSelector
+		target   btcutil.Amount
+		reason   coinset.SelectionFailure
+		best     btcutil.Amount
+	}{
+		{rangeSelectors[2], 80000000, coinset.ExcessiveChange, 185000000},
+		{rangeSelectors[1], 190000000, coinset.InsufficientFunds, 185000000},
+		{rangeSelectors[4], 110000000, coinset.MaxInputsExceeded, 100000000},
+		{rangeSelectors[4], 80000000, coinset.ExcessiveChange, 100000000},
+	}
+	for i, test := range failures {
+		_, err := test.selector.CoinSelect(test.target, coins)
+		selErr, ok := err.(*coinset.SelectionError)
+		if !ok {
+			t.Errorf("[%d] expected *SelectionError, got %v", i, err)
+			continue
+		}
+		if selErr.Reason != test.reason || selErr.BestTotal != test.best {
+			t.Errorf("[%d] got reason %v best total %v, expected %v %v",
+				i, selErr.Reason, selErr.BestTotal, test.reason, test.best)
+		}
+	}
+}
+
+var maxTotalSelectors = []coinset.MaxTotalCoinSelector{
+	{MaxInputs: 10, MaxTotal: 70000000},
+	{MaxInputs: 1, MaxTotal: 70000000},
+	{MaxInputs: 10, MaxTotal: 80000000},
+	{MaxInputs: 10, MaxTotal: 0},
+}
+
+var maxTotalTests = []coinSelectTest{
+	// A selection covering the target within the cap exists.
+	{maxTotalSelectors[0], coins, 60000000, []coinset.Coin{coins[2], coins[1]}, nil},
+	{maxTotalSelectors[0], coins, 70000000, nil, coinset.ErrCoinsNoSelectionAvailable},
+	// The only single coin covering the target exceeds the cap.
+	{maxTotalSelectors[1], coins, 60000000, nil, coinset.ErrCoinsNoSelectionAvailable},
+	{maxTotalSelectors[1], coins, 50000000, []coinset.Coin{coins[2]}, nil},
+	// Every selection covering the target exceeds the cap.
+	{maxTotalSelectors[2], coins, 80000000, nil, coinset.ErrCoinsNoSelectionAvailable},
+	// The cap is below the target.
+	{maxTotalSelectors[0], coins, 75000000, nil, coinset.ErrCoinsNoSelectionAvailable},
+	// Without a cap any total is allowed.
+	{maxTotalSelectors[3], coins, 80000000, []coinset.Coin{coins[2], coins[3], coins[1]}, nil},
+	{maxTotalSelectors[3], coins, 185000000, []coinset.Coin{coins[0], coins[2], coins[3], coins[1]}, nil},
+}
+
+func TestMaxTotalSelector(t *testing.T) {
+	testCoinSelector(maxTotalTests, t)
+
+	// The total of a successful selection never exceeds the cap.
+	for i, test := range maxTotalTests {
+		s := test.selector.(coinset.MaxTotalCoinSelector)
+		cs, err := s.CoinSelect(test.targetValue, test.inputCoins)
+		if err != nil || s.MaxTotal <= 0 {
+			continue
+		}
+		if total := coinset.NewCoinSet(cs.Coins()).TotalValue(); total > s.MaxTotal {
+			t.Errorf("[%d] total value %v exceeds cap %v", i, total,
+				s.MaxTotal)
+		}
+	}
+}
+
+var noChangeSelectors = []coinset.NoChangeCoinSelector{
+	{MaxInputs: 10, MinChangeAmount: 10000},
+	{MaxInputs: 1, MinChangeAmount: 10000},
+}
+
+var noChangeTests = []coinSelectTest{
+	// An exact subset is chosen over the single coin with change which
+	// MinNumberCoinSelector selects.
+	{noChangeSelectors[0], coins, 35000000, []coinset.Coin{coins[3], coins[1]}, nil},
+	{noChangeSelectors[0], coins, 75000000, []coinset.Coin{coins[2], coins[3]}, nil},
+	{noChangeSelectors[0], coins, 100000000, []coinset.Coin{coins[0]}, nil},
+	{noChangeSelectors[0], coins, 185000000, []coinset.Coin{coins[0], coins[2], coins[3], coins[1]}, nil},
+	// Without an exact subset, the selection falls back to one with change.
+	{noChangeSelectors[0], coins, 70000000, []coinset.Coin{coins[0]}, nil},
+	{noChangeSelectors[0], coins, 99995000, []coinset.Coin{coins[0], coins[2]}, nil},
+	{noChangeSelectors[1], coins, 35000000, []coinset.Coin{coins[0]}, nil},
+	{noChangeSelectors[0], coins, 200000000, nil, coinset.ErrCoinsNoSelectionAvailable},
+}
+
+func TestNoChangeSelector(t *testing.T) {
+	testCoinSelector(noChangeTests, t)
+}
+
+var (
+	minChangeCoins = []coinset.Coin{
+		NewCoin(1, 100000000, 1),
+		NewCoin(2, 40000000, 1),
+		NewCoin(3, 30000000, 1),
+		NewCoin(4, 20000000, 1),
+		NewCoin(5, 11000000, 1),
+		NewCoin(6, 9000000, 1),
+	}
+
+	minChangeSelectors = []coinset.MinChangeCoinSelector{
+		{MaxInputs: 10, MinChangeAmount: 0, Rand: rand.New(rand.NewSource(1))},
+		{MaxInputs: 10, MinChangeAmount: 5000000, Rand: rand.New(rand.NewSource(1))},
+		{MaxInputs: 2, MinChangeAmount: 0, Rand: rand.New(rand.NewSource(1))},
+		{MaxInputs: 0, MinChangeAmount: 0, Rand: rand.New(rand.NewSource(1))},
+	}
+)
+
+var minChangeTests = []coinSelectTest{
+	// Accumulating by value would select the 100M coin and leave 39M of
+	// change, while 30M+20M+11M meets the target exactly.
+	{minChangeSelectors[0], minChangeCoins, 61000000, []coinset.Coin{minChangeCoins[2], minChangeCoins[3], minChangeCoins[4]}, nil},
+	{minChangeSelectors[0], minChangeCoins, 45000000, []coinset.Coin{minChangeCoins[1], minChangeCoins[5]}, nil},
+	// A single coin is used when it is the closest match.
+	{minChangeSelectors[0], minChangeCoins, 100000000, []coinset.Coin{minChangeCoins[0]}, nil},
+	// Totals leaving less than the minimum change are avoided.
+	{minChangeSelectors[1], minChangeCoins, 48000000, []coinset.Coin{minChangeCoins[2], minChangeCoins[3], minChangeCoins[5]}, nil},
+	{minChangeSelectors[2], minChangeCoins, 51000000, []coinset.Coin{minChangeCoins[1], minChangeCoins[4]}, nil},
+	{minChangeSelectors[2], minChangeCoins, 52000000, []coinset.Coin{minChangeCoins[1], minChangeCoins[3]}, nil},
+	{minChangeSelectors[2], minChangeCoins, 150000000, nil, coinset.ErrCoinsNoSelectionAvailable},
+	{minChangeSelectors[0], minChangeCoins, 210000001, nil, coinset.ErrCoinsNoSelectionAvailable},
+	{minChangeSelectors[3], minChangeCoins, 1, nil, coinset.ErrCoinsNoSelectionAvailable},
+}
+
+func TestMinChangeSelector(t *testing.T) {
+	testCoinSelector(minChangeTests, t)
+
+	// The change left by the selection is never more than that left by
+	// accumulating the coins by value.
+	greedy := coinset.MinNumberCoinSelector{MaxInputs: 10}
+	for target := btcutil.Amount(1000000); target <= 210000000; target += 7000000 {
+		selector := coinset.MinChangeCoinSelector{
+			MaxInputs: 10,
+			Rand:      rand.New(rand.NewSource(int64(target))),
+		}
+		cs, err := selector.CoinSelect(target, minChangeCoins)
+		if err != nil {
+			t.Fatalf("target %v: unexpected error: %v", target, err)
+		}
+		greedyCS, err := greedy.CoinSelect(target, minChangeCoins)
+		if err != nil {
+			t.Fatalf("target %v: unexpected greedy error: %v", target, err)
+		}
+		total := coinset.NewCoinSet(cs.Coins()).TotalValue()
+		greedyTotal := coinset.NewCoinSet(greedyCS.Coins()).TotalValue()
+		if total < target || total > greedyTotal {
+			t.Errorf("target %v: got total %v, greedy total %v", target,
+				total, greedyTotal)
+		}
+	}
+
+	// A seeded math/rand source must produce a reproducible selection.
+	for seed := int64(0); seed < 10; seed++ {
+		var selections [2][]coinset.Coin
+		for i := range selections {
+			selector := coinset.MinChangeCoinSelector{
+				MaxInputs:  10,
+				Iterations: 3,
+				Rand:       rand.New(rand.NewSource(seed)),
+			}
+			cs, err := selector.CoinSelect(75000000, minChangeCoins)
+			if err != nil {
+				t.Fatalf("seed %d: unexpected error: %v", seed, err)
+			}
+			selections[i] = cs.Coins()
+		}
+		if !reflect.DeepEqual(selections[0], selections[1]) {
+			t.Errorf("seed %d: selection is not reproducible", seed)
+		}
+	}
+}
+
+var (
+	ffdCoins = []coinset.Coin{
+		NewCoin(1, 45000000, 1),
+		NewCoin(2, 20000000, 1),
+		NewCoin(3, 5500000, 1),
+		NewCoin(4, 3500000, 1),
+	}
+
+	ffdFallbackCoins = []coinset.Coin{
+		NewCoin(1, 45000000, 1),
+		NewCoin(2, 5500000, 1),
+		NewCoin(3, 5400000, 1),
+	}
+
+	ffdSelectors = []coinset.FirstFitDecreasingCoinSelector{
+		{MaxInputs: 10, MinChangeAmount: 10000},
+		{MaxInputs: 10, MinChangeAmount: 1000000},
+		{MaxInputs: 2, MinChangeAmount: 10000},
+		{MaxInputs: 1, MinChangeAmount: 10000},
+		{MaxInputs: 0, MinChangeAmount: 10000},
+	}
+)
+
+var ffdTests = []coinSelectTest{
+	// Accumulating by value would select the 100M coin and leave 40M of
+	// change, while 50M+10M meets the target exactly.
+	{ffdSelectors[0], coins, 60000000, []coinset.Coin{coins[2], coins[1]}, nil},
+	// 45M+5.5M would leave 0.5M of dust change, so the 5.5M coin is
+	// skipped for the 3.5M coin and only then completes the selection.
+	// Accumulating by value would instead add the 20M coin.
+	{ffdSelectors[1], ffdCoins, 50000000, []coinset.Coin{ffdCoins[0], ffdCoins[3], ffdCoins[2]}, nil},
+	// The smallest coin which leaves enough change completes the
+	// selection.
+	{ffdSelectors[1], ffdCoins, 47000000, []coinset.Coin{ffdCoins[0], ffdCoins[3]}, nil},
+	// No single coin can complete the 45M coin to 50M without leaving
+	// dust change, so the selection falls back to accumulating by value.
+	{ffdSelectors[1], ffdFallbackCoins, 50000000, ffdFallbackCoins, nil},
+	// The last input is reserved for completing the selection.
+	{ffdSelectors[2], coins, 60000000, []coinset.Coin{coins[2], coins[1]}, nil},
+	{ffdSelectors[3], coins, 60000000, []coinset.Coin{coins[0]}, nil},
+	{ffdSelectors[3], coins, 25000000, []coinset.Coin{coins[3]}, nil},
+	{ffdSelectors[0], coins, 200000000, nil, coinset.ErrCoinsNoSelectionAvailable},
+	{ffdSelectors[4], coins, 1, nil, coinset.ErrCoinsNoSelectionAvailable},
+}
+
+func TestFirstFitDecreasingSelector(t *testing.T) {
+	testCoinSelector(ffdTests, t)
+
+	// The change left by the selection is never dust, and never more
+	// than that left by accumulating the coins by value.
+	for target := btcutil.Amount(1000000); target <= 200000000; target += 1000000 {
+		selector := coinset.FirstFitDecreasingCoinSelector{
+			MaxInputs:       10,
+			MinChangeAmount: 5000000,
+		}
+		cs, err := selector.CoinSelect(target, minChangeCoins)
+		if err != nil {
+			t.Fatalf("target %v: unexpected error: %v", target, err)
+		}
+		greedy := coinset.MinNumberCoinSelector{
+			MaxInputs:       selector.MaxInputs,
+			MinChangeAmount: selector.MinChangeAmount,
+		}
+		greedyCS, err := greedy.CoinSelect(target, minChangeCoins)
+		if err != nil {
+			t.Fatalf("target %v: unexpected greedy error: %v", target, err)
+		}
+		change := coinset.ChangeAmount(cs, target)
+		if change < 0 || change > 0 && change < selector.MinChangeAmount {
+			t.Errorf("target %v: got change %v", target, change)
+		}
+		if greedyChange := coinset.ChangeAmount(greedyCS, target); change > greedyChange {
+			t.Errorf("target %v: got change %v, greedy change %v",
+				target, change, greedyChange)
+		}
+	}
+}
+
+var bestSingleCoinTests = []struct {
+	targetValue btcutil.Amount
+	minChange   btcutil.Amount
+	coins       []coinset.Coin
+	index       int
+	ok          bool
+}{
+	// Exact match is preferred over any coin that leaves change.
+	{25000000, 10000, coins, 3, true},
+	{50000000, 10000, coins, 2, true},
+	// Smallest overpayment which still satisfies the change rules.
+	{20000000, 10000, coins, 3, true},
+	{24990000, 10000, coins, 3, true},
+	// 25000000 would leave too little change, so the next coin up is used.
+	{24999000, 10000, coins, 2, true},
+	{100000000, 10000, coins, 0, true},
+	// No single coin is large enough.
+	{100000001, 10000, coins, -1, false},
+	{0, 0, nil, -1, false},
+}
+
+func TestBestSingleCoin(t *testing.T) {
+	for i, test := range bestSingleCoinTests {
+		index, ok := coinset.BestSingleCoin(test.targetValue,
+			test.minChange, test.coins)
+		if ok != test.ok || index != test.index {
+			t.Errorf("[%d] got (%d, %v), expected (%d, %v)", i,
+				index, ok, test.index, test.ok)
+		}
+	}
+}
+
 func TestSimpleCoin(t *testing.T) {
 	if testSimpleCoin.Hash().String() != testSimpleCoinTxHash {
 		t.Error("Different value for tx hash than expected")
@@ -258,3 +1037,1446 @@ func TestSimpleCoin(t *testing.T) {
 		t.Error("Different value of coin value * age than expected")
 	}
 }
+
+func TestNewSimpleCoin(t *testing.T) {
+	coin, err := coinset.NewSimpleCoin(testSimpleCoinTx, 1, 6)
+	if err != nil {
+		t.Fatalf("NewSimpleCoin: unexpected error: %v", err)
+	}
+	if coin.Index() != 1 || coin.NumConfs() != 6 {
+		t.Error("Different index or num confs than expected")
+	}
+	if coin.Value() != btcutil.Amount(testSimpleCoinTx.MsgTx().TxOut[1].Value) {
+		t.Error("Different value of coin value than expected")
+	}
+
+	// The test transaction only has two outputs.
+	for _, index := range []uint32{2, 1<<32 - 1} {
+		_, err := coinset.NewSimpleCoin(testSimpleCoinTx, index, 6)
+		if err != coinset.ErrOutputIndexOutOfRange {
+			t.Errorf("NewSimpleCoin(%d): got error %v, want %v",
+				index, err, coinset.ErrOutputIndexOutOfRange)
+		}
+	}
+	if _, err := coinset.NewSimpleCoin(nil, 0, 6); err != coinset.ErrOutputIndexOutOfRange {
+		t.Errorf("NewSimpleCoin(nil): got error %v, want %v", err,
+			coinset.ErrOutputIndexOutOfRange)
+	}
+}
+
+func TestSameCluster(t *testing.T) {
+	addr1, _ := btcutil.NewAddressPubKeyHash(make([]byte, 20), &chaincfg.MainNetParams)
+	addr2, _ := btcutil.NewAddressPubKeyHash(bytes.Repeat([]byte{0x01}, 20), &chaincfg.MainNetParams)
+
+	coin1 := NewOptionCoin(1, 100000000, 1, TestOptionCoin{TxAddress: addr1})
+	coin2 := NewOptionCoin(2, 10000000, 2, TestOptionCoin{TxAddress: addr1})
+	coin3 := NewOptionCoin(3, 50000000, 3, TestOptionCoin{TxAddress: addr2})
+	coin4 := NewOptionCoin(4, 25000000, 4, TestOptionCoin{})
+
+	tests := []struct {
+		a, b coinset.AddressCoin
+		want bool
+	}{
+		{coin1, coin2, true},
+		{coin2, coin1, true},
+		{coin1, coin3, false},
+		{coin3, coin3, true},
+		{coin4, coin1, false},
+		{coin4, coin4, true},
+		{coin1, nil, false},
+	}
+
+	for i, test := range tests {
+		got := coinset.SameCluster(test.a, test.b, coinset.SameAddress)
+		if got != test.want {
+			t.Errorf("[%d] SameCluster: got %v, want %v", i, got, test.want)
+		}
+	}
+
+	// Ensure the heuristic is consulted in both directions.
+	oneWay := func(x, y coinset.AddressCoin) bool { return x == coin1 && y == coin3 }
+	if !coinset.SameCluster(coin3, coin1, oneWay) {
+		t.Error("SameCluster: expected asymmetric heuristic to match in reverse")
+	}
+}
+
+var (
+	testP2PKHScript, _  = hex.DecodeString("76a914686dd149a79b4a559d561fbc396d3e3c6628b98d88ac")
+	testP2WPKHScript, _ = hex.DecodeString("0014751e76e8199196d454941c45d1b3a323f1433bd6")
+)
+
+func TestUniformScriptSelector(t *testing.T) {
+	p2pkh := []coinset.Coin{
+		NewOptionCoin(1, 60000000, 1, TestOptionCoin{TxPkScript: testP2PKHScript}),
+		NewOptionCoin(2, 30000000, 1, TestOptionCoin{TxPkScript: testP2PKHScript}),
+		NewOptionCoin(3, 20000000, 1, TestOptionCoin{TxPkScript: testP2PKHScript}),
+	}
+	p2wpkh := []coinset.Coin{
+		NewOptionCoin(4, 50000000, 1, TestOptionCoin{TxPkScript: testP2WPKHScript}),
+		NewOptionCoin(5, 45000000, 1, TestOptionCoin{TxPkScript: testP2WPKHScript}),
+	}
+	mixed := []coinset.Coin{p2pkh[0], p2wpkh[0], p2pkh[1], p2wpkh[1], p2pkh[2]}
+
+	selector := coinset.UniformScriptCoinSelector{
+		Selector: coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+	}
+	tests := []coinSelectTest{
+		// A single coin of either type is enough; the p2pkh coin is
+		// the only single coin which covers the target.
+		{selector, mixed, 60000000, []coinset.Coin{p2pkh[0]}, nil},
+		// Both types need two coins, so the smaller total wins.
+		{selector, mixed, 80000000, []coinset.Coin{p2pkh[0], p2pkh[1]}, nil},
+		// Only the p2pkh coins are able to cover the target.
+		{selector, mixed, 100000000, []coinset.Coin{p2pkh[0], p2pkh[1], p2pkh[2]}, nil},
+		// The total of all coins would cover the target, but not the
+		// total of any single type.
+		{selector, mixed, 120000000, nil, coinset.ErrCoinsNoSelectionAvailable},
+	}
+	testCoinSelector(tests, t)
+
+	// Ensure that the selected coins never mix script types.
+	for i, test := range tests {
+		cs, err := test.selector.CoinSelect(test.targetValue, test.inputCoins)
+		if err != nil {
+			continue
+		}
+		selected := cs.Coins()
+		for _, coin := range selected[1:] {
+			if !bytes.Equal(coin.PkScript(), selected[0].PkScript()) {
+				t.Errorf("[%d] selection mixes input script types", i)
+				break
+			}
+		}
+	}
+}
+
+func TestEstimatedInputSize(t *testing.T) {
+	if size := coinset.EstimatedInputSize(coins[0]); size != coinset.DefaultInputSize {
+		t.Errorf("unsized coin: got %d, expected %d", size, coinset.DefaultInputSize)
+	}
+	if size := coinset.EstimatedInputSize(NewOptionCoin(1, 1, 1, TestOptionCoin{TxSize: 297})); size != 297 {
+		t.Errorf("sized coin: got %d, expected 297", size)
+	}
+}
+
+var (
+	minFeeCoins = []coinset.Coin{
+		// A 2-of-3 multisig input which is expensive to spend.
+		NewOptionCoin(1, 60000000, 1, TestOptionCoin{TxSize: 300}),
+		NewCoin(2, 35000000, 1),
+		NewCoin(3, 30000000, 1),
+		// A small but very cheap input.
+		NewOptionCoin(4, 5000000, 1, TestOptionCoin{TxSize: 10}),
+		// An input which costs more to spend than it is worth.
+		NewCoin(5, 10000, 1),
+	}
+
+	minFeeSelectors = []coinset.MinFeeCoinSelector{
+		{MaxInputs: 10, MinChangeAmount: 10000, FeePerByte: 100},
+		{MaxInputs: 1, MinChangeAmount: 10000, FeePerByte: 100},
+		{MaxInputs: 10, MinChangeAmount: 10000, FeePerByte: 100, ChangeOutputSize: 34},
+	}
+)
+
+var minFeeTests = []coinSelectTest{
+	// Two standard inputs are cheaper than the single multisig input, and
+	// the cheap input is dropped once it is no longer needed.
+	{minFeeSelectors[0], minFeeCoins, 55000000, []coinset.Coin{minFeeCoins[1], minFeeCoins[2]}, nil},
+	{minFeeSelectors[0], minFeeCoins[:3], 55000000, []coinset.Coin{minFeeCoins[1], minFeeCoins[2]}, nil},
+	{minFeeSelectors[0], minFeeCoins, 4000000, []coinset.Coin{minFeeCoins[3]}, nil},
+	// The fee must be covered in addition to the target.
+	{minFeeSelectors[0], minFeeCoins[1:2], 35000000 - 14800, []coinset.Coin{minFeeCoins[1]}, nil},
+	{minFeeSelectors[0], minFeeCoins[1:2], 35000000 - 14799, nil, coinset.ErrCoinsNoSelectionAvailable},
+	{minFeeSelectors[0], minFeeCoins[4:], 1, nil, coinset.ErrCoinsNoSelectionAvailable},
+	{minFeeSelectors[0], minFeeCoins, 130000000, nil, coinset.ErrCoinsNoSelectionAvailable},
+	{minFeeSelectors[1], minFeeCoins[1:3], 30000000, []coinset.Coin{minFeeCoins[1]}, nil},
+	{minFeeSelectors[1], minFeeCoins[1:3], 40000000, nil, coinset.ErrCoinsNoSelectionAvailable},
+	// The minimum change is left after the input fee, but not after the
+	// 3400 fee for the change output, so a second input is required.
+	{minFeeSelectors[0], minFeeCoins[1:3], 35000000 - 14800 - 10000, []coinset.Coin{minFeeCoins[1]}, nil},
+	{minFeeSelectors[2], minFeeCoins[1:3], 35000000 - 14800 - 10000, []coinset.Coin{minFeeCoins[1], minFeeCoins[2]}, nil},
+	{minFeeSelectors[2], minFeeCoins[1:3], 35000000 - 14800 - 13400, []coinset.Coin{minFeeCoins[1]}, nil},
+	// A selection without change does not pay for a change output.
+	{minFeeSelectors[2], minFeeCoins[1:2], 35000000 - 14800, []coinset.Coin{minFeeCoins[1]}, nil},
+	{minFeeSelectors[2], minFeeCoins[1:2], 35000000 - 14801, nil, coinset.ErrCoinsNoSelectionAvailable},
+}
+
+func TestMinFeeSelector(t *testing.T) {
+	testCoinSelector(minFeeTests, t)
+
+	// The minimum number of inputs is not the minimum fee here.
+	minNumber := coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 10000}
+	cs, err := minNumber.CoinSelect(55000000, minFeeCoins)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cs.Coins()) != 1 || cs.Coins()[0] != minFeeCoins[0] {
+		t.Errorf("expected MinNumberCoinSelector to choose the multisig input")
+	}
+}
+
+var (
+	weightCoins = []coinset.Coin{
+		// 2-of-3 multisig inputs which are heavy to spend.
+		NewOptionCoin(1, 60000000, 1, TestOptionCoin{TxSize: 300}),
+		NewOptionCoin(2, 50000000, 1, TestOptionCoin{TxSize: 300}),
+		NewCoin(3, 40000000, 1),
+		NewCoin(4, 35000000, 1),
+		NewCoin(5, 30000000, 1),
+	}
+
+	maxWeightSelectors = []coinset.MaxWeightCoinSelector{
+		{MaxInputs: 10, MinChangeAmount: 0, MaxWeight: 1800},
+		{MaxInputs: 10, MinChangeAmount: 0, MaxWeight: 0},
+		{MaxInputs: 2, MinChangeAmount: 0, MaxWeight: 1800},
+	}
+)
+
+var maxWeightTests = []coinSelectTest{
+	{maxWeightSelectors[0], weightCoins, 60000000, []coinset.Coin{weightCoins[0]}, nil},
+	// The second multisig input would exceed the weight, so it is skipped.
+	{maxWeightSelectors[0], weightCoins, 100000000, []coinset.Coin{weightCoins[0], weightCoins[2]}, nil},
+	// Three standard inputs weigh less than one multisig and one standard
+	// input, so the lighter coins are preferred once weight binds.
+	{maxWeightSelectors[0], weightCoins, 101000000, []coinset.Coin{weightCoins[2], weightCoins[3], weightCoins[4]}, nil},
+	{maxWeightSelectors[0], weightCoins, 106000000, nil, coinset.ErrCoinsNoSelectionAvailable},
+	// Without a maximum weight, the fewest inputs are selected.
+	{maxWeightSelectors[1], weightCoins, 101000000, []coinset.Coin{weightCoins[0], weightCoins[1]}, nil},
+	{maxWeightSelectors[2], weightCoins, 101000000, nil, coinset.ErrCoinsNoSelectionAvailable},
+}
+
+func TestMaxWeightSelector(t *testing.T) {
+	testCoinSelector(maxWeightTests, t)
+
+	if w := coinset.EstimatedInputWeight(weightCoins[0]); w != 1200 {
+		t.Errorf("EstimatedInputWeight: got %d for sized coin, want 1200", w)
+	}
+	if w := coinset.EstimatedInputWeight(weightCoins[2]); w != 4*coinset.DefaultInputSize {
+		t.Errorf("EstimatedInputWeight: got %d for unsized coin, want %d",
+			w, 4*coinset.DefaultInputSize)
+	}
+}
+
+func TestBreakEvenFeeRate(t *testing.T) {
+	tests := []struct {
+		amount    btcutil.Amount
+		inputSize int
+		want      btcutil.Amount
+	}{
+		{14800, coinset.DefaultInputSize, 100},
+		{14900, coinset.DefaultInputSize, 100},
+		{147, coinset.DefaultInputSize, 0},
+		{100000000, 68, 1470588},
+		{29700, 297, 100},
+		{0, 148, 0},
+		{10000, 0, 0},
+		{10000, -1, 0},
+	}
+
+	for i, test := range tests {
+		got := coinset.BreakEvenFeeRate(test.amount, test.inputSize)
+		if got != test.want {
+			t.Errorf("[%d] BreakEvenFeeRate(%v, %d): got %d, want %d",
+				i, test.amount, test.inputSize, int64(got), int64(test.want))
+		}
+	}
+}
+
+func TestPartitionByEconomics(t *testing.T) {
+	mixed := []coinset.Coin{
+		NewCoin(1, 100000, 1),
+		NewCoin(2, 14800, 1),
+		NewCoin(3, 14801, 1),
+		NewOptionCoin(4, 14801, 1, TestOptionCoin{TxSize: 300}),
+		NewCoin(5, 546, 1),
+		NewOptionCoin(6, 2000, 1, TestOptionCoin{TxSize: 10}),
+	}
+
+	tests := []struct {
+		feePerByte btcutil.Amount
+		inputSize  func(coinset.Coin) int
+		economic   []int
+		dust       []int
+	}{
+		{100, nil, []int{0, 2, 5}, []int{1, 3, 4}},
+		{0, nil, []int{0, 1, 2, 3, 4, 5}, nil},
+		{500, nil, []int{0}, []int{1, 2, 3, 4, 5}},
+		// A custom size function overrides any SizedCoin estimate.
+		{100, func(coinset.Coin) int { return 68 }, []int{0, 1, 2, 3}, []int{4, 5}},
+	}
+
+	for i, test := range tests {
+		economic, dust := coinset.PartitionByEconomics(mixed,
+			test.feePerByte, test.inputSize)
+		if !reflect.DeepEqual(economic, test.economic) {
+			t.Errorf("[%d] economic: got %v, want %v", i, economic,
+				test.economic)
+		}
+		if !reflect.DeepEqual(dust, test.dust) {
+			t.Errorf("[%d] dust: got %v, want %v", i, dust, test.dust)
+		}
+	}
+}
+
+func TestAuditSelector(t *testing.T) {
+	auditCoins := []coinset.Coin{
+		NewOptionCoin(1, 30000000, 1, TestOptionCoin{TxPkScript: testP2PKHScript}),
+		NewOptionCoin(2, 25000000, 1, TestOptionCoin{TxPkScript: testP2WPKHScript}),
+		NewOptionCoin(3, 10000000, 1, TestOptionCoin{TxPkScript: testP2PKHScript}),
+	}
+
+	var audits []*coinset.SelectionAudit
+	selector := coinset.AuditCoinSelector{
+		Selector: coinset.MinIndexCoinSelector{
+			MaxInputs:       10,
+			MinChangeAmount: 10000,
+		},
+		FeePerByte: 10,
+		Audit: func(a *coinset.SelectionAudit) {
+			audits = append(audits, a)
+		},
+	}
+
+	if _, err := selector.CoinSelect(50000000, auditCoins); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(audits) != 1 {
+		t.Fatalf("expected 1 audit record, got %d", len(audits))
+	}
+	want := coinset.SelectionAudit{
+		TargetValue:  50000000,
+		TotalValue:   55000000,
+		NumInputs:    2,
+		Change:       5000000,
+		Waste:        2 * coinset.DefaultInputSize * 10,
+		PrivacyScore: 0.5,
+	}
+	if *audits[0] != want {
+		t.Errorf("unexpected audit record: got %+v, want %+v",
+			*audits[0], want)
+	}
+
+	// Failed selections are not audited.
+	_, err := selector.CoinSelect(100000000, auditCoins)
+	if !errors.Is(err, coinset.ErrCoinsNoSelectionAvailable) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(audits) != 1 {
+		t.Errorf("failed selection was audited")
+	}
+
+	// The selection is unchanged when no audit function is provided.
+	selector.Audit = nil
+	cs, err := selector.CoinSelect(25000000, auditCoins)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cs.Coins()) != 1 || cs.Coins()[0] != auditCoins[0] {
+		t.Errorf("unexpected selection without audit function")
+	}
+}
+
+func TestSelectorTieBreaking(t *testing.T) {
+	equalCoins := []coinset.Coin{
+		NewCoin(1, 10000000, 5),
+		NewCoin(2, 10000000, 5),
+		NewCoin(3, 10000000, 5),
+		NewCoin(4, 10000000, 5),
+		NewCoin(5, 10000000, 5),
+	}
+	selectors := []coinset.CoinSelector{
+		coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.SmallestFirstCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.MaxValueAgeCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.MaxConfsCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.MinPriorityCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.MinFeeCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+	}
+
+	for i, selector := range selectors {
+		var want []coinset.Coin
+		for seed := int64(0); seed < 10; seed++ {
+			shuffled := make([]coinset.Coin, len(equalCoins))
+			copy(shuffled, equalCoins)
+			rng := rand.New(rand.NewSource(seed))
+			rng.Shuffle(len(shuffled), func(i, j int) {
+				shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+			})
+
+			cs, err := selector.CoinSelect(25000000, shuffled)
+			if err != nil {
+				t.Fatalf("[%d] %T: unexpected error: %v", i, selector, err)
+			}
+			if want == nil {
+				want = cs.Coins()
+				continue
+			}
+			if !reflect.DeepEqual(cs.Coins(), want) {
+				t.Errorf("[%d] %T: selection depends on input order",
+					i, selector)
+				break
+			}
+		}
+	}
+}
+
+var (
+	dustCoins = []coinset.Coin{
+		NewCoin(1, 546, 10),
+		NewCoin(2, 30000000, 1),
+		NewCoin(3, 1000, 10),
+		NewCoin(4, 20000000, 1),
+	}
+
+	dustFilterSelectors = []coinset.DustFilterCoinSelector{
+		{
+			Selector:      coinset.MinIndexCoinSelector{MaxInputs: 10, MinChangeAmount: 0},
+			DustThreshold: 1000,
+		},
+		{
+			Selector:      coinset.MinIndexCoinSelector{MaxInputs: 10, MinChangeAmount: 0},
+			DustThreshold: 0,
+		},
+	}
+)
+
+var dustFilterTests = []coinSelectTest{
+	{dustFilterSelectors[0], dustCoins, 30000000, []coinset.Coin{dustCoins[1]}, nil},
+	{dustFilterSelectors[0], dustCoins, 50000000, []coinset.Coin{dustCoins[1], dustCoins[3]}, nil},
+	// The dust would be enough to reach the target, but is never used.
+	{dustFilterSelectors[0], dustCoins, 50000546, nil, coinset.ErrCoinsNoSelectionAvailable},
+	{dustFilterSelectors[0], dustCoins[:1], 546, nil, coinset.ErrCoinsNoSelectionAvailable},
+	// A zero threshold preserves the behavior of the wrapped selector.
+	{dustFilterSelectors[1], dustCoins, 546, []coinset.Coin{dustCoins[0]}, nil},
+	{dustFilterSelectors[1], dustCoins, 50001546, dustCoins, nil},
+}
+
+func TestDustFilterSelector(t *testing.T) {
+	testCoinSelector(dustFilterTests, t)
+}
+
+var (
+	minConfCoins = []coinset.Coin{
+		&coinset.SimpleCoin{Tx: testSimpleCoinTx, TxIndex: 0, TxNumConfs: 0},
+		&coinset.SimpleCoin{Tx: testSimpleCoinTx, TxIndex: 1, TxNumConfs: 6},
+		&coinset.SimpleCoin{Tx: testSimpleCoinTx, TxIndex: 0, TxNumConfs: 1},
+	}
+
+	minConfSelectors = []coinset.MinConfCoinSelector{
+		{
+			Selector: coinset.MinIndexCoinSelector{MaxInputs: 10, MinChangeAmount: 0},
+			MinConf:  0,
+		},
+		{
+			Selector: coinset.MinIndexCoinSelector{MaxInputs: 10, MinChangeAmount: 0},
+			MinConf:  1,
+		},
+		{
+			Selector: coinset.MinIndexCoinSelector{MaxInputs: 10, MinChangeAmount: 0},
+			MinConf:  6,
+		},
+	}
+)
+
+var minConfTests = []coinSelectTest{
+	// Without a minimum the unconfirmed coin is selected first.
+	{minConfSelectors[0], minConfCoins, 3500000, []coinset.Coin{minConfCoins[0]}, nil},
+	{minConfSelectors[1], minConfCoins, 3500000, []coinset.Coin{minConfCoins[1]}, nil},
+	{minConfSelectors[1], minConfCoins, 52876872, []coinset.Coin{minConfCoins[1], minConfCoins[2]}, nil},
+	{minConfSelectors[1], minConfCoins, 56376872, nil, coinset.ErrCoinsNoSelectionAvailable},
+	{minConfSelectors[2], minConfCoins, 3500000, []coinset.Coin{minConfCoins[1]}, nil},
+	{minConfSelectors[2], minConfCoins, 52876872, nil, coinset.ErrCoinsNoSelectionAvailable},
+	{minConfSelectors[2], minConfCoins[:1], 1, nil, coinset.ErrCoinsNoSelectionAvailable},
+}
+
+func TestMinConfSelector(t *testing.T) {
+	testCoinSelector(minConfTests, t)
+}
+
+// lockedOutPoints returns a set containing the outpoints of the passed coins.
+func lockedOutPoints(coins ...coinset.Coin) map[wire.OutPoint]struct{} {
+	locked := make(map[wire.OutPoint]struct{}, len(coins))
+	for _, coin := range coins {
+		locked[*wire.NewOutPoint(coin.Hash(), coin.Index())] = struct{}{}
+	}
+	return locked
+}
+
+var lockedSelectors = []coinset.LockedCoinSelector{
+	{
+		Selector: coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+	},
+	{
+		Selector: coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		Locked:   lockedOutPoints(coins[0]),
+	},
+	{
+		Selector: coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		Locked:   lockedOutPoints(coins...),
+	},
+}
+
+var lockedTests = []coinSelectTest{
+	// Without any locked coins the single largest coin is ideal.
+	{lockedSelectors[0], coins, 60000000, []coinset.Coin{coins[0]}, nil},
+	// The ideal coin is locked, so it must never be selected.
+	{lockedSelectors[1], coins, 60000000, []coinset.Coin{coins[2], coins[3]}, nil},
+	{lockedSelectors[1], coins, 100000000, nil, coinset.ErrCoinsNoSelectionAvailable},
+	{lockedSelectors[2], coins, 1, nil, coinset.ErrCoinsNoSelectionAvailable},
+}
+
+func TestLockedSelector(t *testing.T) {
+	testCoinSelector(lockedTests, t)
+}
+
+var (
+	timelockedCoins = []coinset.Coin{
+		NewOptionCoin(1, 100000000, 1, TestOptionCoin{TxMaturity: 500}),
+		NewCoin(2, 30000000, 1),
+		NewOptionCoin(3, 50000000, 1, TestOptionCoin{TxMaturity: 200}),
+		NewOptionCoin(4, 25000000, 1, TestOptionCoin{}),
+	}
+
+	spendableSelectors = []coinset.SpendableCoinSelector{
+		{
+			Selector:    coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 0},
+			SpendHeight: 199,
+		},
+		{
+			Selector:    coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 0},
+			SpendHeight: 200,
+		},
+		{
+			Selector:    coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 0},
+			SpendHeight: 500,
+		},
+	}
+)
+
+var spendableTests = []coinSelectTest{
+	// Below their maturity heights the timelocked coins are excluded.
+	{spendableSelectors[0], timelockedCoins, 50000000, []coinset.Coin{timelockedCoins[1], timelockedCoins[3]}, nil},
+	{spendableSelectors[0], timelockedCoins, 60000000, nil, coinset.ErrCoinsNoSelectionAvailable},
+	// Coins are included from their maturity height onwards.
+	{spendableSelectors[1], timelockedCoins, 50000000, []coinset.Coin{timelockedCoins[2]}, nil},
+	{spendableSelectors[1], timelockedCoins, 100000000, []coinset.Coin{timelockedCoins[2], timelockedCoins[1], timelockedCoins[3]}, nil},
+	{spendableSelectors[2], timelockedCoins, 100000000, []coinset.Coin{timelockedCoins[0]}, nil},
+}
+
+func TestSpendableSelector(t *testing.T) {
+	testCoinSelector(spendableTests, t)
+}
+
+var (
+	labeledCoins = []coinset.Coin{
+		NewOptionCoin(1, 100000000, 1, TestOptionCoin{TxLabel: "savings"}),
+		NewOptionCoin(2, 30000000, 1, TestOptionCoin{TxLabel: "spending"}),
+		// An unlabeled coin which would otherwise be ideal.
+		NewCoin(3, 50000000, 1),
+		NewOptionCoin(4, 25000000, 1, TestOptionCoin{TxLabel: "spending"}),
+		NewOptionCoin(5, 10000000, 1, TestOptionCoin{TxLabel: "savings"}),
+	}
+
+	labelSelectors = []coinset.LabelCoinSelector{
+		{
+			Selector: coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 0},
+			Label:    "spending",
+		},
+		{
+			Selector: coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 0},
+			Label:    "savings",
+		},
+		{
+			Selector: coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 0},
+			Label:    "",
+		},
+		{
+			Selector: coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 0},
+			Label:    "unknown",
+		},
+	}
+)
+
+var labelTests = []coinSelectTest{
+	{labelSelectors[0], labeledCoins, 50000000, []coinset.Coin{labeledCoins[1], labeledCoins[3]}, nil},
+	{labelSelectors[0], labeledCoins, 20000000, []coinset.Coin{labeledCoins[1]}, nil},
+	{labelSelectors[0], labeledCoins, 60000000, nil, coinset.ErrCoinsNoSelectionAvailable},
+	{labelSelectors[1], labeledCoins, 105000000, []coinset.Coin{labeledCoins[0], labeledCoins[4]}, nil},
+	// Without a label every coin may be selected.
+	{labelSelectors[2], labeledCoins, 140000000, []coinset.Coin{labeledCoins[0], labeledCoins[2]}, nil},
+	{labelSelectors[3], labeledCoins, 1, nil, coinset.ErrCoinsNoSelectionAvailable},
+}
+
+func TestLabelSelector(t *testing.T) {
+	testCoinSelector(labelTests, t)
+}
+
+var (
+	reusedAddr, _   = btcutil.NewAddressPubKeyHash(make([]byte, 20), &chaincfg.MainNetParams)
+	distinctAddr, _ = btcutil.NewAddressPubKeyHash(bytes.Repeat([]byte{0x01}, 20), &chaincfg.MainNetParams)
+
+	addressCoins = []coinset.Coin{
+		NewOptionCoin(1, 50000000, 1, TestOptionCoin{TxAddress: reusedAddr}),
+		NewOptionCoin(2, 40000000, 1, TestOptionCoin{TxAddress: reusedAddr}),
+		NewOptionCoin(3, 30000000, 1, TestOptionCoin{TxAddress: distinctAddr}),
+		// Coins without an address are never considered reused.
+		NewOptionCoin(4, 20000000, 1, TestOptionCoin{}),
+		NewOptionCoin(5, 10000000, 1, TestOptionCoin{TxAddress: reusedAddr}),
+	}
+
+	distinctAddressSelectors = []coinset.DistinctAddressCoinSelector{
+		{Selector: coinset.MinIndexCoinSelector{MaxInputs: 10, MinChangeAmount: 0}},
+		{Selector: coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 0}},
+	}
+)
+
+var distinctAddressTests = []coinSelectTest{
+	// The second coin paying the reused address is skipped in favor of
+	// the next coin paying a distinct address.
+	{distinctAddressSelectors[0], addressCoins, 70000000, []coinset.Coin{addressCoins[0], addressCoins[2]}, nil},
+	{distinctAddressSelectors[0], addressCoins, 100000000, []coinset.Coin{addressCoins[0], addressCoins[2], addressCoins[3]}, nil},
+	{distinctAddressSelectors[1], addressCoins, 85000000, []coinset.Coin{addressCoins[0], addressCoins[2], addressCoins[3]}, nil},
+	// Reusing the address is the only way to reach the target value.
+	{distinctAddressSelectors[0], addressCoins, 110000000, []coinset.Coin{addressCoins[0], addressCoins[1], addressCoins[2]}, nil},
+	{distinctAddressSelectors[1], addressCoins, 110000000, []coinset.Coin{addressCoins[0], addressCoins[1], addressCoins[2]}, nil},
+	{distinctAddressSelectors[1], addressCoins, 160000000, nil, coinset.ErrCoinsNoSelectionAvailable},
+	// Coins which do not expose an address are passed through unchanged.
+	{distinctAddressSelectors[1], coins, 105000000, []coinset.Coin{coins[0], coins[2]}, nil},
+}
+
+func TestDistinctAddressSelector(t *testing.T) {
+	testCoinSelector(distinctAddressTests, t)
+}
+
+func TestMaxAchievable(t *testing.T) {
+	tests := []struct {
+		coins         []coinset.Coin
+		maxInputs     int
+		dustThreshold btcutil.Amount
+		want          btcutil.Amount
+	}{
+		{coins, 1, 0, 100000000},
+		{coins, 2, 0, 150000000},
+		{coins, 3, 0, 175000000},
+		{coins, 4, 0, 185000000},
+		// Fewer coins than the maximum number of inputs.
+		{coins, 10, 0, 185000000},
+		{coins[1:2], 10, 0, 10000000},
+		{nil, 10, 0, 0},
+		{coins, 0, 0, 0},
+		// Dust coins are never counted, even when there is room for
+		// more inputs.
+		{coins, 4, 10000000, 175000000},
+		{coins, 2, 50000000, 100000000},
+		{coins, 4, 100000000, 0},
+	}
+
+	for i, test := range tests {
+		got := coinset.MaxAchievable(test.coins, test.maxInputs,
+			test.dustThreshold)
+		if got != test.want {
+			t.Errorf("[%d] MaxAchievable: got %v, want %v", i, got,
+				test.want)
+		}
+	}
+
+	// A target is only achievable up to the reported total.
+	selector := coinset.DustFilterCoinSelector{
+		Selector:      coinset.MinNumberCoinSelector{MaxInputs: 2},
+		DustThreshold: 10000000,
+	}
+	max := coinset.MaxAchievable(coins, 2, 10000000)
+	if _, err := selector.CoinSelect(max, coins); err != nil {
+		t.Errorf("CoinSelect: unexpected error for achievable target: %v",
+			err)
+	}
+	if _, err := selector.CoinSelect(max+1, coins); err == nil {
+		t.Errorf("CoinSelect: expected error for unachievable target")
+	}
+}
+
+func TestSelectForOutputs(t *testing.T) {
+	selector := coinset.MinIndexCoinSelector{MaxInputs: 10, MinChangeAmount: 10000}
+	tests := []struct {
+		name     string
+		outputs  []btcutil.Amount
+		fee      btcutil.Amount
+		dust     btcutil.Amount
+		expected []coinset.Coin
+		err      error
+	}{
+		{
+			name:     "single output",
+			outputs:  []btcutil.Amount{50000000},
+			fee:      10000,
+			dust:     546,
+			expected: []coinset.Coin{coins[0]},
+		},
+		{
+			name:     "several outputs",
+			outputs:  []btcutil.Amount{60000000, 30000000, 15000000},
+			fee:      10000,
+			dust:     546,
+			expected: []coinset.Coin{coins[0], coins[1]},
+		},
+		{
+			// The outputs and fees total exactly the first two coins,
+			// so no change is needed.
+			name:     "exact total",
+			outputs:  []btcutil.Amount{60000000, 49980000},
+			fee:      10000,
+			dust:     546,
+			expected: []coinset.Coin{coins[0], coins[1]},
+		},
+		{
+			// The change would be below the minimum change amount.
+			name:     "change below minimum",
+			outputs:  []btcutil.Amount{60000000, 49975000},
+			fee:      10000,
+			dust:     546,
+			expected: []coinset.Coin{coins[0], coins[1], coins[2]},
+		},
+		{
+			name:    "output below dust",
+			outputs: []btcutil.Amount{60000000, 500, 15000000},
+			fee:     10000,
+			dust:    546,
+			err:     coinset.ErrDustOutput,
+		},
+		{
+			name:    "output at dust threshold",
+			outputs: []btcutil.Amount{546},
+			dust:    546,
+			err:     coinset.ErrDustOutput,
+		},
+		{
+			name: "no outputs",
+			err:  coinset.ErrNoOutputs,
+		},
+		{
+			name:    "insufficient funds",
+			outputs: []btcutil.Amount{100000000, 85000000},
+			fee:     1,
+			err:     coinset.ErrCoinsNoSelectionAvailable,
+		},
+	}
+
+	for _, test := range tests {
+		cs, err := coinset.SelectForOutputs(selector, test.outputs,
+			test.fee, test.dust, coins)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: got error %v, want %v", test.name, err,
+				test.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if !reflect.DeepEqual(cs.Coins(), test.expected) {
+			t.Errorf("%s: got coins %v, want %v", test.name,
+				cs.Coins(), test.expected)
+		}
+	}
+}
+
+func TestChangeAmount(t *testing.T) {
+	tests := []struct {
+		selected []coinset.Coin
+		target   btcutil.Amount
+		want     btcutil.Amount
+	}{
+		// Exact match.
+		{[]coinset.Coin{coins[0]}, 100000000, 0},
+		{[]coinset.Coin{coins[2], coins[3]}, 75000000, 0},
+		// Overshoot.
+		{[]coinset.Coin{coins[0]}, 60000000, 40000000},
+		{[]coinset.Coin{coins[1], coins[3]}, 1, 34999999},
+		// The selection does not cover the target.
+		{[]coinset.Coin{coins[1]}, 20000000, 0},
+		{nil, 1, 0},
+	}
+
+	for i, test := range tests {
+		got := coinset.ChangeAmount(coinset.NewCoinSet(test.selected),
+			test.target)
+		if got != test.want {
+			t.Errorf("[%d] ChangeAmount: got %v, want %v", i, got,
+				test.want)
+		}
+	}
+
+	// The change of a selection satisfies the minimum change amount.
+	selector := coinset.MinIndexCoinSelector{MaxInputs: 10, MinChangeAmount: 10000}
+	for _, target := range []btcutil.Amount{100000000, 99995000, 60000000} {
+		cs, err := selector.CoinSelect(target, coins)
+		if err != nil {
+			t.Fatalf("CoinSelect: unexpected error: %v", err)
+		}
+		change := coinset.ChangeAmount(cs, target)
+		if change != 0 && change < 10000 {
+			t.Errorf("ChangeAmount: got %v for target %v, want zero "+
+				"or at least the minimum change", change, target)
+		}
+	}
+	if change := coinset.ChangeAmount(nil, 1); change != 0 {
+		t.Errorf("ChangeAmount: got %v for nil selection, want 0", change)
+	}
+}
+
+func TestInputHeadroom(t *testing.T) {
+	tests := []struct {
+		selector  coinset.MinNumberCoinSelector
+		target    btcutil.Amount
+		maxInputs int
+		want      int
+	}{
+		{coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 0}, 60000000, 10, 9},
+		{coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 0}, 140000000, 10, 8},
+		{coinset.MinNumberCoinSelector{MaxInputs: 3, MinChangeAmount: 0}, 160000000, 3, 0},
+		{coinset.MinNumberCoinSelector{MaxInputs: 4, MinChangeAmount: 0}, 185000000, 4, 0},
+	}
+
+	for i, test := range tests {
+		cs, err := test.selector.CoinSelect(test.target, coins)
+		if err != nil {
+			t.Fatalf("[%d] CoinSelect: unexpected error: %v", i, err)
+		}
+		got := coinset.InputHeadroom(cs, test.maxInputs)
+		if got != test.want {
+			t.Errorf("[%d] InputHeadroom: got %d, want %d", i, got,
+				test.want)
+		}
+		if got != test.maxInputs-len(cs.Coins()) {
+			t.Errorf("[%d] InputHeadroom: got %d for %d of %d inputs",
+				i, got, len(cs.Coins()), test.maxInputs)
+		}
+	}
+
+	// The headroom is never negative.
+	if got := coinset.InputHeadroom(coinset.NewCoinSet(coins), 2); got != 0 {
+		t.Errorf("InputHeadroom: got %d for a selection exceeding the "+
+			"maximum, want 0", got)
+	}
+	if got := coinset.InputHeadroom(nil, 5); got != 5 {
+		t.Errorf("InputHeadroom: got %d for nil selection, want 5", got)
+	}
+}
+
+func TestValidateSelection(t *testing.T) {
+	tests := []struct {
+		name      string
+		selected  []coinset.Coin
+		target    btcutil.Amount
+		maxInputs int
+		minChange btcutil.Amount
+		dust      btcutil.Amount
+		err       error
+	}{
+		{"exact", []coinset.Coin{coins[0]}, 100000000, 10, 10000, 0, nil},
+		{"with change", []coinset.Coin{coins[0], coins[1]}, 100000000, 10, 10000, 0, nil},
+		{"minimum change", []coinset.Coin{coins[1]}, 9990000, 10, 10000, 0, nil},
+		{"max inputs", coins, 185000000, 4, 0, 0, nil},
+		{"above dust", []coinset.Coin{coins[1]}, 10000000, 10, 0, 9999999, nil},
+		{"too many inputs", coins, 185000000, 3, 0, 0, coinset.ErrTooManyInputs},
+		{"dust input", []coinset.Coin{coins[0], coins[1]}, 100000000, 10, 0, 10000000, coinset.ErrDustInput},
+		{"below target", []coinset.Coin{coins[2], coins[3]}, 80000000, 10, 0, 0, coinset.ErrBelowTarget},
+		{"empty selection", nil, 1, 10, 0, 0, coinset.ErrBelowTarget},
+		{"insufficient change", []coinset.Coin{coins[1]}, 9990001, 10, 10000, 0, coinset.ErrInsufficientChange},
+	}
+
+	for _, test := range tests {
+		err := coinset.ValidateSelection(test.target, test.selected,
+			test.maxInputs, test.minChange, test.dust)
+		if err != test.err {
+			t.Errorf("%s: got error %v, want %v", test.name, err, test.err)
+		}
+	}
+
+	// Selections made by the selectors with the same rules are valid.
+	selector := coinset.MinNumberCoinSelector{MaxInputs: 3, MinChangeAmount: 10000}
+	for _, target := range []btcutil.Amount{10000000, 99995000, 150000000, 175000000} {
+		cs, err := selector.CoinSelect(target, coins)
+		if err != nil {
+			t.Fatalf("CoinSelect: unexpected error: %v", err)
+		}
+		if err := coinset.ValidateSelection(target, cs.Coins(), 3, 10000, 0); err != nil {
+			t.Errorf("target %v: selection is invalid: %v", target, err)
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+	pinned := NewCoin(99, 5000000, 1)
+	tests := []struct {
+		selector coinset.CoinSelector
+		coins    []coinset.Coin
+		target   btcutil.Amount
+		indexes  []int
+		total    btcutil.Amount
+		change   btcutil.Amount
+	}{
+		{coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 0}, coins, 120000000, []int{0, 2}, 150000000, 30000000},
+		{coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 0}, coins, 100000000, []int{0}, 100000000, 0},
+		{coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 0}, coins, 185000000, []int{0, 2, 3, 1}, 185000000, 0},
+		{coinset.MinIndexCoinSelector{MaxInputs: 10, MinChangeAmount: 0}, coins[1:], 55000000, []int{0, 1}, 60000000, 5000000},
+		// A pinned coin which was not passed has no index.
+		{coinset.PinnedCoinSelector{MaxInputs: 10, MinChangeAmount: 0, Pinned: []coinset.Coin{pinned}}, coins[1:], 50000000, []int{-1, 1}, 55000000, 5000000},
+	}
+
+	for i, test := range tests {
+		s, err := coinset.Select(test.selector, test.target, test.coins)
+		if err != nil {
+			t.Errorf("[%d] Select: unexpected error: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(s.Indexes, test.indexes) {
+			t.Errorf("[%d] Select: got indexes %v, want %v", i, s.Indexes,
+				test.indexes)
+			continue
+		}
+		if s.Total != test.total || s.Change != test.change {
+			t.Errorf("[%d] Select: got total %v and change %v, want %v "+
+				"and %v", i, s.Total, s.Change, test.total, test.change)
+		}
+
+		// Every field must describe the same selection.
+		if s.NumInputs != len(s.Coins) || len(s.Indexes) != len(s.Coins) {
+			t.Errorf("[%d] Select: %d inputs with %d coins and %d "+
+				"indexes", i, s.NumInputs, len(s.Coins), len(s.Indexes))
+			continue
+		}
+		var total btcutil.Amount
+		for j, coin := range s.Coins {
+			total += coin.Value()
+			if idx := s.Indexes[j]; idx >= 0 && test.coins[idx] != coin {
+				t.Errorf("[%d] Select: index %d does not refer to "+
+					"selected coin %d", i, idx, j)
+			}
+		}
+		if total != s.Total || s.Total-s.Change != test.target {
+			t.Errorf("[%d] Select: total %v and change %v are "+
+				"inconsistent with coins totaling %v for target %v", i,
+				s.Total, s.Change, total, test.target)
+		}
+	}
+
+	// Selection errors are returned unchanged.
+	selector := coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 0}
+	want, wantErr := selector.CoinSelect(200000000, coins)
+	s, err := coinset.Select(selector, 200000000, coins)
+	if s != nil || want != nil || !reflect.DeepEqual(err, wantErr) {
+		t.Errorf("Select: got %v and error %v, want error %v", s, err,
+			wantErr)
+	}
+}
+
+func TestSelectStable(t *testing.T) {
+	tests := []struct {
+		strategy coinset.Strategy
+		expected []coinset.Coin
+	}{
+		{coinset.MinNumber, []coinset.Coin{coins[0]}},
+		{coinset.MaxValueAge, []coinset.Coin{coins[1], coins[3], coins[0]}},
+		{coinset.SmallestFirst, []coinset.Coin{coins[1], coins[3], coins[2]}},
+		// The selection is randomized, so only its total is checked.
+		{coinset.MinChange, nil},
+	}
+
+	for _, test := range tests {
+		input := make([]coinset.Coin, len(coins))
+		copy(input, coins)
+
+		selected, err := coinset.SelectStable(test.strategy, 10, 10000,
+			60000000, input)
+		if err != nil {
+			t.Errorf("%d: SelectStable: unexpected error: %v",
+				test.strategy, err)
+			continue
+		}
+		if !reflect.DeepEqual(input, coins) {
+			t.Errorf("%d: SelectStable: input coins were reordered",
+				test.strategy)
+		}
+		if test.expected != nil && !reflect.DeepEqual(selected, test.expected) {
+			t.Errorf("%d: SelectStable: got %v, want %v", test.strategy,
+				selected, test.expected)
+		}
+		if total := coinset.NewCoinSet(selected).TotalValue(); total < 60000000 {
+			t.Errorf("%d: SelectStable: total %v is below target",
+				test.strategy, total)
+		}
+
+		// The returned slice must not share memory with the input.
+		for i := range selected {
+			selected[i] = nil
+		}
+		if !reflect.DeepEqual(input, coins) {
+			t.Errorf("%d: SelectStable: result aliases input coins",
+				test.strategy)
+		}
+	}
+
+	// Selection failures and unknown strategies are reported.
+	if _, err := coinset.SelectStable(coinset.MinNumber, 10, 10000,
+		200000000, coins); !errors.Is(err, coinset.ErrCoinsNoSelectionAvailable) {
+		t.Errorf("SelectStable: got error %v, want %v", err,
+			coinset.ErrCoinsNoSelectionAvailable)
+	}
+	if _, err := coinset.SelectStable(coinset.Strategy(-1), 10, 10000,
+		60000000, coins); err != coinset.ErrUnknownStrategy {
+		t.Errorf("SelectStable: got error %v, want %v", err,
+			coinset.ErrUnknownStrategy)
+	}
+}
+
+func TestUnselected(t *testing.T) {
+	selectors := []coinset.CoinSelector{
+		coinset.MinIndexCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.SmallestFirstCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.MaxConfsCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.RangeCoinSelector{MaxInputs: 10, MaxExcess: 100000000},
+	}
+	targets := []btcutil.Amount{10000000, 35000000, 60000000, 185000000}
+
+	for i, selector := range selectors {
+		for _, target := range targets {
+			cs, err := selector.CoinSelect(target, coins)
+			if err != nil {
+				t.Fatalf("[%d] %T: unexpected error: %v", i, selector, err)
+			}
+			unselected := coinset.Unselected(coins, cs)
+
+			// The selected and unselected coins must partition the
+			// original coins exactly.
+			seen := make(map[coinset.Coin]int)
+			for _, coin := range append(cs.Coins(), unselected...) {
+				seen[coin]++
+			}
+			if len(seen) != len(coins) ||
+				len(cs.Coins())+len(unselected) != len(coins) {
+				t.Errorf("[%d] %T: target %v: selected %d and "+
+					"unselected %d coins do not partition %d coins",
+					i, selector, target, len(cs.Coins()),
+					len(unselected), len(coins))
+			}
+			for _, coin := range coins {
+				if seen[coin] != 1 {
+					t.Errorf("[%d] %T: target %v: coin %v seen %d "+
+						"times", i, selector, target, coin.Value(),
+						seen[coin])
+				}
+			}
+
+			// The unselected coins keep their original order.
+			for j := 1; j < len(unselected); j++ {
+				if indexOfCoin(unselected[j-1]) > indexOfCoin(unselected[j]) {
+					t.Errorf("[%d] %T: unselected coins reordered",
+						i, selector)
+				}
+			}
+		}
+	}
+
+	// Nothing is removed for an empty selection, and only one coin is
+	// removed per selected coin when an outpoint is repeated.
+	if got := coinset.Unselected(coins, nil); !reflect.DeepEqual(got, coins) {
+		t.Errorf("unexpected coins for nil selection: %v", got)
+	}
+	repeated := []coinset.Coin{coins[0], coins[1], coins[0]}
+	got := coinset.Unselected(repeated, coinset.NewCoinSet(coins[:1]))
+	if want := []coinset.Coin{coins[1], coins[0]}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected coins for repeated outpoint: %v", got)
+	}
+}
+
+// indexOfCoin returns the index of the coin in coins, or -1 if it is not
+// present.
+func indexOfCoin(coin coinset.Coin) int {
+	for i, c := range coins {
+		if c == coin {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestSelectorsDoNotMutateInput(t *testing.T) {
+	selectors := []coinset.CoinSelector{
+		coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.SmallestFirstCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.MaxValueAgeCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.MaxConfsCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.MinPriorityCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.MinFeeCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.MaxWeightCoinSelector{MaxInputs: 10, MinChangeAmount: 10000, MaxWeight: 1200},
+		coinset.RandomCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.WeightedRandomCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.RangeCoinSelector{MaxInputs: 10, MaxExcess: 100000000},
+		coinset.MaxTotalCoinSelector{MaxInputs: 10, MaxTotal: 160000000},
+		coinset.NoChangeCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.MinChangeCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.FirstFitDecreasingCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.SortedCoinSelector{MaxInputs: 10, MinChangeAmount: 10000, Less: oldestFirst},
+		coinset.PinnedCoinSelector{MaxInputs: 10, MinChangeAmount: 10000, Pinned: coins[1:2]},
+		coinset.TopKCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.LockedCoinSelector{
+			Selector: coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+			Locked:   lockedOutPoints(coins[0]),
+		},
+		coinset.SpendableCoinSelector{
+			Selector:    coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+			SpendHeight: 100,
+		},
+		coinset.DistinctAddressCoinSelector{
+			Selector: coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		},
+	}
+
+	for i, selector := range selectors {
+		input := make([]coinset.Coin, len(coins))
+		copy(input, coins)
+
+		cs, err := selector.CoinSelect(60000000, input)
+		if err != nil {
+			t.Fatalf("[%d] %T: unexpected error: %v", i, selector, err)
+		}
+		if !reflect.DeepEqual(input, coins) {
+			t.Errorf("[%d] %T: input coins were reordered", i, selector)
+		}
+
+		// Every selected coin must be one of the input coins.
+		for _, selected := range cs.Coins() {
+			found := false
+			for _, coin := range input {
+				if coin == selected {
+					found = true
+					break
+				}
+			}
+			if !found {
+				t.Errorf("[%d] %T: selected coin not in input", i,
+					selector)
+			}
+		}
+	}
+}
+
+var fallbackSelectors = []coinset.FallbackCoinSelector{
+	{
+		Selectors: []coinset.CoinSelector{
+			// Only a single input is allowed, which is not enough to
+			// reach most targets.
+			coinset.MinNumberCoinSelector{MaxInputs: 1, MinChangeAmount: 10000},
+			coinset.SmallestFirstCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		},
+	},
+	{},
+}
+
+var fallbackTests = []coinSelectTest{
+	// The first selector succeeds.
+	{fallbackSelectors[0], coins, 50000000, []coinset.Coin{coins[0]}, nil},
+	// The first selector fails and the second succeeds.
+	{fallbackSelectors[0], coins, 110000000, []coinset.Coin{coins[1], coins[3], coins[2], coins[0]}, nil},
+	// Both selectors fail.
+	{fallbackSelectors[0], coins, 185000001, nil, coinset.ErrCoinsNoSelectionAvailable},
+	// No selectors to try.
+	{fallbackSelectors[1], coins, 1, nil, coinset.ErrCoinsNoSelectionAvailable},
+}
+
+func TestFallbackSelector(t *testing.T) {
+	testCoinSelector(fallbackTests, t)
+}
+
+func TestSelectionError(t *testing.T) {
+	scriptCoins := []coinset.Coin{
+		NewOptionCoin(1, 60000000, 1, TestOptionCoin{TxPkScript: testP2PKHScript}),
+		NewOptionCoin(2, 50000000, 1, TestOptionCoin{TxPkScript: testP2WPKHScript}),
+		NewOptionCoin(3, 30000000, 1, TestOptionCoin{TxPkScript: testP2PKHScript}),
+	}
+	tests := []struct {
+		name      string
+		selector  coinset.CoinSelector
+		coins     []coinset.Coin
+		target    btcutil.Amount
+		reason    coinset.SelectionFailure
+		bestTotal btcutil.Amount
+	}{
+		{
+			name:      "insufficient funds",
+			selector:  coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+			coins:     coins,
+			target:    200000000,
+			reason:    coinset.InsufficientFunds,
+			bestTotal: 185000000,
+		},
+		{
+			name:      "max inputs exceeded",
+			selector:  coinset.MinNumberCoinSelector{MaxInputs: 2, MinChangeAmount: 10000},
+			coins:     coins,
+			target:    160000000,
+			reason:    coinset.MaxInputsExceeded,
+			bestTotal: 150000000,
+		},
+		{
+			name:      "insufficient funds with max inputs",
+			selector:  coinset.MinNumberCoinSelector{MaxInputs: 2, MinChangeAmount: 10000},
+			coins:     coins,
+			target:    190000000,
+			reason:    coinset.InsufficientFunds,
+			bestTotal: 150000000,
+		},
+		{
+			name: "all dust",
+			selector: coinset.DustFilterCoinSelector{
+				Selector:      coinset.MinIndexCoinSelector{MaxInputs: 10},
+				DustThreshold: 1000,
+			},
+			coins:  dustCoins[:1],
+			target: 500,
+			reason: coinset.AllDust,
+		},
+		{
+			name:     "all uneconomic",
+			selector: coinset.MinFeeCoinSelector{MaxInputs: 10, FeePerByte: 100},
+			coins:    minFeeCoins[4:],
+			target:   1,
+			reason:   coinset.AllDust,
+		},
+		{
+			name:      "max weight exceeded",
+			selector:  maxWeightSelectors[0],
+			coins:     weightCoins,
+			target:    106000000,
+			reason:    coinset.MaxWeightExceeded,
+			bestTotal: 105000000,
+		},
+		{
+			name:      "max weight exceeded with max inputs",
+			selector:  maxWeightSelectors[2],
+			coins:     weightCoins,
+			target:    101000000,
+			reason:    coinset.MaxWeightExceeded,
+			bestTotal: 100000000,
+		},
+		{
+			name:      "max weight max inputs exceeded",
+			selector:  maxWeightSelectors[2],
+			coins:     weightCoins,
+			target:    120000000,
+			reason:    coinset.MaxInputsExceeded,
+			bestTotal: 100000000,
+		},
+		{
+			name:      "max weight insufficient funds",
+			selector:  maxWeightSelectors[0],
+			coins:     weightCoins,
+			target:    300000000,
+			reason:    coinset.InsufficientFunds,
+			bestTotal: 105000000,
+		},
+		{
+			name:      "min fee max inputs exceeded",
+			selector:  coinset.MinFeeCoinSelector{MaxInputs: 1, MinChangeAmount: 10000, FeePerByte: 100},
+			coins:     minFeeCoins[1:3],
+			target:    40000000,
+			reason:    coinset.MaxInputsExceeded,
+			bestTotal: 35000000 - 14800,
+		},
+		{
+			name:      "min priority insufficient funds",
+			selector:  minPrioritySelectors[0],
+			coins:     connectedCoins,
+			target:    140000000,
+			reason:    coinset.InsufficientFunds,
+			bestTotal: 135000000,
+		},
+		{
+			name:      "min priority no coins with sufficient value age",
+			selector:  minPrioritySelectors[4],
+			coins:     connectedCoins,
+			target:    1,
+			reason:    coinset.InsufficientFunds,
+			bestTotal: 0,
+		},
+		{
+			name:      "min priority max inputs exceeded",
+			selector:  coinset.MinPriorityCoinSelector{MaxInputs: 2, MinChangeAmount: 10000, MinAvgValueAgePerInput: 100000000},
+			coins:     connectedCoins,
+			target:    130000000,
+			reason:    coinset.MaxInputsExceeded,
+			bestTotal: 125000000,
+		},
+		{
+			name:      "uniform script insufficient funds",
+			selector:  coinset.UniformScriptCoinSelector{Selector: coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 10000}},
+			coins:     scriptCoins,
+			target:    100000000,
+			reason:    coinset.InsufficientFunds,
+			bestTotal: 90000000,
+		},
+		{
+			name:      "uniform script max inputs exceeded",
+			selector:  coinset.UniformScriptCoinSelector{Selector: coinset.MinNumberCoinSelector{MaxInputs: 1, MinChangeAmount: 10000}},
+			coins:     scriptCoins,
+			target:    80000000,
+			reason:    coinset.MaxInputsExceeded,
+			bestTotal: 60000000,
+		},
+		{
+			name:      "uniform script no coins",
+			selector:  coinset.UniformScriptCoinSelector{Selector: coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 10000}},
+			coins:     nil,
+			target:    1,
+			reason:    coinset.InsufficientFunds,
+			bestTotal: 0,
+		},
+		{
+			name: "fallback returns last error",
+			selector: coinset.FallbackCoinSelector{Selectors: []coinset.CoinSelector{
+				coinset.DustFilterCoinSelector{
+					Selector:      coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+					DustThreshold: 60000000,
+				},
+				coinset.MinNumberCoinSelector{MaxInputs: 2, MinChangeAmount: 10000},
+			}},
+			coins:     coins,
+			target:    160000000,
+			reason:    coinset.MaxInputsExceeded,
+			bestTotal: 150000000,
+		},
+		{
+			name:      "fallback without selectors",
+			selector:  coinset.FallbackCoinSelector{},
+			coins:     coins,
+			target:    1,
+			reason:    coinset.InsufficientFunds,
+			bestTotal: 0,
+		},
+	}
+
+	for _, test := range tests {
+		_, err := test.selector.CoinSelect(test.target, test.coins)
+		if !errors.Is(err, coinset.ErrCoinsNoSelectionAvailable) {
+			t.Errorf("%s: error %v is not ErrCoinsNoSelectionAvailable",
+				test.name, err)
+			continue
+		}
+		var selErr *coinset.SelectionError
+		if !errors.As(err, &selErr) {
+			t.Errorf("%s: error %v is not a SelectionError", test.name, err)
+			continue
+		}
+		if selErr.Reason != test.reason {
+			t.Errorf("%s: got reason %v, want %v", test.name,
+				selErr.Reason, test.reason)
+		}
+		if selErr.BestTotal != test.bestTotal {
+			t.Errorf("%s: got best total %v, want %v", test.name,
+				selErr.BestTotal, test.bestTotal)
+		}
+	}
+
+	if s := coinset.SelectionFailure(99).String(); s != "Unknown SelectionFailure (99)" {
+		t.Errorf("unexpected string for unknown reason: %s", s)
+	}
+}
+
+// TestSelectorEdgeCases ensures every selector selects no coins for a zero
+// target, fails for a positive target without coins, selects a single coin
+// which meets the target exactly, and fails when a single coin is
+// insufficient.
+func TestSelectorEdgeCases(t *testing.T) {
+	base := coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 10000}
+	selectors := []coinset.CoinSelector{
+		coinset.MinIndexCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.MinNumberCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.TopKCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.SmallestFirstCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.MaxValueAgeCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.MaxConfsCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.SortedCoinSelector{MaxInputs: 10, MinChangeAmount: 10000, Less: oldestFirst},
+		coinset.MinPriorityCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.MinFeeCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.MaxWeightCoinSelector{MaxInputs: 10, MinChangeAmount: 10000, MaxWeight: 1200},
+		coinset.RandomCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.WeightedRandomCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.RangeCoinSelector{MaxInputs: 10, MaxExcess: 100000000},
+		coinset.MaxTotalCoinSelector{MaxInputs: 10, MaxTotal: 160000000},
+		coinset.NoChangeCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.MinChangeCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.FirstFitDecreasingCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+		coinset.PinnedCoinSelector{MaxInputs: 10, MinChangeAmount: 10000},
+
+		// Wrapper selectors must preserve the behavior of the selector
+		// they wrap.
+		coinset.MinConfCoinSelector{Selector: base, MinConf: 1},
+		coinset.LockedCoinSelector{Selector: base},
+		coinset.SpendableCoinSelector{Selector: base, SpendHeight: 1},
+		coinset.LabelCoinSelector{Selector: base},
+		coinset.DistinctAddressCoinSelector{Selector: base},
+		coinset.UniformScriptCoinSelector{Selector: base},
+		coinset.DustFilterCoinSelector{Selector: base, DustThreshold: 1000},
+		coinset.FallbackCoinSelector{Selectors: []coinset.CoinSelector{base}},
+		coinset.AuditCoinSelector{Selector: base, Audit: func(*coinset.SelectionAudit) {}},
+	}
+
+	single := []coinset.Coin{NewCoin(1, 50000000, 1)}
+	tests := []struct {
+		name     string
+		coins    []coinset.Coin
+		target   btcutil.Amount
+		expected []coinset.Coin
+		err      error
+	}{
+		{"zero target", coins, 0, []coinset.Coin{}, nil},
+		{"zero target without coins", nil, 0, []coinset.Coin{}, nil},
+		{"no coins", nil, 1, nil, coinset.ErrCoinsNoSelectionAvailable},
+		{"empty coins", []coinset.Coin{}, 1, nil, coinset.ErrCoinsNoSelectionAvailable},
+		{"single exact coin", single, 50000000, single, nil},
+		{"single insufficient coin", single, 50000001, nil, coinset.ErrCoinsNoSelectionAvailable},
+	}
+
+	for _, selector := range selectors {
+		for _, test := range tests {
+			cs, err := selector.CoinSelect(test.target, test.coins)
+			if !errors.Is(err, test.err) {
+				t.Errorf("%T (%s): got error %v, want %v", selector,
+					test.name, err, test.err)
+				continue
+			}
+			if err != nil {
+				continue
+			}
+			if got := cs.Coins(); !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("%T (%s): got coins %v, want %v", selector,
+					test.name, got, test.expected)
+			}
+		}
+	}
+
+	// A zero target needs no coins even when the wrapper would otherwise
+	// be unable to select any.
+	dust := []coinset.Coin{NewCoin(1, 500, 1), NewCoin(2, 1000, 1)}
+	zeroTarget := []struct {
+		selector coinset.CoinSelector
+		coins    []coinset.Coin
+	}{
+		{coinset.UniformScriptCoinSelector{Selector: base}, nil},
+		{coinset.DustFilterCoinSelector{Selector: base, DustThreshold: 1000}, dust},
+		{coinset.FallbackCoinSelector{}, coins},
+	}
+	for _, test := range zeroTarget {
+		cs, err := test.selector.CoinSelect(0, test.coins)
+		if err != nil {
+			t.Errorf("%T: unexpected error for zero target: %v",
+				test.selector, err)
+			continue
+		}
+		if got := cs.Coins(); len(got) != 0 {
+			t.Errorf("%T: got coins %v for zero target, want none",
+				test.selector, got)
+		}
+	}
+}
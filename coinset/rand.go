@@ -0,0 +1,85 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package coinset
+
+import (
+	"crypto/rand"
+	"math"
+	"math/big"
+	mrand "math/rand"
+
+	"github.com/btcsuite/btcutil"
+)
+
+// RandSource is the source of randomness used by the randomized
+// CoinSelectors.  It is satisfied by *math/rand.Rand, which is useful for
+// deterministic selections in tests, and by CryptoRandSource, which should
+// be preferred in production so that selections can not be predicted.
+type RandSource interface {
+	// Intn returns a uniformly distributed non-negative integer in the
+	// range [0, n).  It panics if n <= 0.
+	Intn(n int) int
+
+	// Shuffle pseudo-randomizes the order of n elements using swap to
+	// exchange the elements at indexes i and j.
+	Shuffle(n int, swap func(i, j int))
+}
+
+// Ensure *math/rand.Rand satisfies the RandSource interface.
+var _ RandSource = (*mrand.Rand)(nil)
+
+// cryptoRandSource implements RandSource using crypto/rand.
+type cryptoRandSource struct{}
+
+// CryptoRandSource is a RandSource backed by the cryptographically secure
+// random number generator provided by crypto/rand.
+var CryptoRandSource RandSource = cryptoRandSource{}
+
+// Intn returns a uniformly distributed non-negative integer in the range
+// [0, n) read from crypto/rand.  It panics if n <= 0 or if the system
+// random number generator fails.
+//
+// This is part of the RandSource interface implementation.
+func (cryptoRandSource) Intn(n int) int {
+	if n <= 0 {
+		panic("invalid argument to Intn")
+	}
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		panic(err)
+	}
+	return int(v.Int64())
+}
+
+// Shuffle randomizes the order of n elements with a Fisher-Yates shuffle
+// using randomness read from crypto/rand.
+//
+// This is part of the RandSource interface implementation.
+func (r cryptoRandSource) Shuffle(n int, swap func(i, j int)) {
+	if n < 0 {
+		panic("invalid argument to Shuffle")
+	}
+	for i := n - 1; i > 0; i-- {
+		swap(i, r.Intn(i+1))
+	}
+}
+
+// maxInt is the largest value of an int on the target platform.
+const maxInt = int(^uint(0) >> 1)
+
+// randAmount returns a uniformly distributed amount in the range [0, n) read
+// from rng.  It panics if n <= 0.
+func randAmount(rng RandSource, n btcutil.Amount) btcutil.Amount {
+	if int64(n) <= int64(maxInt) {
+		return btcutil.Amount(rng.Intn(int(n)))
+	}
+
+	// The amount does not fit in an int on this platform, so combine two
+	// values which do.  The result is very slightly biased toward smaller
+	// amounts.
+	hi := int64(rng.Intn(math.MaxInt32))
+	lo := int64(rng.Intn(math.MaxInt32))
+	return btcutil.Amount((hi*math.MaxInt32 + lo) % int64(n))
+}
@@ -45,6 +45,46 @@ func BenchmarkDeriveNormal(b *testing.B) {
 	}
 }
 
+// BenchmarkDeriveRangeLoop benchmarks how long it takes to derive a range of
+// normal children from a public extended key by calling Child in a loop.
+func BenchmarkDeriveRangeLoop(b *testing.B) {
+	b.StopTimer()
+	pubKey := benchPubKey(b)
+	b.StartTimer()
+
+	for i := 0; i < b.N; i++ {
+		for j := uint32(0); j < 20; j++ {
+			pubKey.Child(j)
+		}
+	}
+}
+
+// BenchmarkDeriveRange benchmarks how long it takes to derive the same range
+// of normal children as BenchmarkDeriveRangeLoop with DeriveRange.
+func BenchmarkDeriveRange(b *testing.B) {
+	b.StopTimer()
+	pubKey := benchPubKey(b)
+	b.StartTimer()
+
+	for i := 0; i < b.N; i++ {
+		pubKey.DeriveRange(0, 20)
+	}
+}
+
+// benchPubKey returns the public extended key for the first set of test
+// vectors in BIP0032.
+func benchPubKey(b *testing.B) *hdkeychain.ExtendedKey {
+	masterKey, err := hdkeychain.NewKeyFromString(bip0032MasterPriv1)
+	if err != nil {
+		b.Fatalf("Failed to decode master seed: %v", err)
+	}
+	pubKey, err := masterKey.Neuter()
+	if err != nil {
+		b.Fatalf("Neuter: unexpected error: %v", err)
+	}
+	return pubKey
+}
+
 // BenchmarkPrivToPub benchmarks how long it takes to convert a private extended
 // key to a public extended key.
 func BenchmarkPrivToPub(b *testing.B) {
@@ -60,6 +100,46 @@ func BenchmarkPrivToPub(b *testing.B) {
 	}
 }
 
+// BenchmarkECPubKey benchmarks how long it takes to convert an extended key
+// to a btcec public key when the conversion has already been memoized.
+func BenchmarkECPubKey(b *testing.B) {
+	b.StopTimer()
+	masterKey, err := hdkeychain.NewKeyFromString(bip0032MasterPriv1)
+	if err != nil {
+		b.Errorf("Failed to decode master seed: %v", err)
+	}
+	masterKey.ECPubKey()
+	b.StartTimer()
+
+	for i := 0; i < b.N; i++ {
+		masterKey.ECPubKey()
+	}
+}
+
+// BenchmarkECPubKeyUncached benchmarks how long it takes to convert an
+// extended key to a btcec public key for the first time, which requires
+// parsing the serialized public key.
+func BenchmarkECPubKeyUncached(b *testing.B) {
+	b.StopTimer()
+	masterKey, err := hdkeychain.NewKeyFromString(bip0032MasterPriv1)
+	if err != nil {
+		b.Errorf("Failed to decode master seed: %v", err)
+	}
+	pubKey, err := masterKey.Neuter()
+	if err != nil {
+		b.Errorf("Failed to neuter master key: %v", err)
+	}
+	serialized := pubKey.String()
+	b.StartTimer()
+
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		key, _ := hdkeychain.NewKeyFromString(serialized)
+		b.StartTimer()
+		key.ECPubKey()
+	}
+}
+
 // BenchmarkDeserialize benchmarks how long it takes to deserialize a private
 // extended key.
 func BenchmarkDeserialize(b *testing.B) {
@@ -16,6 +16,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"hash"
 	"math/big"
 
 	"github.com/btcsuite/btcd/btcec"
@@ -52,6 +53,10 @@ const (
 
 	// maxUint8 is the max positive integer which can be serialized in a uint8
 	maxUint8 = 1<<8 - 1
+
+	// maxUint32 is the max positive integer which can be serialized in a
+	// uint32
+	maxUint32 = 1<<32 - 1
 )
 
 var (
@@ -92,12 +97,54 @@ var (
 	// a serialized extended key does not match the calculated value.
 	ErrBadChecksum = errors.New("bad extended key checksum")
 
+	// ErrInvalidRange describes an error in which the caller attempted to
+	// derive a range of children which extends beyond the largest possible
+	// child index.
+	ErrInvalidRange = errors.New("the range of child indexes is invalid")
+
+	// ErrWrongNetwork describes an error in which the version of a
+	// serialized extended key is not the version of the expected network
+	// for the type of key it contains.
+	ErrWrongNetwork = errors.New("the extended key version does not " +
+		"match the network")
+
+	// ErrZeroedKey describes an error in which the caller attempted to use
+	// an extended key after its key material was cleared with Zero.
+	ErrZeroedKey = errors.New("the extended key has been zeroed")
+
+	// ErrUnknownNetwork describes an error in which the version of an
+	// extended key does not identify any of the known networks.
+	ErrUnknownNetwork = errors.New("the extended key version does not " +
+		"identify a known network")
+
+	// ErrKeyTypeMismatch describes an error in which the version of a
+	// serialized extended key is a known private version while the key
+	// data is a public key, or a known public version while the key data
+	// is a private key.
+	ErrKeyTypeMismatch = errors.New("the extended key version does not " +
+		"match the type of key data")
+
 	// ErrInvalidKeyLen describes an error in which the provided serialized
 	// key is not the expected length.
 	ErrInvalidKeyLen = errors.New("the provided serialized extended key " +
 		"length is invalid")
+
+	// ErrInvalidKey describes an error in which a serialized extended key
+	// has a chain code of all zeros, which can not result from a valid
+	// derivation and would make the derivation of its children trivially
+	// predictable.
+	ErrInvalidKey = errors.New("the extended key chain code is invalid")
 )
 
+// knownNets are the networks an extended key may be associated with by its
+// version when the network is not provided by the caller.
+var knownNets = []*chaincfg.Params{
+	&chaincfg.MainNetParams,
+	&chaincfg.TestNet3Params,
+	&chaincfg.RegressionNetParams,
+	&chaincfg.SimNetParams,
+}
+
 // masterKey is the master key used along with a random seed used to generate
 // the master node in the hierarchical tree.
 var masterKey = []byte("Bitcoin seed")
@@ -114,6 +161,11 @@ type ExtendedKey struct {
 	childNum  uint32
 	version   []byte
 	isPrivate bool
+
+	// ecPubKey and ecPrivKey memoize the keys returned by ECPubKey and
+	// ECPrivKey.
+	ecPubKey  *btcec.PublicKey
+	ecPrivKey *btcec.PrivateKey
 }
 
 // NewExtendedKey returns a new instance of an extended key with the given
@@ -185,6 +237,17 @@ func (k *ExtendedKey) ParentFingerprint() uint32 {
 	return binary.BigEndian.Uint32(k.parentFP)
 }
 
+// Fingerprint returns the fingerprint of the extended key, which is the first
+// four bytes of the hash160 of its public key.  This is the parent
+// fingerprint of each of its children, and identifies a master key in the key
+// origin of an output descriptor.  Zero is returned for a zeroed key.
+func (k *ExtendedKey) Fingerprint() uint32 {
+	if len(k.key) == 0 {
+		return 0
+	}
+	return binary.BigEndian.Uint32(btcutil.Hash160(k.pubKeyBytes())[:4])
+}
+
 // Child returns a derived child extended key at the given index.  When this
 // extended key is a private extended key (as determined by the IsPrivate
 // function), a private extended key will be derived.  Otherwise, the derived
@@ -207,6 +270,11 @@ func (k *ExtendedKey) ParentFingerprint() uint32 {
 // returned if this should occur, and the caller is expected to ignore the
 // invalid child and simply increment to the next index.
 func (k *ExtendedKey) Child(i uint32) (*ExtendedKey, error) {
+	// A zeroed key no longer has the material needed to derive children.
+	if len(k.key) == 0 {
+		return nil, ErrZeroedKey
+	}
+
 	// Prevent derivation of children beyond the max allowed depth.
 	if k.depth == maxUint8 {
 		return nil, ErrDeriveBeyondMaxDepth
@@ -226,6 +294,35 @@ func (k *ExtendedKey) Child(i uint32) (*ExtendedKey, error) {
 		return nil, ErrDeriveHardFromPublic
 	}
 
+	// Convert the serialized compressed parent public key into X and Y
+	// coordinates up front when deriving from a public key since it is
+	// needed to derive the child public key.
+	var parentPubKey *btcec.PublicKey
+	if !k.isPrivate {
+		var err error
+		parentPubKey, err = btcec.ParsePubKey(k.key, btcec.S256())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The fingerprint of the parent for the derived child is the first 4
+	// bytes of the RIPEMD160(SHA256(parentPubKey)).
+	parentFP := btcutil.Hash160(k.pubKeyBytes())[:4]
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	return k.deriveChild(i, mac, parentPubKey, parentFP)
+}
+
+// deriveChild derives the child extended key at index i.  The caller must
+// have already ensured the derivation is allowed.  The passed mac must be an
+// HMAC-SHA512 keyed with the chain code of k, parentPubKey must be the parsed
+// public key of k when k is public, and parentFP must be the fingerprint of
+// k.  Accepting these allows them to be reused when deriving several
+// children of the same parent.
+func (k *ExtendedKey) deriveChild(i uint32, mac hash.Hash, parentPubKey *btcec.PublicKey, parentFP []byte) (*ExtendedKey, error) {
+	isChildHardened := i >= HardenedKeyStart
+
 	// The data used to derive the child key depends on whether or not the
 	// child is hardened per [BIP32].
 	//
@@ -254,9 +351,9 @@ func (k *ExtendedKey) Child(i uint32) (*ExtendedKey, error) {
 	// Take the HMAC-SHA512 of the current key's chain code and the derived
 	// data:
 	//   I = HMAC-SHA512(Key = chainCode, Data = data)
-	hmac512 := hmac.New(sha512.New, k.chainCode)
-	hmac512.Write(data)
-	ilr := hmac512.Sum(nil)
+	mac.Reset()
+	mac.Write(data)
+	ilr := mac.Sum(nil)
 
 	// Split "I" into two 32-byte sequences Il and Ir where:
 	//   Il = intermediate key used to derive the child
@@ -305,83 +402,218 @@ func (k *ExtendedKey) Child(i uint32) (*ExtendedKey, error) {
 			return nil, ErrInvalidChild
 		}
 
-		// Convert the serialized compressed parent public key into X
-		// and Y coordinates so it can be added to the intermediate
-		// public key.
-		pubKey, err := btcec.ParsePubKey(k.key, btcec.S256())
-		if err != nil {
-			return nil, err
-		}
-
 		// Add the intermediate public key to the parent public key to
 		// derive the final child key.
 		//
 		// childKey = serP(point(parse256(Il)) + parentKey)
-		childX, childY := btcec.S256().Add(ilx, ily, pubKey.X, pubKey.Y)
+		childX, childY := btcec.S256().Add(ilx, ily, parentPubKey.X,
+			parentPubKey.Y)
 		pk := btcec.PublicKey{Curve: btcec.S256(), X: childX, Y: childY}
 		childKey = pk.SerializeCompressed()
 	}
 
-	// The fingerprint of the parent for the derived child is the first 4
-	// bytes of the RIPEMD160(SHA256(parentPubKey)).
+	// Each child gets its own copy of the parent fingerprint so zeroing one
+	// child does not affect any others.
+	childParentFP := make([]byte, len(parentFP))
+	copy(childParentFP, parentFP)
+	return NewExtendedKey(k.version, childKey, childChainCode,
+		childParentFP, k.depth+1, i, isPrivate), nil
+}
+
+// DeriveRange derives the count child extended keys of k with the contiguous
+// indexes start through start+count-1.  It is equivalent to calling Child for
+// each index, however the work which only depends on the parent key is only
+// done once, which makes it more efficient when deriving many children such
+// as while scanning for used addresses.
+//
+// The returned slice always has count entries, where the entry at position j
+// is the child at index start+j.  In the extremely unlikely event an index
+// does not derive to a usable child, which Child reports with
+// ErrInvalidChild, the corresponding entry is nil and the caller is expected
+// to skip it.
+//
+// ErrDeriveHardFromPublic is returned if k is a public extended key and any
+// index in the range is a hardened index, and ErrInvalidRange is returned if
+// the range extends beyond the largest possible index.
+func (k *ExtendedKey) DeriveRange(start, count uint32) ([]*ExtendedKey, error) {
+	if len(k.key) == 0 {
+		return nil, ErrZeroedKey
+	}
+	if k.depth == maxUint8 {
+		return nil, ErrDeriveBeyondMaxDepth
+	}
+	if count == 0 {
+		return nil, nil
+	}
+	last := uint64(start) + uint64(count) - 1
+	if last > maxUint32 {
+		return nil, ErrInvalidRange
+	}
+	if !k.isPrivate && last >= HardenedKeyStart {
+		return nil, ErrDeriveHardFromPublic
+	}
+
+	var parentPubKey *btcec.PublicKey
+	if !k.isPrivate {
+		var err error
+		parentPubKey, err = btcec.ParsePubKey(k.key, btcec.S256())
+		if err != nil {
+			return nil, err
+		}
+	}
 	parentFP := btcutil.Hash160(k.pubKeyBytes())[:4]
-	return NewExtendedKey(k.version, childKey, childChainCode, parentFP,
-		k.depth+1, i, isPrivate), nil
+	mac := hmac.New(sha512.New, k.chainCode)
+
+	children := make([]*ExtendedKey, count)
+	for j := uint32(0); j < count; j++ {
+		child, err := k.deriveChild(start+j, mac, parentPubKey, parentFP)
+		if err == ErrInvalidChild {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		children[j] = child
+	}
+	return children, nil
 }
 
-// Neuter returns a new extended public key from this extended private key.  The
-// same extended key will be returned unaltered if it is already an extended
-// public key.
+// Neuter returns a new extended public key from this extended private key.  A
+// copy of the extended key is returned if it is already an extended public
+// key.  In either case, the returned key does not share any memory with this
+// one, so modifying or zeroing either key does not affect the other.
 //
 // As the name implies, an extended public key does not have access to the
 // private key, so it is not capable of signing transactions or deriving
 // child extended private keys.  However, it is capable of deriving further
-// child extended public keys.
+// child extended public keys.  ErrZeroedKey is returned for a zeroed key.
+//
+// NOTE: The public key of an extended private key is calculated with the
+// ScalarBaseMult method of the btcec secp256k1 curve, which is not constant
+// time.  It adds precomputed points selected by each byte of the private key,
+// so the private key may leak through timing or cache side channels to an
+// attacker able to observe the calculation.  The same applies to ECPubKey and
+// Address.
 func (k *ExtendedKey) Neuter() (*ExtendedKey, error) {
-	// Already an extended public key.
-	if !k.isPrivate {
-		return k, nil
+	if len(k.key) == 0 {
+		return nil, ErrZeroedKey
 	}
 
-	// Get the associated public extended key version bytes.
-	version, err := chaincfg.HDPrivateKeyToPublicKeyID(k.version)
-	if err != nil {
-		return nil, err
+	version := k.version
+	if k.isPrivate {
+		// Get the associated public extended key version bytes.
+		var err error
+		version, err = chaincfg.HDPrivateKeyToPublicKeyID(k.version)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Convert it to an extended public key.  The key for the new extended
 	// key will simply be the pubkey of the current extended private key.
 	//
 	// This is the function N((k,c)) -> (K, c) from [BIP32].
-	return NewExtendedKey(version, k.pubKeyBytes(), k.chainCode, k.parentFP,
-		k.depth, k.childNum, false), nil
+	return NewExtendedKey(copyBytes(version), copyBytes(k.pubKeyBytes()),
+		copyBytes(k.chainCode), copyBytes(k.parentFP), k.depth, k.childNum,
+		false), nil
+}
+
+// copyBytes returns a copy of the passed bytes, or nil when there are none.
+func copyBytes(b []byte) []byte {
+	if len(b) == 0 {
+		return nil
+	}
+	return append([]byte(nil), b...)
 }
 
 // ECPubKey converts the extended key to a btcec public key and returns it.
+// The key is memoized so future calls return the same instance without parsing
+// the key again, so callers must not modify it.  ErrZeroedKey is returned for
+// a zeroed key.
+//
+// The key is memoized on the first call, so this method is not safe for
+// concurrent use with other calls on the same extended key.
 func (k *ExtendedKey) ECPubKey() (*btcec.PublicKey, error) {
-	return btcec.ParsePubKey(k.pubKeyBytes(), btcec.S256())
+	if len(k.key) == 0 {
+		return nil, ErrZeroedKey
+	}
+	if k.ecPubKey != nil {
+		return k.ecPubKey, nil
+	}
+
+	pubKey, err := btcec.ParsePubKey(k.pubKeyBytes(), btcec.S256())
+	if err != nil {
+		return nil, err
+	}
+	k.ecPubKey = pubKey
+	return pubKey, nil
 }
 
 // ECPrivKey converts the extended key to a btcec private key and returns it.
 // As you might imagine this is only possible if the extended key is a private
 // extended key (as determined by the IsPrivate function).  The ErrNotPrivExtKey
 // error will be returned if this function is called on a public extended key.
+// Like ECPubKey, the key is memoized and must not be modified by callers, and
+// this method is not safe for concurrent use with other calls on the same
+// extended key.
 func (k *ExtendedKey) ECPrivKey() (*btcec.PrivateKey, error) {
 	if !k.isPrivate {
 		return nil, ErrNotPrivExtKey
 	}
 
-	privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), k.key)
-	return privKey, nil
+	if k.ecPrivKey == nil {
+		k.ecPrivKey, _ = btcec.PrivKeyFromBytes(btcec.S256(), k.key)
+	}
+	return k.ecPrivKey, nil
 }
 
 // Address converts the extended key to a standard bitcoin pay-to-pubkey-hash
 // address for the passed network.
 func (k *ExtendedKey) Address(net *chaincfg.Params) (*btcutil.AddressPubKeyHash, error) {
+	if len(k.key) == 0 {
+		return nil, ErrZeroedKey
+	}
+
 	pkHash := btcutil.Hash160(k.pubKeyBytes())
 	return btcutil.NewAddressPubKeyHash(pkHash, net)
 }
 
+// AddressChecked converts the extended key to a standard bitcoin
+// pay-to-pubkey-hash address for the passed network in the same way as
+// Address, but first ensures the extended key is associated with that
+// network as determined by IsForNet.  ErrWrongNetwork is returned if it is
+// not, rather than producing an address for a network other than the one the
+// key was created for.
+func (k *ExtendedKey) AddressChecked(net *chaincfg.Params) (*btcutil.AddressPubKeyHash, error) {
+	if len(k.key) == 0 {
+		return nil, ErrZeroedKey
+	}
+	if !k.IsForNet(net) {
+		return nil, ErrWrongNetwork
+	}
+	return k.Address(net)
+}
+
+// AddressForNet converts the extended key to a standard bitcoin
+// pay-to-pubkey-hash address for the network identified by the version of
+// the extended key, so the network does not need to be provided as it does
+// with Address.  Testnet3 and the regression test network share the same
+// versions and addresses, so either may be identified for those keys.
+// ErrUnknownNetwork is returned if the version does not identify mainnet,
+// testnet3, the regression test network, or simnet.
+func (k *ExtendedKey) AddressForNet() (*btcutil.AddressPubKeyHash, error) {
+	if len(k.key) == 0 {
+		return nil, ErrZeroedKey
+	}
+
+	for _, net := range knownNets {
+		if k.IsForNet(net) {
+			return k.Address(net)
+		}
+	}
+	return nil, ErrUnknownNetwork
+}
+
 // paddedAppend appends the src byte slice to dst, returning the new slice.
 // If the length of the source is smaller than the passed size, leading zero
 // bytes are appended to the dst slice before appending src.
@@ -398,13 +630,51 @@ func (k *ExtendedKey) String() string {
 		return "zeroed extended key"
 	}
 
+	// Append the checksum to the serialized key.
+	serializedBytes := k.serialize(serializedKeyLen + 4)
+	checkSum := chainhash.DoubleHashB(serializedBytes)[:4]
+	serializedBytes = append(serializedBytes, checkSum...)
+	return base58.Encode(serializedBytes)
+}
+
+// NeuteredString returns the serialization of the extended public key
+// associated with the extended key, which is equivalent to calling String on
+// the key returned by Neuter.  This is convenient for exporting watch-only
+// keys.  The string of an extended public key is returned as is.
+// ErrZeroedKey is returned for a zeroed key, and any error returned by Neuter
+// is returned when the public version of a private key is unknown.
+func (k *ExtendedKey) NeuteredString() (string, error) {
+	if len(k.key) == 0 {
+		return "", ErrZeroedKey
+	}
+	pubKey, err := k.Neuter()
+	if err != nil {
+		return "", err
+	}
+	return pubKey.String(), nil
+}
+
+// Bytes returns the raw 78-byte serialization of the extended key as defined
+// by [BIP32].  This is the same data String encodes, without the checksum and
+// base58 encoding, which makes it suitable for compact binary storage.  Use
+// NewKeyFromBytes to parse it.  Nil is returned for a zeroed key.
+func (k *ExtendedKey) Bytes() []byte {
+	if len(k.key) == 0 {
+		return nil
+	}
+	return k.serialize(serializedKeyLen)
+}
+
+// serialize returns the raw serialization of the extended key in a new slice
+// with the passed capacity.
+func (k *ExtendedKey) serialize(capacity int) []byte {
 	var childNumBytes [4]byte
 	binary.BigEndian.PutUint32(childNumBytes[:], k.childNum)
 
 	// The serialized format is:
 	//   version (4) || depth (1) || parent fingerprint (4)) ||
-	//   child num (4) || chain code (32) || key data (33) || checksum (4)
-	serializedBytes := make([]byte, 0, serializedKeyLen+4)
+	//   child num (4) || chain code (32) || key data (33)
+	serializedBytes := make([]byte, 0, capacity)
 	serializedBytes = append(serializedBytes, k.version...)
 	serializedBytes = append(serializedBytes, k.depth)
 	serializedBytes = append(serializedBytes, k.parentFP...)
@@ -416,10 +686,7 @@ func (k *ExtendedKey) String() string {
 	} else {
 		serializedBytes = append(serializedBytes, k.pubKeyBytes()...)
 	}
-
-	checkSum := chainhash.DoubleHashB(serializedBytes)[:4]
-	serializedBytes = append(serializedBytes, checkSum...)
-	return base58.Encode(serializedBytes)
+	return serializedBytes
 }
 
 // IsForNet returns whether or not the extended key is associated with the
@@ -452,6 +719,13 @@ func zero(b []byte) {
 // used to explicitly clear key material from memory for enhanced security
 // against memory scraping.  This function only clears this particular key and
 // not any children that have already been derived.
+//
+// Any private key previously returned by ECPrivKey shares its scalar with the
+// extended key, so it is wiped as well and must not be used afterwards.
+//
+// Once zeroed, ECPrivKey returns ErrNotPrivExtKey, while Child, Neuter,
+// ECPubKey, Address, NeuteredString and Descriptor return ErrZeroedKey.
+// String returns "zeroed extended key" and Fingerprint returns zero.
 func (k *ExtendedKey) Zero() {
 	zero(k.key)
 	zero(k.pubKey)
@@ -462,6 +736,17 @@ func (k *ExtendedKey) Zero() {
 	k.depth = 0
 	k.childNum = 0
 	k.isPrivate = false
+	k.ecPubKey = nil
+	if k.ecPrivKey != nil {
+		// Setting D to zero only truncates it, so overwrite the words
+		// backing the scalar first.
+		words := k.ecPrivKey.D.Bits()
+		for i := range words {
+			words[i] = 0
+		}
+		k.ecPrivKey.D.SetInt64(0)
+		k.ecPrivKey = nil
+	}
 }
 
 // NewMaster creates a new master node for use in creating a hierarchical
@@ -523,6 +808,40 @@ func NewKeyFromString(key string) (*ExtendedKey, error) {
 		return nil, ErrBadChecksum
 	}
 
+	return parseKey(payload)
+}
+
+// NewKeyFromBytes returns a new extended key instance from the raw 78-byte
+// serialization returned by Bytes.  The version of the serialized key must be
+// the private or public extended key version of the passed network, matching
+// the key data, otherwise ErrWrongNetwork is returned.
+func NewKeyFromBytes(data []byte, net *chaincfg.Params) (*ExtendedKey, error) {
+	if len(data) != serializedKeyLen {
+		return nil, ErrInvalidKeyLen
+	}
+
+	// Copy the data so the returned key does not share memory with the
+	// caller.
+	payload := make([]byte, serializedKeyLen)
+	copy(payload, data)
+	k, err := parseKey(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	wantVersion := net.HDPublicKeyID[:]
+	if k.isPrivate {
+		wantVersion = net.HDPrivateKeyID[:]
+	}
+	if !bytes.Equal(k.version, wantVersion) {
+		return nil, ErrWrongNetwork
+	}
+	return k, nil
+}
+
+// parseKey returns a new extended key instance from the fields of the passed
+// serialized payload, which must be serializedKeyLen bytes.
+func parseKey(payload []byte) (*ExtendedKey, error) {
 	// Deserialize each of the payload fields.
 	version := payload[:4]
 	depth := payload[4:5][0]
@@ -534,9 +853,29 @@ func NewKeyFromString(key string) (*ExtendedKey, error) {
 	// The key data is a private key if it starts with 0x00.  Serialized
 	// compressed pubkeys either start with 0x02 or 0x03.
 	isPrivate := keyData[0] == 0x00
+
+	// Ensure the key data is the type of key identified by the version
+	// when it is a known version.
+	for _, net := range knownNets {
+		wrongVersion := net.HDPrivateKeyID[:]
+		if isPrivate {
+			wrongVersion = net.HDPublicKeyID[:]
+		}
+		if bytes.Equal(version, wrongVersion) {
+			return nil, ErrKeyTypeMismatch
+		}
+	}
+
+	// Ensure the chain code is not all zeros.
+	if bytes.Equal(chainCode, make([]byte, len(chainCode))) {
+		return nil, ErrInvalidKey
+	}
+
 	if isPrivate {
 		// Ensure the private key is valid.  It must be within the range
-		// of the order of the secp256k1 curve and not be 0.
+		// of the order of the secp256k1 curve and not be 0.  This also
+		// rejects key data of all zeros, which would otherwise be the
+		// encoding of the point at infinity.
 		keyData = keyData[1:]
 		keyNum := new(big.Int).SetBytes(keyData)
 		if keyNum.Cmp(btcec.S256().N) >= 0 || keyNum.Sign() == 0 {
@@ -14,9 +14,13 @@ import (
 	"errors"
 	"math"
 	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil/base58"
 )
 
 // TestBIP0032Vectors tests the vectors provided by [BIP32] to ensure the
@@ -554,6 +558,186 @@ func TestGenenerateSeed(t *testing.T) {
 	}
 }
 
+// TestNewMasterSeedLen ensures NewMaster enforces the seed length bounds
+// defined by BIP32.
+func TestNewMasterSeedLen(t *testing.T) {
+	tests := []struct {
+		name   string
+		length int
+		err    error
+	}{
+		{name: "min", length: MinSeedBytes},
+		{name: "recommended", length: RecommendedSeedLen},
+		{name: "max", length: MaxSeedBytes},
+		{name: "empty", length: 0, err: ErrInvalidSeedLen},
+		{name: "below min", length: MinSeedBytes - 1, err: ErrInvalidSeedLen},
+		{name: "above max", length: MaxSeedBytes + 1, err: ErrInvalidSeedLen},
+	}
+
+	net := &chaincfg.MainNetParams
+	for i, test := range tests {
+		seed := bytes.Repeat([]byte{0x01}, test.length)
+		extKey, err := NewMaster(seed, net)
+		if err != test.err {
+			t.Errorf("NewMaster #%d (%s): unexpected error -- "+
+				"want %v, got %v", i, test.name, test.err, err)
+			continue
+		}
+		if test.err == nil && !extKey.IsPrivate() {
+			t.Errorf("NewMaster #%d (%s): master key is not private",
+				i, test.name)
+		}
+	}
+}
+
+// TestDeriveRange ensures DeriveRange derives the same keys as calling Child
+// for each index and rejects invalid ranges.
+func TestDeriveRange(t *testing.T) {
+	master, err := NewKeyFromString("xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2" +
+		"nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk" +
+		"33yuGBxrMPHi")
+	if err != nil {
+		t.Fatalf("NewKeyFromString: unexpected error: %v", err)
+	}
+	pub, err := master.Neuter()
+	if err != nil {
+		t.Fatalf("Neuter: unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		key   *ExtendedKey
+		start uint32
+		count uint32
+		err   error
+	}{
+		{name: "private normal", key: master, start: 0, count: 20},
+		{name: "private hardened", key: master, start: HardenedKeyStart, count: 5},
+		{name: "private spanning", key: master, start: HardenedKeyStart - 2, count: 4},
+		{name: "private last", key: master, start: maxUint32, count: 1},
+		{name: "public normal", key: pub, start: 100, count: 20},
+		{name: "empty", key: master, start: 5, count: 0},
+		{name: "public hardened", key: pub, start: HardenedKeyStart - 2,
+			count: 3, err: ErrDeriveHardFromPublic},
+		{name: "overflow", key: master, start: maxUint32, count: 2,
+			err: ErrInvalidRange},
+	}
+
+	for i, test := range tests {
+		children, err := test.key.DeriveRange(test.start, test.count)
+		if err != test.err {
+			t.Errorf("DeriveRange #%d (%s): mismatched error -- "+
+				"want %v, got %v", i, test.name, test.err, err)
+			continue
+		}
+		if len(children) != int(test.count) && test.err == nil {
+			t.Errorf("DeriveRange #%d (%s): mismatched count -- "+
+				"want %d, got %d", i, test.name, test.count,
+				len(children))
+			continue
+		}
+
+		for j, child := range children {
+			want, err := test.key.Child(test.start + uint32(j))
+			if err != nil {
+				t.Errorf("Child #%d (%s): unexpected error: %v",
+					i, test.name, err)
+				break
+			}
+			if child.String() != want.String() {
+				t.Errorf("DeriveRange #%d (%s): mismatched child "+
+					"%d -- want %s, got %s", i, test.name, j,
+					want, child)
+			}
+		}
+	}
+
+	// Zeroing a derived child must not affect its siblings.
+	children, err := master.DeriveRange(0, 2)
+	if err != nil {
+		t.Fatalf("DeriveRange: unexpected error: %v", err)
+	}
+	children[0].Zero()
+	if children[1].ParentFingerprint() == 0 {
+		t.Errorf("DeriveRange: zeroing a child cleared its sibling")
+	}
+}
+
+// TestKeyBytes ensures the raw serialization of extended keys round trips
+// and invalid serializations are rejected.
+func TestKeyBytes(t *testing.T) {
+	privStr := "xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqji" +
+		"ChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi"
+	priv, err := NewKeyFromString(privStr)
+	if err != nil {
+		t.Fatalf("NewKeyFromString: unexpected error: %v", err)
+	}
+	child, err := priv.Child(HardenedKeyStart + 1)
+	if err != nil {
+		t.Fatalf("Child: unexpected error: %v", err)
+	}
+	pub, err := child.Neuter()
+	if err != nil {
+		t.Fatalf("Neuter: unexpected error: %v", err)
+	}
+
+	net := &chaincfg.MainNetParams
+	for i, key := range []*ExtendedKey{priv, child, pub} {
+		serialized := key.Bytes()
+		if len(serialized) != serializedKeyLen {
+			t.Errorf("Bytes #%d: mismatched length -- got %d, want %d",
+				i, len(serialized), serializedKeyLen)
+			continue
+		}
+
+		parsed, err := NewKeyFromBytes(serialized, net)
+		if err != nil {
+			t.Errorf("NewKeyFromBytes #%d: unexpected error: %v", i, err)
+			continue
+		}
+		if parsed.String() != key.String() {
+			t.Errorf("NewKeyFromBytes #%d: mismatched key -- got %s, "+
+				"want %s", i, parsed, key)
+		}
+		if parsed.IsPrivate() != key.IsPrivate() {
+			t.Errorf("NewKeyFromBytes #%d: mismatched key type", i)
+		}
+
+		// Truncated and extended data is rejected.
+		_, err = NewKeyFromBytes(serialized[:serializedKeyLen-1], net)
+		if err != ErrInvalidKeyLen {
+			t.Errorf("NewKeyFromBytes #%d: truncated data -- got %v, "+
+				"want %v", i, err, ErrInvalidKeyLen)
+		}
+		_, err = NewKeyFromBytes(append(serialized, 0x00), net)
+		if err != ErrInvalidKeyLen {
+			t.Errorf("NewKeyFromBytes #%d: extended data -- got %v, "+
+				"want %v", i, err, ErrInvalidKeyLen)
+		}
+
+		// The key must be for the passed network.
+		_, err = NewKeyFromBytes(serialized, &chaincfg.TestNet3Params)
+		if err != ErrWrongNetwork {
+			t.Errorf("NewKeyFromBytes #%d: wrong network -- got %v, "+
+				"want %v", i, err, ErrWrongNetwork)
+		}
+	}
+
+	// A public key version with private key data is rejected.
+	serialized := priv.Bytes()
+	copy(serialized[:4], net.HDPublicKeyID[:])
+	if _, err := NewKeyFromBytes(serialized, net); err != ErrKeyTypeMismatch {
+		t.Errorf("NewKeyFromBytes: mismatched version -- got %v, want %v",
+			err, ErrKeyTypeMismatch)
+	}
+
+	// A zeroed key has no serialization.
+	priv.Zero()
+	if serialized := priv.Bytes(); serialized != nil {
+		t.Errorf("Bytes: got %x for zeroed key, want nil", serialized)
+	}
+}
+
 // TestExtendedKeyAPI ensures the API on the ExtendedKey type works as intended.
 func TestExtendedKeyAPI(t *testing.T) {
 	tests := []struct {
@@ -796,6 +980,324 @@ func TestNet(t *testing.T) {
 	}
 }
 
+// TestNeuteredDerivation ensures a neutered extended key refuses to derive
+// hardened children while normal children match those derived from the
+// private extended key.
+func TestNeuteredDerivation(t *testing.T) {
+	net := &chaincfg.MainNetParams
+	extKey, err := NewMaster([]byte(`abcd1234abcd1234abcd1234abcd1234`), net)
+	if err != nil {
+		t.Fatalf("NewMaster: unexpected error: %v", err)
+	}
+	pubKey, err := extKey.Neuter()
+	if err != nil {
+		t.Fatalf("Neuter: unexpected error: %v", err)
+	}
+	if !extKey.IsPrivate() || pubKey.IsPrivate() {
+		t.Fatalf("IsPrivate: mismatched key types -- got %v and %v, "+
+			"want true and false", extKey.IsPrivate(), pubKey.IsPrivate())
+	}
+
+	for _, i := range []uint32{HardenedKeyStart, HardenedKeyStart + 1, maxUint32} {
+		child, err := pubKey.Child(i)
+		if err != ErrDeriveHardFromPublic {
+			t.Errorf("Child(%d): mismatched error -- got: %v, want: %v",
+				i, err, ErrDeriveHardFromPublic)
+		}
+		if child != nil {
+			t.Errorf("Child(%d): unexpected child from public key", i)
+		}
+	}
+
+	for _, i := range []uint32{0, 1, HardenedKeyStart - 1} {
+		child, err := pubKey.Child(i)
+		if err != nil {
+			t.Errorf("Child(%d): unexpected error: %v", i, err)
+			continue
+		}
+		privChild, err := extKey.Child(i)
+		if err != nil {
+			t.Errorf("Child(%d): unexpected error: %v", i, err)
+			continue
+		}
+		wantChild, err := privChild.Neuter()
+		if err != nil {
+			t.Errorf("Neuter: unexpected error: %v", err)
+			continue
+		}
+		if child.String() != wantChild.String() {
+			t.Errorf("Child(%d): mismatched public child -- got: %v, "+
+				"want: %v", i, child, wantChild)
+		}
+	}
+}
+
+// TestNeuterCopy ensures Neuter returns a key which is equal to, but does not
+// share any memory with, the key it was called on.
+func TestNeuterCopy(t *testing.T) {
+	extKey, err := NewMaster([]byte(`abcd1234abcd1234abcd1234abcd1234`),
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("NewMaster: unexpected error: %v", err)
+	}
+	child, err := extKey.Child(HardenedKeyStart + 1)
+	if err != nil {
+		t.Fatalf("Child: unexpected error: %v", err)
+	}
+
+	for _, key := range []*ExtendedKey{extKey, child} {
+		privString := key.String()
+		pubKey, err := key.Neuter()
+		if err != nil {
+			t.Fatalf("Neuter: unexpected error: %v", err)
+		}
+		pubString := pubKey.String()
+
+		// Neutering the public key returns an equal but distinct key.
+		pubCopy, err := pubKey.Neuter()
+		if err != nil {
+			t.Fatalf("Neuter: unexpected error: %v", err)
+		}
+		if pubCopy == pubKey {
+			t.Errorf("Neuter: public key was returned rather than a copy")
+		}
+		if pubCopy.String() != pubString || pubCopy.IsPrivate() {
+			t.Errorf("Neuter: mismatched public key copy -- got: %v, "+
+				"want: %v", pubCopy, pubString)
+		}
+
+		// Zeroing either copy leaves the other keys intact.
+		pubCopy.Zero()
+		if got := pubKey.String(); got != pubString {
+			t.Errorf("Neuter: zeroing the copy modified the public key "+
+				"-- got: %v, want: %v", got, pubString)
+		}
+		pubKey.Zero()
+		if got := key.String(); got != privString {
+			t.Errorf("Neuter: zeroing the public key modified the "+
+				"private key -- got: %v, want: %v", got, privString)
+		}
+		pubKey, err = key.Neuter()
+		if err != nil {
+			t.Fatalf("Neuter: unexpected error: %v", err)
+		}
+		if got := pubKey.String(); got != pubString {
+			t.Errorf("Neuter: mismatched public key after zeroing -- "+
+				"got: %v, want: %v", got, pubString)
+		}
+	}
+}
+
+// TestNeuteredString ensures NeuteredString serializes the extended public key
+// associated with both private and public extended keys.
+func TestNeuteredString(t *testing.T) {
+	extKey, err := NewMaster([]byte(`abcd1234abcd1234abcd1234abcd1234`),
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("NewMaster: unexpected error: %v", err)
+	}
+	pubKey, err := extKey.Neuter()
+	if err != nil {
+		t.Fatalf("Neuter: unexpected error: %v", err)
+	}
+
+	for _, key := range []*ExtendedKey{extKey, pubKey} {
+		got, err := key.NeuteredString()
+		if err != nil {
+			t.Errorf("NeuteredString: unexpected error: %v", err)
+			continue
+		}
+		if want := pubKey.String(); got != want {
+			t.Errorf("NeuteredString: mismatched string -- got: %v, "+
+				"want: %v", got, want)
+		}
+		if !strings.HasPrefix(got, "xpub") {
+			t.Errorf("NeuteredString: %v is not an extended public key",
+				got)
+		}
+	}
+
+	// A private key whose version has no public counterpart cannot be
+	// neutered.
+	unknownNet := chaincfg.MainNetParams
+	unknownNet.HDPrivateKeyID = [4]byte{0x01, 0x02, 0x03, 0x04}
+	unknownKey, err := NewMaster(make([]byte, RecommendedSeedLen), &unknownNet)
+	if err != nil {
+		t.Fatalf("NewMaster: unexpected error: %v", err)
+	}
+	if _, err := unknownKey.NeuteredString(); err != chaincfg.ErrUnknownHDKeyID {
+		t.Errorf("NeuteredString: mismatched error -- got: %v, want: %v",
+			err, chaincfg.ErrUnknownHDKeyID)
+	}
+
+	// Zeroed keys are rejected.
+	extKey.Zero()
+	if _, err := extKey.NeuteredString(); err != ErrZeroedKey {
+		t.Errorf("NeuteredString: mismatched error -- got: %v, want: %v",
+			err, ErrZeroedKey)
+	}
+}
+
+// TestAddressForNet ensures the address of an extended key is created for
+// the network identified by its version.
+func TestAddressForNet(t *testing.T) {
+	// Master public key from BIP0032 test vector 1.
+	mainKey, err := NewKeyFromString("xpub661MyMwAqRbcFtXgS5sYJABqqG9YLm" +
+		"C4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDF" +
+		"dp6W1EGMcet8")
+	if err != nil {
+		t.Fatalf("NewKeyFromString: unexpected error: %v", err)
+	}
+	seed := []byte(`abcd1234abcd1234abcd1234abcd1234`)
+	testKey, err := NewMaster(seed, &chaincfg.TestNet3Params)
+	if err != nil {
+		t.Fatalf("NewMaster: unexpected error: %v", err)
+	}
+	testPubKey, err := testKey.Neuter()
+	if err != nil {
+		t.Fatalf("Neuter: unexpected error: %v", err)
+	}
+	simKey, err := NewMaster(seed, &chaincfg.SimNetParams)
+	if err != nil {
+		t.Fatalf("NewMaster: unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		key     *ExtendedKey
+		net     *chaincfg.Params
+		address string
+	}{
+		{"mainnet public", mainKey, &chaincfg.MainNetParams,
+			"15mKKb2eos1hWa6tisdPwwDC1a5J1y9nma"},
+		{"testnet private", testKey, &chaincfg.TestNet3Params, ""},
+		{"testnet public", testPubKey, &chaincfg.TestNet3Params, ""},
+		{"simnet private", simKey, &chaincfg.SimNetParams, ""},
+	}
+	for _, test := range tests {
+		addr, err := test.key.AddressForNet()
+		if err != nil {
+			t.Errorf("AddressForNet (%s): unexpected error: %v",
+				test.name, err)
+			continue
+		}
+		want, err := test.key.Address(test.net)
+		if err != nil {
+			t.Errorf("Address (%s): unexpected error: %v", test.name,
+				err)
+			continue
+		}
+		if addr.EncodeAddress() != want.EncodeAddress() {
+			t.Errorf("AddressForNet (%s): mismatched address -- got "+
+				"%v, want %v", test.name, addr, want)
+		}
+		if test.address != "" && addr.EncodeAddress() != test.address {
+			t.Errorf("AddressForNet (%s): mismatched address -- got "+
+				"%v, want %v", test.name, addr, test.address)
+		}
+		if !addr.IsForNet(test.net) {
+			t.Errorf("AddressForNet (%s): address %v is not for %s",
+				test.name, addr, test.net.Name)
+		}
+	}
+
+	// Testnet addresses begin with m or n.
+	addr, err := testPubKey.AddressForNet()
+	if err != nil {
+		t.Fatalf("AddressForNet: unexpected error: %v", err)
+	}
+	if prefix := addr.EncodeAddress()[0]; prefix != 'm' && prefix != 'n' {
+		t.Errorf("AddressForNet: unexpected testnet address prefix %q",
+			prefix)
+	}
+
+	// A version which identifies no known network is rejected.
+	unknownNet := chaincfg.MainNetParams
+	unknownNet.HDPrivateKeyID = [4]byte{0x01, 0x02, 0x03, 0x04}
+	unknownKey, err := NewMaster(seed, &unknownNet)
+	if err != nil {
+		t.Fatalf("NewMaster: unexpected error: %v", err)
+	}
+	if _, err := unknownKey.AddressForNet(); err != ErrUnknownNetwork {
+		t.Errorf("AddressForNet: mismatched error -- got: %v, want: %v",
+			err, ErrUnknownNetwork)
+	}
+
+	// Zeroed keys are rejected.
+	unknownKey.Zero()
+	if _, err := unknownKey.AddressForNet(); err != ErrZeroedKey {
+		t.Errorf("AddressForNet: mismatched error -- got: %v, want: %v",
+			err, ErrZeroedKey)
+	}
+}
+
+// TestAddressChecked ensures AddressChecked only produces addresses for the
+// network the extended key is associated with.
+func TestAddressChecked(t *testing.T) {
+	mainKey, err := NewKeyFromString("xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8")
+	if err != nil {
+		t.Fatalf("NewKeyFromString: unexpected error: %v", err)
+	}
+	testKey, err := NewMaster(make([]byte, RecommendedSeedLen),
+		&chaincfg.TestNet3Params)
+	if err != nil {
+		t.Fatalf("NewMaster: unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		key  *ExtendedKey
+		net  *chaincfg.Params
+		err  error
+	}{
+		{"mainnet key with mainnet", mainKey, &chaincfg.MainNetParams, nil},
+		{"mainnet key with testnet", mainKey, &chaincfg.TestNet3Params, ErrWrongNetwork},
+		{"mainnet key with simnet", mainKey, &chaincfg.SimNetParams, ErrWrongNetwork},
+		{"testnet key with testnet", testKey, &chaincfg.TestNet3Params, nil},
+		{"testnet key with regtest", testKey, &chaincfg.RegressionNetParams, nil},
+		{"testnet key with mainnet", testKey, &chaincfg.MainNetParams, ErrWrongNetwork},
+	}
+
+	for _, test := range tests {
+		addr, err := test.key.AddressChecked(test.net)
+		if err != test.err {
+			t.Errorf("AddressChecked (%s): mismatched error -- got: %v, "+
+				"want: %v", test.name, err, test.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		// The address must match the one produced by Address.
+		want, err := test.key.Address(test.net)
+		if err != nil {
+			t.Errorf("Address (%s): unexpected error: %v", test.name, err)
+			continue
+		}
+		if addr.EncodeAddress() != want.EncodeAddress() {
+			t.Errorf("AddressChecked (%s): got %v, want %v", test.name,
+				addr, want)
+		}
+	}
+
+	// The BIP0032 test vector 1 master key hashes to a known address.
+	addr, err := mainKey.AddressChecked(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("AddressChecked: unexpected error: %v", err)
+	}
+	if want := "15mKKb2eos1hWa6tisdPwwDC1a5J1y9nma"; addr.EncodeAddress() != want {
+		t.Errorf("AddressChecked: got %v, want %v", addr, want)
+	}
+
+	// Zeroed keys are rejected.
+	testKey.Zero()
+	if _, err := testKey.AddressChecked(&chaincfg.TestNet3Params); err != ErrZeroedKey {
+		t.Errorf("AddressChecked: mismatched error -- got: %v, want: %v",
+			err, ErrZeroedKey)
+	}
+}
+
 // TestErrors performs some negative tests for various invalid cases to ensure
 // the errors are handled properly.
 func TestErrors(t *testing.T) {
@@ -888,6 +1390,211 @@ func TestErrors(t *testing.T) {
 	}
 }
 
+// reencodeKey returns the base58-encoded form of the passed extended key with
+// its version replaced and a checksum calculated over the modified payload.
+func reencodeKey(t *testing.T, key string, version []byte) string {
+	t.Helper()
+
+	decoded := base58.Decode(key)
+	payload := decoded[:len(decoded)-4]
+	copy(payload[:4], version)
+	checkSum := chainhash.DoubleHashB(payload)[:4]
+	return base58.Encode(append(payload, checkSum...))
+}
+
+// replaceKeyBytes returns the base58-encoded form of the passed extended key
+// with the payload bytes starting at offset replaced by data and a checksum
+// calculated over the modified payload.
+func replaceKeyBytes(t *testing.T, key string, offset int, data []byte) string {
+	t.Helper()
+
+	decoded := base58.Decode(key)
+	payload := decoded[:len(decoded)-4]
+	copy(payload[offset:], data)
+	checkSum := chainhash.DoubleHashB(payload)[:4]
+	return base58.Encode(append(payload, checkSum...))
+}
+
+// TestParseKeyValidation ensures NewKeyFromString rejects keys with a bad
+// checksum, a bad length, a zero chain code, invalid key data, or key data
+// which does not match the type of key identified by the version.
+func TestParseKeyValidation(t *testing.T) {
+	// Master keys from BIP0032 test vector 1.
+	xprv := "xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqj" +
+		"iChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi"
+	xpub := "xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY" +
+		"2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8"
+
+	// Corrupt the final character of the key to invalidate the checksum.
+	badChecksum := xprv[:len(xprv)-1] + "j"
+
+	mainNet := &chaincfg.MainNetParams
+	testNet := &chaincfg.TestNet3Params
+	tests := []struct {
+		name    string
+		key     string
+		err     error
+		private bool
+	}{
+		{
+			name:    "valid xprv",
+			key:     xprv,
+			private: true,
+		},
+		{
+			name: "valid xpub",
+			key:  xpub,
+		},
+		{
+			name: "bad checksum",
+			key:  badChecksum,
+			err:  ErrBadChecksum,
+		},
+		{
+			name: "truncated",
+			key:  xprv[:len(xprv)-4],
+			err:  ErrInvalidKeyLen,
+		},
+		{
+			name: "private version with public key data",
+			key:  reencodeKey(t, xpub, mainNet.HDPrivateKeyID[:]),
+			err:  ErrKeyTypeMismatch,
+		},
+		{
+			name: "public version with private key data",
+			key:  reencodeKey(t, xprv, mainNet.HDPublicKeyID[:]),
+			err:  ErrKeyTypeMismatch,
+		},
+		{
+			name: "testnet private version with public key data",
+			key:  reencodeKey(t, xpub, testNet.HDPrivateKeyID[:]),
+			err:  ErrKeyTypeMismatch,
+		},
+		{
+			name:    "testnet private version with private key data",
+			key:     reencodeKey(t, xprv, testNet.HDPrivateKeyID[:]),
+			private: true,
+		},
+		{
+			name: "private key with zero chain code",
+			key:  replaceKeyBytes(t, xprv, 13, make([]byte, 32)),
+			err:  ErrInvalidKey,
+		},
+		{
+			name: "public key with zero chain code",
+			key:  replaceKeyBytes(t, xpub, 13, make([]byte, 32)),
+			err:  ErrInvalidKey,
+		},
+		{
+			name: "zero private key",
+			key:  replaceKeyBytes(t, xprv, 46, make([]byte, 32)),
+			err:  ErrUnusableSeed,
+		},
+		{
+			name: "private key equal to the curve order",
+			key:  replaceKeyBytes(t, xprv, 46, btcec.S256().N.Bytes()),
+			err:  ErrUnusableSeed,
+		},
+		{
+			name: "public key at infinity",
+			key:  replaceKeyBytes(t, xpub, 45, make([]byte, 33)),
+			err:  ErrKeyTypeMismatch,
+		},
+	}
+
+	for i, test := range tests {
+		extKey, err := NewKeyFromString(test.key)
+		if err != test.err {
+			t.Errorf("NewKeyFromString #%d (%s): mismatched error "+
+				"-- got: %v, want: %v", i, test.name, err, test.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if extKey.IsPrivate() != test.private {
+			t.Errorf("IsPrivate #%d (%s): mismatched result -- "+
+				"got: %v, want: %v", i, test.name,
+				extKey.IsPrivate(), test.private)
+		}
+	}
+}
+
+// TestECKeyCache ensures the btcec keys returned by ECPubKey and ECPrivKey are
+// memoized and that zeroing the extended key clears them.
+func TestECKeyCache(t *testing.T) {
+	// Master private key from BIP0032 test vector 1.
+	priv, err := NewKeyFromString("xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2n" +
+		"W2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33y" +
+		"uGBxrMPHi")
+	if err != nil {
+		t.Fatalf("NewKeyFromString: unexpected error: %v", err)
+	}
+	pub, err := priv.Neuter()
+	if err != nil {
+		t.Fatalf("Neuter: unexpected error: %v", err)
+	}
+
+	for i, key := range []*ExtendedKey{priv, pub} {
+		pubKey1, err := key.ECPubKey()
+		if err != nil {
+			t.Fatalf("ECPubKey #%d: unexpected error: %v", i, err)
+		}
+		pubKey2, err := key.ECPubKey()
+		if err != nil {
+			t.Fatalf("ECPubKey #%d: unexpected error: %v", i, err)
+		}
+		if pubKey1 != pubKey2 {
+			t.Errorf("ECPubKey #%d: public key was not memoized", i)
+		}
+		if !bytes.Equal(pubKey1.SerializeCompressed(), key.pubKeyBytes()) {
+			t.Errorf("ECPubKey #%d: mismatched public key", i)
+		}
+	}
+
+	privKey1, err := priv.ECPrivKey()
+	if err != nil {
+		t.Fatalf("ECPrivKey: unexpected error: %v", err)
+	}
+	privKey2, err := priv.ECPrivKey()
+	if err != nil {
+		t.Fatalf("ECPrivKey: unexpected error: %v", err)
+	}
+	if privKey1 != privKey2 {
+		t.Errorf("ECPrivKey: private key was not memoized")
+	}
+	if !bytes.Equal(privKey1.Serialize(), priv.key) {
+		t.Errorf("ECPrivKey: mismatched private key")
+	}
+	pubKey, _ := priv.ECPubKey()
+	if !privKey1.PubKey().IsEqual(pubKey) {
+		t.Errorf("ECPrivKey: private key does not match public key")
+	}
+
+	// Zeroing the key must clear the memoized keys.
+	words := privKey1.D.Bits()
+	priv.Zero()
+	if _, err := priv.ECPrivKey(); err != ErrNotPrivExtKey {
+		t.Errorf("ECPrivKey: mismatched error for zeroed key -- got "+
+			"%v, want %v", err, ErrNotPrivExtKey)
+	}
+	if _, err := priv.ECPubKey(); err == nil {
+		t.Errorf("ECPubKey: expected error for zeroed key")
+	}
+
+	// The memoized private key handed out earlier must be wiped too,
+	// including the words which backed its scalar.
+	if privKey1.D.Sign() != 0 {
+		t.Errorf("Zero: memoized private key scalar was not cleared")
+	}
+	for _, word := range words {
+		if word != 0 {
+			t.Errorf("Zero: memoized private key words were not cleared")
+			break
+		}
+	}
+}
+
 // TestZero ensures that zeroing an extended key works as intended.
 func TestZero(t *testing.T) {
 	tests := []struct {
@@ -950,28 +1657,61 @@ func TestZero(t *testing.T) {
 			return false
 		}
 
-		wantErr = errors.New("pubkey string is empty")
+		if fp := key.Fingerprint(); fp != 0 {
+			t.Errorf("Fingerprint #%d (%s): mismatched fingerprint "+
+				"-- want %d, got %d", i, testName, 0, fp)
+			return false
+		}
+
+		wantErr = ErrZeroedKey
 		_, err = key.ECPubKey()
-		if !reflect.DeepEqual(err, wantErr) {
+		if err != wantErr {
 			t.Errorf("ECPubKey #%d (%s): mismatched error: want "+
 				"%v, got %v", i, testName, wantErr, err)
 			return false
 		}
 
-		wantAddr := "1HT7xU2Ngenf7D4yocz2SAcnNLW7rK8d4E"
-		addr, err := key.Address(&chaincfg.MainNetParams)
-		if err != nil {
-			t.Errorf("Addres s #%d (%s): unexpected error: %v", i,
-				testName, err)
+		_, err = key.Neuter()
+		if err != wantErr {
+			t.Errorf("Neuter #%d (%s): mismatched error: want "+
+				"%v, got %v", i, testName, wantErr, err)
 			return false
 		}
-		if addr.EncodeAddress() != wantAddr {
-			t.Errorf("Address #%d (%s): mismatched address -- want "+
-				"%s, got %s", i, testName, wantAddr,
-				addr.EncodeAddress())
+
+		_, err = key.NeuteredString()
+		if err != wantErr {
+			t.Errorf("NeuteredString #%d (%s): mismatched error: "+
+				"want %v, got %v", i, testName, wantErr, err)
 			return false
 		}
 
+		_, err = key.Address(&chaincfg.MainNetParams)
+		if err != wantErr {
+			t.Errorf("Address #%d (%s): mismatched error: want "+
+				"%v, got %v", i, testName, wantErr, err)
+			return false
+		}
+
+		_, err = key.Child(0)
+		if err != wantErr {
+			t.Errorf("Child #%d (%s): mismatched error: want "+
+				"%v, got %v", i, testName, wantErr, err)
+			return false
+		}
+
+		// The key material itself must be cleared.
+		for _, buf := range [][]byte{key.key, key.pubKey, key.chainCode,
+			key.parentFP} {
+
+			for _, b := range buf {
+				if b != 0 {
+					t.Errorf("Zero #%d (%s): key material "+
+						"not cleared", i, testName)
+					return false
+				}
+			}
+		}
+
 		return true
 	}
 
@@ -0,0 +1,113 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package hdkeychain
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidPath describes an error in which a derivation path could not be
+// parsed since it is not of the form "m/44'/0'/0'".
+var ErrInvalidPath = errors.New("invalid derivation path")
+
+// FormatChildIndex returns the child index formatted as it appears in a
+// derivation path.  Hardened indexes, which are those greater than or equal to
+// HardenedKeyStart, are formatted without the hardened bit followed by an
+// apostrophe, so for example both 0 and HardenedKeyStart are formatted as "0"
+// and "0'" respectively.
+func FormatChildIndex(i uint32) string {
+	if i >= HardenedKeyStart {
+		return strconv.FormatUint(uint64(i-HardenedKeyStart), 10) + "'"
+	}
+	return strconv.FormatUint(uint64(i), 10)
+}
+
+// FormatPath returns the derivation path from the master key through each of
+// the passed child indexes, such as "m/44'/0'/0'".  An empty path is formatted
+// as "m", which refers to the master key itself.
+func FormatPath(indices []uint32) string {
+	var b strings.Builder
+	b.WriteString("m")
+	for _, i := range indices {
+		b.WriteString("/")
+		b.WriteString(FormatChildIndex(i))
+	}
+	return b.String()
+}
+
+// ParsePath returns the child indexes of a derivation path formatted by
+// FormatPath.  Hardened indexes may be marked with either an apostrophe or the
+// letter h, such as "m/44h/0h/0h".  ErrInvalidPath is returned if the path
+// does not begin with "m" or any of its indexes are malformed or out of range.
+func ParsePath(path string) ([]uint32, error) {
+	parts := strings.Split(path, "/")
+	if parts[0] != "m" {
+		return nil, ErrInvalidPath
+	}
+
+	indices := make([]uint32, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		var hardened uint32
+		if n := len(part); n > 0 && (part[n-1] == '\'' ||
+			part[n-1] == 'h' || part[n-1] == 'H') {
+
+			hardened = HardenedKeyStart
+			part = part[:n-1]
+		}
+
+		// Only unadorned decimal digits are allowed, and the index must
+		// be less than HardenedKeyStart before the hardened bit is set.
+		if part == "" || part[0] < '0' || part[0] > '9' {
+			return nil, ErrInvalidPath
+		}
+		i, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || i >= HardenedKeyStart {
+			return nil, ErrInvalidPath
+		}
+		indices = append(indices, uint32(i)+hardened)
+	}
+	return indices, nil
+}
+
+// Descriptor returns an output descriptor key expression for the receiving
+// addresses of the account key derived from the extended key along the passed
+// derivation path, such as "[3442193e/44h/0h/0h]xpub.../0/*".  The extended
+// key is normally the master key, whose fingerprint and the path form the key
+// origin, and the account key is always given as an extended public key so
+// the descriptor may be imported into a watch-only wallet.
+//
+// ErrZeroedKey is returned for a zeroed key and ErrInvalidPath for a path
+// which can not be parsed by ParsePath.  Any error deriving the account key,
+// such as ErrDeriveHardFromPublic, is also returned.
+func (k *ExtendedKey) Descriptor(path string) (string, error) {
+	if len(k.key) == 0 {
+		return "", ErrZeroedKey
+	}
+	indices, err := ParsePath(path)
+	if err != nil {
+		return "", err
+	}
+
+	account := k
+	for _, i := range indices {
+		account, err = account.Child(i)
+		if err != nil {
+			return "", err
+		}
+	}
+	accountPub, err := account.NeuteredString()
+	if err != nil {
+		return "", err
+	}
+
+	// Descriptors conventionally mark hardened indexes with the letter h
+	// rather than an apostrophe, and the key origin omits the leading m.
+	origin := strings.Replace(FormatPath(indices)[1:], "'", "h", -1)
+	return fmt.Sprintf("[%08x%s]%s/0/*", k.Fingerprint(), origin,
+		accountPub), nil
+}
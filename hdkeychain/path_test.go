@@ -0,0 +1,198 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package hdkeychain_test
+
+import (
+	"encoding/hex"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
+)
+
+// TestFormatChildIndex ensures child indexes are formatted with an apostrophe
+// marking hardened indexes.
+func TestFormatChildIndex(t *testing.T) {
+	tests := []struct {
+		index uint32
+		want  string
+	}{
+		{0, "0"},
+		{1, "1"},
+		{44, "44"},
+		{hdkeychain.HardenedKeyStart - 1, "2147483647"},
+		{hdkeychain.HardenedKeyStart, "0'"},
+		{hdkeychain.HardenedKeyStart + 44, "44'"},
+		{math.MaxUint32, "2147483647'"},
+	}
+
+	for i, test := range tests {
+		if got := hdkeychain.FormatChildIndex(test.index); got != test.want {
+			t.Errorf("FormatChildIndex #%d (%d): got %s, want %s", i,
+				test.index, got, test.want)
+		}
+	}
+}
+
+// TestPath ensures derivation paths are formatted as expected and that parsing
+// a formatted path returns the original indexes.
+func TestPath(t *testing.T) {
+	const h = hdkeychain.HardenedKeyStart
+	tests := []struct {
+		name    string
+		indices []uint32
+		path    string
+	}{
+		{"master", []uint32{}, "m"},
+		{"normal", []uint32{0}, "m/0"},
+		{"bip44 account", []uint32{h + 44, h + 0, h + 0}, "m/44'/0'/0'"},
+		{"bip44 address", []uint32{h + 44, h + 0, h + 0, 0, 5}, "m/44'/0'/0'/0/5"},
+		{"bip32 vector", []uint32{h + 0, 1, h + 2, 2, 1000000000}, "m/0'/1/2'/2/1000000000"},
+		{"largest normal", []uint32{h - 1}, "m/2147483647"},
+		{"largest hardened", []uint32{math.MaxUint32}, "m/2147483647'"},
+	}
+
+	for _, test := range tests {
+		path := hdkeychain.FormatPath(test.indices)
+		if path != test.path {
+			t.Errorf("FormatPath (%s): got %s, want %s", test.name,
+				path, test.path)
+			continue
+		}
+		indices, err := hdkeychain.ParsePath(path)
+		if err != nil {
+			t.Errorf("ParsePath (%s): unexpected error: %v", test.name,
+				err)
+			continue
+		}
+		if !reflect.DeepEqual(indices, test.indices) {
+			t.Errorf("ParsePath (%s): got %v, want %v", test.name,
+				indices, test.indices)
+		}
+	}
+
+	// The letter h may also be used to mark hardened indexes.
+	indices, err := hdkeychain.ParsePath("m/44h/0H/0'")
+	if err != nil {
+		t.Fatalf("ParsePath: unexpected error: %v", err)
+	}
+	if want := []uint32{h + 44, h, h}; !reflect.DeepEqual(indices, want) {
+		t.Errorf("ParsePath: got %v, want %v", indices, want)
+	}
+
+	invalidPaths := []string{
+		"",
+		"/0",
+		"M/0",
+		"n/0",
+		"m/",
+		"m//0",
+		"m/0/",
+		"m/'",
+		"m/0''",
+		"m/-1",
+		"m/+1",
+		"m/0x10",
+		"m/1a",
+		"m/2147483648",
+		"m/2147483648'",
+		"m/4294967296",
+	}
+	for _, path := range invalidPaths {
+		if _, err := hdkeychain.ParsePath(path); err != hdkeychain.ErrInvalidPath {
+			t.Errorf("ParsePath (%q): got error %v, want %v", path, err,
+				hdkeychain.ErrInvalidPath)
+		}
+	}
+}
+
+// TestDescriptor ensures the descriptor key expression of an account key is
+// formatted with the fingerprint of the origin key and the derivation path,
+// using the keys of the BIP0032 test vector 1.
+func TestDescriptor(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("NewMaster: unexpected error: %v", err)
+	}
+	if fp := master.Fingerprint(); fp != 0x3442193e {
+		t.Fatalf("Fingerprint: got %08x, want 3442193e", fp)
+	}
+	pubMaster, err := master.Neuter()
+	if err != nil {
+		t.Fatalf("Neuter: unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		key  *hdkeychain.ExtendedKey
+		path string
+		want string
+		err  error
+	}{
+		{
+			name: "master",
+			key:  master,
+			path: "m",
+			want: "[3442193e]xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8/0/*",
+		},
+		{
+			name: "hardened child",
+			key:  master,
+			path: "m/0'",
+			want: "[3442193e/0h]xpub68Gmy5EdvgibQVfPdqkBBCHxA5htiqg55crXYuXoQRKfDBFA1WEjWgP6LHhwBZeNK1VTsfTFUHCdrfp1bgwQ9xv5ski8PX9rL2dZXvgGDnw/0/*",
+		},
+		{
+			name: "path with h and normal child",
+			key:  master,
+			path: "m/0h/1",
+			want: "[3442193e/0h/1]xpub6ASuArnXKPbfEwhqN6e3mwBcDTgzisQN1wXN9BJcM47sSikHjJf3UFHKkNAWbWMiGj7Wf5uMash7SyYq527Hqck2AxYysAA7xmALppuCkwQ/0/*",
+		},
+		{
+			name: "invalid path",
+			key:  master,
+			path: "44'/0'/0'",
+			err:  hdkeychain.ErrInvalidPath,
+		},
+		{
+			name: "hardened child of public key",
+			key:  pubMaster,
+			path: "m/0'",
+			err:  hdkeychain.ErrDeriveHardFromPublic,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := test.key.Descriptor(test.path)
+		if err != test.err {
+			t.Errorf("Descriptor (%s): got error %v, want %v", test.name,
+				err, test.err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("Descriptor (%s): got %q, want %q", test.name, got,
+				test.want)
+		}
+	}
+
+	// The fingerprint of a key is the parent fingerprint of its children.
+	child, err := master.Child(hdkeychain.HardenedKeyStart)
+	if err != nil {
+		t.Fatalf("Child: unexpected error: %v", err)
+	}
+	if child.ParentFingerprint() != master.Fingerprint() {
+		t.Errorf("ParentFingerprint: got %08x, want %08x",
+			child.ParentFingerprint(), master.Fingerprint())
+	}
+
+	// Zeroed keys are rejected.
+	master.Zero()
+	if _, err := master.Descriptor("m/0'"); err != hdkeychain.ErrZeroedKey {
+		t.Errorf("Descriptor: got error %v, want %v", err,
+			hdkeychain.ErrZeroedKey)
+	}
+}
@@ -13,6 +13,7 @@ package btcutil
 
 import (
 	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcutil/base58"
 	"github.com/btcsuite/btcutil/bech32"
 	"golang.org/x/crypto/ripemd160"
@@ -25,6 +26,16 @@ func (b *Block) SetBlockBytes(buf []byte) {
 	b.serializedBlock = buf
 }
 
+// TstRestoreWIFNets returns a function which restores the networks known to
+// DecodeWIF to those known when it was called, undoing any later calls to
+// RegisterWIFNet.  It is only available to the test package.
+func TstRestoreWIFNets() func() {
+	saved := append([]*chaincfg.Params(nil), wifNets...)
+	return func() {
+		wifNets = saved
+	}
+}
+
 // TstAppDataDir makes the internal appDataDir function available to the test
 // package.
 func TstAppDataDir(goos, appName string, roaming bool) string {
@@ -0,0 +1,221 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcutil
+
+import (
+	"errors"
+
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// These are the opcodes used by the standard output scripts.  They are
+// defined here rather than using the txscript package since it depends on
+// this package.
+const (
+	op0             = 0x00
+	opData20        = 0x14
+	opData32        = 0x20
+	opData33        = 0x21
+	opData65        = 0x41
+	op1             = 0x51
+	op16            = 0x60
+	opDup           = 0x76
+	opEqual         = 0x87
+	opEqualVerify   = 0x88
+	opHash160       = 0xa9
+	opCheckSig      = 0xac
+	opCheckMultiSig = 0xae
+)
+
+// maxMultiSigPubKeys is the maximum number of public keys in a standard bare
+// multisig script, which is limited by the small integer opcode used to
+// encode the number of keys.
+const maxMultiSigPubKeys = 16
+
+// ErrUnsupportedAddress describes an error where an address is not one of
+// the concrete address types provided by this package, so the script it
+// should be paid to is not known.
+var ErrUnsupportedAddress = errors.New("unsupported address type")
+
+// PayToAddrScript creates a new script to pay a transaction output to the
+// passed address.  ErrUnsupportedAddress is returned for nil addresses and
+// address types which are not provided by this package.
+func PayToAddrScript(addr Address) ([]byte, error) {
+	switch addr := addr.(type) {
+	case *AddressPubKeyHash:
+		if addr == nil {
+			return nil, ErrUnsupportedAddress
+		}
+		// OP_DUP OP_HASH160 <hash> OP_EQUALVERIFY OP_CHECKSIG
+		script := make([]byte, 0, 25)
+		script = append(script, opDup, opHash160)
+		script = appendPush(script, addr.ScriptAddress())
+		return append(script, opEqualVerify, opCheckSig), nil
+
+	case *AddressScriptHash:
+		if addr == nil {
+			return nil, ErrUnsupportedAddress
+		}
+		// OP_HASH160 <hash> OP_EQUAL
+		script := make([]byte, 0, 23)
+		script = append(script, opHash160)
+		script = appendPush(script, addr.ScriptAddress())
+		return append(script, opEqual), nil
+
+	case *AddressPubKey:
+		if addr == nil {
+			return nil, ErrUnsupportedAddress
+		}
+		// <pubkey> OP_CHECKSIG
+		pubKey := addr.ScriptAddress()
+		script := make([]byte, 0, len(pubKey)+2)
+		script = appendPush(script, pubKey)
+		return append(script, opCheckSig), nil
+
+	case *AddressWitnessPubKeyHash:
+		if addr == nil {
+			return nil, ErrUnsupportedAddress
+		}
+		// OP_0 <20-byte hash>
+		return appendPush([]byte{op0}, addr.WitnessProgram()), nil
+
+	case *AddressWitnessScriptHash:
+		if addr == nil {
+			return nil, ErrUnsupportedAddress
+		}
+		// OP_0 <32-byte hash>
+		return appendPush([]byte{op0}, addr.WitnessProgram()), nil
+	}
+
+	return nil, ErrUnsupportedAddress
+}
+
+// appendPush appends a canonical push of data to the passed script.  It only
+// supports data which can be pushed with a single opcode indicating its
+// length, which is the case for all hashes and public keys.
+func appendPush(script, data []byte) []byte {
+	script = append(script, byte(len(data)))
+	return append(script, data...)
+}
+
+// extractScriptAddrs returns the addresses paid to by a standard
+// pay-to-pubkey-hash, pay-to-script-hash, pay-to-pubkey, bare multisig,
+// pay-to-witness-pubkey-hash, or pay-to-witness-script-hash output script for
+// the passed network.  No addresses are returned for any other script.  The
+// public keys of pay-to-pubkey and multisig scripts which are not valid are
+// skipped, while any other failure to create an address is returned.
+func extractScriptAddrs(script []byte, net *chaincfg.Params) ([]Address, error) {
+	switch {
+	// OP_DUP OP_HASH160 <20-byte hash> OP_EQUALVERIFY OP_CHECKSIG
+	case len(script) == 25 && script[0] == opDup && script[1] == opHash160 &&
+		script[2] == opData20 && script[23] == opEqualVerify &&
+		script[24] == opCheckSig:
+
+		addr, err := NewAddressPubKeyHash(script[3:23], net)
+		if err != nil {
+			return nil, err
+		}
+		return []Address{addr}, nil
+
+	// OP_HASH160 <20-byte hash> OP_EQUAL
+	case len(script) == 23 && script[0] == opHash160 &&
+		script[1] == opData20 && script[22] == opEqual:
+
+		addr, err := NewAddressScriptHashFromHash(script[2:22], net)
+		if err != nil {
+			return nil, err
+		}
+		return []Address{addr}, nil
+
+	// OP_0 <20-byte hash>
+	case len(script) == 22 && script[0] == op0 && script[1] == opData20:
+		addr, err := NewAddressWitnessPubKeyHash(script[2:], net)
+		if err != nil {
+			return nil, err
+		}
+		return []Address{addr}, nil
+
+	// OP_0 <32-byte hash>
+	case len(script) == 34 && script[0] == op0 && script[1] == opData32:
+		addr, err := NewAddressWitnessScriptHash(script[2:], net)
+		if err != nil {
+			return nil, err
+		}
+		return []Address{addr}, nil
+	}
+
+	// <pubkey> OP_CHECKSIG
+	if pubKey, rest := parsePubKeyPush(script); pubKey != nil &&
+		len(rest) == 1 && rest[0] == opCheckSig {
+
+		addr, err := NewAddressPubKey(pubKey, net)
+		if err != nil {
+			return nil, nil
+		}
+		return []Address{addr}, nil
+	}
+
+	return extractMultiSigAddrs(script, net), nil
+}
+
+// extractMultiSigAddrs returns the addresses for the public keys of a standard
+// bare multisig script of the form
+// OP_<m> <pubkey>... OP_<n> OP_CHECKMULTISIG, or nil if the script is not of
+// that form.
+func extractMultiSigAddrs(script []byte, net *chaincfg.Params) []Address {
+	if len(script) < 3 || script[0] < op1 || script[0] > op16 ||
+		script[len(script)-1] != opCheckMultiSig {
+		return nil
+	}
+	numRequired := int(script[0]-op1) + 1
+
+	var pubKeys [][]byte
+	rest := script[1 : len(script)-1]
+	for len(pubKeys) < maxMultiSigPubKeys {
+		pubKey, remaining := parsePubKeyPush(rest)
+		if pubKey == nil {
+			break
+		}
+		pubKeys = append(pubKeys, pubKey)
+		rest = remaining
+	}
+	if len(rest) != 1 || rest[0] < op1 || rest[0] > op16 ||
+		int(rest[0]-op1)+1 != len(pubKeys) || numRequired > len(pubKeys) {
+		return nil
+	}
+
+	addrs := make([]Address, 0, len(pubKeys))
+	for _, pubKey := range pubKeys {
+		addr, err := NewAddressPubKey(pubKey, net)
+		if err != nil {
+			continue
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
+// parsePubKeyPush returns the data of a push of a compressed or uncompressed
+// public key sized item at the start of the script and the remainder of the
+// script.  A nil public key is returned if the script does not start with such
+// a push.
+func parsePubKeyPush(script []byte) (pubKey, rest []byte) {
+	if len(script) == 0 {
+		return nil, script
+	}
+	var n int
+	switch script[0] {
+	case opData33:
+		n = 33
+	case opData65:
+		n = 65
+	default:
+		return nil, script
+	}
+	if len(script) < n+1 {
+		return nil, script
+	}
+	return script[1 : n+1], script[n+1:]
+}
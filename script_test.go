@@ -0,0 +1,121 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcutil_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+)
+
+// unsupportedAddress is an Address implementation which is not provided by
+// the btcutil package.
+type unsupportedAddress struct{}
+
+func (unsupportedAddress) String() string                 { return "" }
+func (unsupportedAddress) EncodeAddress() string          { return "" }
+func (unsupportedAddress) ScriptAddress() []byte          { return nil }
+func (unsupportedAddress) IsForNet(*chaincfg.Params) bool { return false }
+
+// TestPayToAddrScript ensures PayToAddrScript creates the expected script for
+// each of the address types.
+func TestPayToAddrScript(t *testing.T) {
+	tests := []struct {
+		name   string
+		addr   string
+		script string
+	}{
+		{
+			name:   "p2pkh",
+			addr:   "1MirQ9bwyQcGVJPwKUgapu5ouK2E2Ey4gX",
+			script: "76a914e34cce70c86373273efcc54ce7d2a491bb4a0e8488ac",
+		},
+		{
+			name:   "p2sh",
+			addr:   "3QJmV3qfvL9SuYo34YihAf3sRCW3qSinyC",
+			script: "a914f815b036d9bbbce5e9f2a00abd1bf3dc91e9551087",
+		},
+		{
+			name:   "p2pk compressed",
+			addr:   "02192d74d0cb94344c9569c2e77901573d8d7903c3ebec3a957724895dca52c6b4",
+			script: "2102192d74d0cb94344c9569c2e77901573d8d7903c3ebec3a957724895dca52c6b4ac",
+		},
+		{
+			name: "p2pk uncompressed",
+			addr: "0411db93e1dcdb8a016b49840f8c53bc1eb68a382e97b1482ecad7b148a" +
+				"6909a5cb2e0eaddfb84ccf9744464f82e160bfa9b8b64f9d4c03f999b864" +
+				"3f656b412a3",
+			script: "410411db93e1dcdb8a016b49840f8c53bc1eb68a382e97b1482ecad7b1" +
+				"48a6909a5cb2e0eaddfb84ccf9744464f82e160bfa9b8b64f9d4c03f999" +
+				"b8643f656b412a3ac",
+		},
+		{
+			name:   "p2wpkh",
+			addr:   "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+			script: "0014751e76e8199196d454941c45d1b3a323f1433bd6",
+		},
+		{
+			name:   "p2wsh",
+			addr:   "bc1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3qccfmv3",
+			script: "00201863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262",
+		},
+	}
+
+	for _, test := range tests {
+		addr, err := btcutil.DecodeAddress(test.addr, &chaincfg.MainNetParams)
+		if err != nil {
+			t.Errorf("%s: DecodeAddress: unexpected error: %v",
+				test.name, err)
+			continue
+		}
+		want, _ := hex.DecodeString(test.script)
+
+		script, err := btcutil.PayToAddrScript(addr)
+		if err != nil {
+			t.Errorf("%s: PayToAddrScript: unexpected error: %v",
+				test.name, err)
+			continue
+		}
+		if !bytes.Equal(script, want) {
+			t.Errorf("%s: PayToAddrScript: mismatched script - got %x, "+
+				"want %x", test.name, script, want)
+			continue
+		}
+
+		// The script must match the one created by txscript.
+		txScript, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			t.Errorf("%s: txscript.PayToAddrScript: unexpected "+
+				"error: %v", test.name, err)
+			continue
+		}
+		if !bytes.Equal(script, txScript) {
+			t.Errorf("%s: PayToAddrScript: script %x does not match "+
+				"txscript %x", test.name, script, txScript)
+		}
+	}
+
+	// Unsupported and nil addresses are rejected.
+	unsupported := []btcutil.Address{
+		nil,
+		unsupportedAddress{},
+		(*btcutil.AddressPubKeyHash)(nil),
+		(*btcutil.AddressScriptHash)(nil),
+		(*btcutil.AddressPubKey)(nil),
+		(*btcutil.AddressWitnessPubKeyHash)(nil),
+		(*btcutil.AddressWitnessScriptHash)(nil),
+	}
+	for i, addr := range unsupported {
+		_, err := btcutil.PayToAddrScript(addr)
+		if err != btcutil.ErrUnsupportedAddress {
+			t.Errorf("PayToAddrScript #%d: got error %v, want %v", i,
+				err, btcutil.ErrUnsupportedAddress)
+		}
+	}
+}
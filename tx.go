@@ -6,8 +6,13 @@ package btcutil
 
 import (
 	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
 	"io"
+	"math"
 
+	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
 )
@@ -17,16 +22,36 @@ import (
 // yet.
 const TxIndexUnknown = -1
 
+// ErrMissingPrevOut describes an error in which the value of an output spent
+// by a transaction could not be found.
+var ErrMissingPrevOut = errors.New("previous output not found")
+
+// ErrMalformedScript describes an error in which a script passed to
+// SignatureHashLegacy ends within an opcode or the data it pushes.
+var ErrMalformedScript = errors.New("script ends within an opcode")
+
+// ErrNoNetwork describes an error in which addresses could not be created
+// because no network was provided.
+var ErrNoNetwork = errors.New("no network")
+
+// zeroHash is the zero value for a chainhash.Hash and is defined as a package
+// level variable to avoid the need to create a new instance every time a
+// check is needed.
+var zeroHash chainhash.Hash
+
 // Tx defines a bitcoin transaction that provides easier and more efficient
 // manipulation of raw transactions.  It also memoizes the hash for the
 // transaction on its first access so subsequent accesses don't have to repeat
 // the relatively expensive hashing operations.
 type Tx struct {
-	msgTx         *wire.MsgTx     // Underlying MsgTx
-	txHash        *chainhash.Hash // Cached transaction hash
-	txHashWitness *chainhash.Hash // Cached transaction witness hash
-	txHasWitness  *bool           // If the transaction has witness data
-	txIndex       int             // Position within a block or TxIndexUnknown
+	msgTx                 *wire.MsgTx     // Underlying MsgTx
+	txHash                *chainhash.Hash // Cached transaction hash
+	txHashWitness         *chainhash.Hash // Cached transaction witness hash
+	txHasWitness          *bool           // If the transaction has witness data
+	txIndex               int             // Position within a block or TxIndexUnknown
+	serializeSize         int             // Cached serialized size or 0 if unknown
+	serializedTx          []byte          // Serialized bytes for the transaction
+	serializedTxNoWitness []byte          // Serialized bytes for transaction w/o witness data
 }
 
 // MsgTx returns the underlying wire.MsgTx for the transaction.
@@ -79,17 +104,363 @@ func (t *Tx) HasWitness() bool {
 	return hasWitness
 }
 
+// SerializeSize returns the number of bytes it would take to serialize the
+// transaction.  This is equivalent to calling SerializeSize on the underlying
+// wire.MsgTx, however it caches the result so subsequent calls are more
+// efficient.
+func (t *Tx) SerializeSize() int {
+	// Return the cached size if it has already been calculated.
+	if t.serializeSize != 0 {
+		return t.serializeSize
+	}
+
+	// Cache the size and return it.
+	t.serializeSize = t.msgTx.SerializeSize()
+	return t.serializeSize
+}
+
+// Bytes returns the serialized bytes for the transaction, including any
+// witness data.  This is equivalent to calling Serialize on the underlying
+// wire.MsgTx, however it caches the result so subsequent calls are more
+// efficient.
+func (t *Tx) Bytes() ([]byte, error) {
+	// Return the cached serialized bytes if it has already been generated.
+	if len(t.serializedTx) != 0 {
+		return t.serializedTx, nil
+	}
+
+	// Serialize the MsgTx.
+	w := bytes.NewBuffer(make([]byte, 0, t.msgTx.SerializeSize()))
+	err := t.msgTx.Serialize(w)
+	if err != nil {
+		return nil, err
+	}
+	serializedTx := w.Bytes()
+
+	// Cache the serialized bytes and return them.
+	t.serializedTx = serializedTx
+	return serializedTx, nil
+}
+
+// BytesNoWitness returns the serialized bytes for the transaction encoded
+// without any witness data, which is the serialization used to calculate the
+// transaction hash and legacy signature hashes.  For a transaction without
+// witness data, this is identical to the result of Bytes.  It is cached
+// separately from Bytes so subsequent calls are more efficient.
+func (t *Tx) BytesNoWitness() ([]byte, error) {
+	// Return the cached serialized bytes if it has already been generated.
+	if len(t.serializedTxNoWitness) != 0 {
+		return t.serializedTxNoWitness, nil
+	}
+
+	// Serialize the MsgTx.
+	w := bytes.NewBuffer(make([]byte, 0, t.msgTx.SerializeSizeStripped()))
+	err := t.msgTx.SerializeNoWitness(w)
+	if err != nil {
+		return nil, err
+	}
+	serializedTx := w.Bytes()
+
+	// Cache the serialized bytes and return them.
+	t.serializedTxNoWitness = serializedTx
+	return serializedTx, nil
+}
+
+// FeeRate returns the fee rate, in satoshis per byte rounded to the nearest
+// satoshi, of a transaction of size bytes which pays the given fee.  Zero is
+// returned if size is not positive.
+func FeeRate(fee Amount, size int) Amount {
+	if size <= 0 {
+		return 0
+	}
+
+	// Round half away from zero.
+	n := Amount(size)
+	rate, rem := fee/n, fee%n
+	if rem < 0 {
+		rem = -rem
+	}
+	if 2*rem >= n {
+		if fee < 0 {
+			rate--
+		} else {
+			rate++
+		}
+	}
+	return rate
+}
+
+// IsCoinBase returns whether or not the transaction is a coinbase.  A coinbase
+// is a special transaction created by miners that has no inputs.  This is
+// represented in the block chain by a transaction with a single input that has
+// a previous output transaction index set to the maximum value along with a
+// zero hash.
+func (t *Tx) IsCoinBase() bool {
+	// A coin base must only have one transaction input.
+	if len(t.msgTx.TxIn) != 1 {
+		return false
+	}
+
+	// The previous output of a coin base must have a max value index and
+	// a zero hash.
+	prevOut := &t.msgTx.TxIn[0].PreviousOutPoint
+	if prevOut.Index != wire.MaxPrevOutIndex || prevOut.Hash != zeroHash {
+		return false
+	}
+
+	return true
+}
+
+// NumInputs returns the number of inputs of the transaction.
+func (t *Tx) NumInputs() int {
+	return len(t.msgTx.TxIn)
+}
+
+// NumOutputs returns the number of outputs of the transaction.
+func (t *Tx) NumOutputs() int {
+	return len(t.msgTx.TxOut)
+}
+
+// TotalOutputValue returns the total value of the outputs of the transaction.
+// The values of the outputs of a transaction which has not been validated
+// may be arbitrary, so math.MaxInt64 or math.MinInt64 is returned as soon as
+// the total would overflow rather than wrapping around.  Callers should check
+// the total with IsValid before relying on it.
+func (t *Tx) TotalOutputValue() Amount {
+	var total int64
+	for _, txOut := range t.msgTx.TxOut {
+		v := txOut.Value
+		switch {
+		case v > 0 && total > math.MaxInt64-v:
+			return math.MaxInt64
+		case v < 0 && total < math.MinInt64-v:
+			return math.MinInt64
+		}
+		total += v
+	}
+	return Amount(total)
+}
+
+// Fee returns the fee paid by the transaction, which is the total value of
+// the outputs it spends less the total value of its outputs.  The value of
+// each spent output is found by calling prevOut with the outpoint referenced
+// by the input, which must report false when the output is unknown, in which
+// case ErrMissingPrevOut is returned.  The input of a coinbase transaction
+// does not spend an output and contributes no value, so prevOut is not called
+// and the returned fee is the negated total output value.
+func (t *Tx) Fee(prevOut func(wire.OutPoint) (Amount, bool)) (Amount, error) {
+	var inputValue Amount
+	if !t.IsCoinBase() {
+		for _, txIn := range t.msgTx.TxIn {
+			value, ok := prevOut(txIn.PreviousOutPoint)
+			if !ok {
+				return 0, ErrMissingPrevOut
+			}
+			inputValue += value
+		}
+	}
+
+	var outputValue Amount
+	for _, txOut := range t.msgTx.TxOut {
+		outputValue += Amount(txOut.Value)
+	}
+	return inputValue - outputValue, nil
+}
+
+// OutputAddresses returns the addresses paid to by each output of the
+// transaction for the passed network, in the order of the outputs.  Addresses
+// are decoded from the standard pay-to-pubkey-hash, pay-to-script-hash,
+// pay-to-pubkey, bare multisig, pay-to-witness-pubkey-hash, and
+// pay-to-witness-script-hash output scripts.  The entry for an output with any
+// other script is empty.  ErrNoNetwork is returned if no network is
+// provided, and any error creating the address of an output is returned.
+func (t *Tx) OutputAddresses(net *chaincfg.Params) ([][]Address, error) {
+	if net == nil {
+		return nil, ErrNoNetwork
+	}
+
+	addrs := make([][]Address, len(t.msgTx.TxOut))
+	for i, txOut := range t.msgTx.TxOut {
+		outAddrs, err := extractScriptAddrs(txOut.PkScript, net)
+		if err != nil {
+			return nil, err
+		}
+		addrs[i] = outAddrs
+	}
+	return addrs, nil
+}
+
+// Signature hash types and opcodes used by SignatureHashLegacy.  These have
+// the values of the equivalent txscript constants, which can not be used here
+// since txscript imports this package.
+const (
+	sigHashNone         = 0x2
+	sigHashSingle       = 0x3
+	sigHashAnyOneCanPay = 0x80
+	sigHashMask         = 0x1f
+
+	opPushData1     = 0x4c
+	opPushData2     = 0x4d
+	opPushData4     = 0x4e
+	opCodeSeparator = 0xab
+)
+
+// SignatureHashLegacy returns the hash signed by a signature of the passed
+// hash type for the input at inputIdx using the original signature hash
+// algorithm, which is used for all inputs which do not spend witness
+// programs.  The subScript is the script the signature is checked by, which
+// is normally the output script spent by the input.  Any OP_CODESEPARATOR
+// opcodes are removed from it as they are when the signature is verified.
+// The hash type is a combination of the txscript SigHashType values such as
+// SigHashSingle|SigHashAnyOneCanPay, and types which are not defined are
+// hashed like SigHashAll.
+//
+// For compatibility with consensus, the hash of a SigHashSingle signature for
+// an input without an output at the same index is 1 as a little-endian
+// uint256 rather than an error.  It does not commit to the transaction, so a
+// signature of it may be reused to spend any output of the key.
+//
+// An OutOfRangeError is returned when there is no input at inputIdx, and
+// ErrMalformedScript when subScript can not be parsed.
+func (t *Tx) SignatureHashLegacy(inputIdx int, subScript []byte, hashType uint32) ([]byte, error) {
+	msgTx := t.msgTx
+	if inputIdx < 0 || inputIdx >= len(msgTx.TxIn) {
+		str := fmt.Sprintf("input index %d is out of range - max %d",
+			inputIdx, len(msgTx.TxIn)-1)
+		return nil, OutOfRangeError(str)
+	}
+	script, err := removeCodeSeparators(subScript)
+	if err != nil {
+		return nil, err
+	}
+	if hashType&sigHashMask == sigHashSingle && inputIdx >= len(msgTx.TxOut) {
+		var hash chainhash.Hash
+		hash[0] = 0x01
+		return hash[:], nil
+	}
+
+	// Copy the inputs and outputs so they can be modified, signing only
+	// the script of the input being signed.
+	txCopy := wire.MsgTx{Version: msgTx.Version, LockTime: msgTx.LockTime}
+	txCopy.TxIn = make([]*wire.TxIn, len(msgTx.TxIn))
+	for i, txIn := range msgTx.TxIn {
+		txCopy.TxIn[i] = &wire.TxIn{
+			PreviousOutPoint: txIn.PreviousOutPoint,
+			Sequence:         txIn.Sequence,
+		}
+	}
+	txCopy.TxIn[inputIdx].SignatureScript = script
+	txCopy.TxOut = make([]*wire.TxOut, len(msgTx.TxOut))
+	for i, txOut := range msgTx.TxOut {
+		txOutCopy := *txOut
+		txCopy.TxOut[i] = &txOutCopy
+	}
+
+	switch hashType & sigHashMask {
+	case sigHashNone:
+		// No outputs are signed, and the other inputs may be replaced.
+		txCopy.TxOut = txCopy.TxOut[:0]
+		for i, txIn := range txCopy.TxIn {
+			if i != inputIdx {
+				txIn.Sequence = 0
+			}
+		}
+
+	case sigHashSingle:
+		// Only the output at the index of the input is signed, and the
+		// other inputs may be replaced.
+		txCopy.TxOut = txCopy.TxOut[:inputIdx+1]
+		for _, txOut := range txCopy.TxOut[:inputIdx] {
+			txOut.Value = -1
+			txOut.PkScript = nil
+		}
+		for i, txIn := range txCopy.TxIn {
+			if i != inputIdx {
+				txIn.Sequence = 0
+			}
+		}
+	}
+	if hashType&sigHashAnyOneCanPay != 0 {
+		txCopy.TxIn = txCopy.TxIn[inputIdx : inputIdx+1]
+	}
+
+	// The signed message is the modified transaction followed by the
+	// hash type as a little-endian uint32.
+	var buf bytes.Buffer
+	buf.Grow(txCopy.SerializeSizeStripped() + 4)
+	if err := txCopy.SerializeNoWitness(&buf); err != nil {
+		return nil, err
+	}
+	var hashTypeBytes [4]byte
+	binary.LittleEndian.PutUint32(hashTypeBytes[:], hashType)
+	buf.Write(hashTypeBytes[:])
+	return chainhash.DoubleHashB(buf.Bytes()), nil
+}
+
+// removeCodeSeparators returns a copy of the script without any of its
+// OP_CODESEPARATOR opcodes.  The data pushed by the script is left intact
+// even when it contains the value of the opcode.
+func removeCodeSeparators(script []byte) ([]byte, error) {
+	stripped := make([]byte, 0, len(script))
+	for i := 0; i < len(script); {
+		op := script[i]
+		remaining := len(script) - i
+
+		// Find the length of the opcode and the data it pushes.
+		var n int
+		switch {
+		case op < opPushData1:
+			n = 1 + int(op)
+		case op == opPushData1 && remaining >= 2:
+			n = 2 + int(script[i+1])
+		case op == opPushData2 && remaining >= 3:
+			n = 3 + int(binary.LittleEndian.Uint16(script[i+1:]))
+		case op == opPushData4 && remaining >= 5:
+			dataLen := binary.LittleEndian.Uint32(script[i+1:])
+			if uint64(dataLen) > uint64(remaining) {
+				return nil, ErrMalformedScript
+			}
+			n = 5 + int(dataLen)
+		case op == opPushData1 || op == opPushData2 || op == opPushData4:
+			return nil, ErrMalformedScript
+		default:
+			n = 1
+		}
+		if n > remaining {
+			return nil, ErrMalformedScript
+		}
+
+		if op != opCodeSeparator {
+			stripped = append(stripped, script[i:i+n]...)
+		}
+		i += n
+	}
+	return stripped, nil
+}
+
 // Index returns the saved index of the transaction within a block.  This value
 // will be TxIndexUnknown if it hasn't already explicitly been set.
 func (t *Tx) Index() int {
 	return t.txIndex
 }
 
-// SetIndex sets the index of the transaction in within a block.
+// SetIndex sets the index of the transaction within a block.  Blocks set this
+// for each of their wrapped transactions, but it may also be set to any other
+// logical index for transactions which are not part of a block, or reset to
+// TxIndexUnknown.
 func (t *Tx) SetIndex(index int) {
 	t.txIndex = index
 }
 
+// Copy returns a new Tx wrapping a deep copy of the underlying wire.MsgTx,
+// including all inputs, outputs, and witness data, so that modifying the
+// copy does not affect the original.  None of the cached values are copied,
+// so they are recalculated from the copy when first accessed, and the index
+// of the copy is TxIndexUnknown.
+func (t *Tx) Copy() *Tx {
+	return NewTx(t.msgTx.Copy())
+}
+
 // NewTx returns a new instance of a bitcoin transaction given an underlying
 // wire.MsgTx.  See Tx.
 func NewTx(msgTx *wire.MsgTx) *Tx {
@@ -6,11 +6,17 @@ package btcutil_test
 
 import (
 	"bytes"
+	"encoding/hex"
 	"io"
+	"math"
 	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 	"github.com/davecgh/go-spew/spew"
 )
@@ -26,12 +32,19 @@ func TestTx(t *testing.T) {
 			spew.Sdump(msgTx), spew.Sdump(testTx))
 	}
 
+	// Ensure the transaction index is unknown until it is set.
+	if gotIndex := tx.Index(); gotIndex != btcutil.TxIndexUnknown {
+		t.Errorf("Index: mismatched default index - got %v, want %v",
+			gotIndex, btcutil.TxIndexUnknown)
+	}
+
 	// Ensure transaction index set and get work properly.
-	wantIndex := 0
-	tx.SetIndex(0)
-	if gotIndex := tx.Index(); gotIndex != wantIndex {
-		t.Errorf("Index: mismatched index - got %v, want %v",
-			gotIndex, wantIndex)
+	for _, wantIndex := range []int{0, 5, btcutil.TxIndexUnknown} {
+		tx.SetIndex(wantIndex)
+		if gotIndex := tx.Index(); gotIndex != wantIndex {
+			t.Errorf("Index: mismatched index - got %v, want %v",
+				gotIndex, wantIndex)
+		}
 	}
 
 	// Hash for block 100,000 transaction 0.
@@ -51,6 +64,646 @@ func TestTx(t *testing.T) {
 	}
 }
 
+// TestTxIsCoinBase ensures coinbase transactions are identified properly.
+func TestTxIsCoinBase(t *testing.T) {
+	coinbaseIn := &wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{
+			Hash:  chainhash.Hash{},
+			Index: wire.MaxPrevOutIndex,
+		},
+		SignatureScript: []byte{0x04, 0x31, 0xdc, 0x00, 0x1b, 0x01, 0x62},
+		Sequence:        wire.MaxTxInSequenceNum,
+	}
+	coinbase := wire.NewMsgTx(1)
+	coinbase.AddTxIn(coinbaseIn)
+	coinbase.AddTxOut(wire.NewTxOut(5000000000, nil))
+
+	// A single input spending a real outpoint.
+	nonNullHash := wire.NewMsgTx(1)
+	nonNullHash.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{
+			Hash:  chainhash.Hash{0x01},
+			Index: wire.MaxPrevOutIndex,
+		},
+	})
+
+	// A single input with a zero hash but a non-max index.
+	nonMaxIndex := wire.NewMsgTx(1)
+	nonMaxIndex.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Index: 0},
+	})
+
+	// A null outpoint alongside another input.
+	multipleInputs := coinbase.Copy()
+	multipleInputs.AddTxIn(Block100000.Transactions[1].TxIn[0])
+
+	tests := []struct {
+		name string
+		tx   *wire.MsgTx
+		want bool
+	}{
+		{"block 100000 coinbase", Block100000.Transactions[0], true},
+		{"constructed coinbase", coinbase, true},
+		{"block 100000 normal tx", Block100000.Transactions[1], false},
+		{"non-null hash", nonNullHash, false},
+		{"non-max index", nonMaxIndex, false},
+		{"multiple inputs", multipleInputs, false},
+		{"no inputs", wire.NewMsgTx(1), false},
+	}
+
+	for _, test := range tests {
+		if got := btcutil.NewTx(test.tx).IsCoinBase(); got != test.want {
+			t.Errorf("IsCoinBase (%s): got %v, want %v", test.name,
+				got, test.want)
+		}
+	}
+}
+
+// TestTxFee ensures the fee of a transaction is calculated from the values of
+// the outputs it spends.
+func TestTxFee(t *testing.T) {
+	prevHash := chainhash.Hash{0x01}
+	prevOuts := map[wire.OutPoint]btcutil.Amount{
+		{Hash: prevHash, Index: 0}: 50000,
+		{Hash: prevHash, Index: 1}: 30000,
+	}
+	lookup := func(op wire.OutPoint) (btcutil.Amount, bool) {
+		value, ok := prevOuts[op]
+		return value, ok
+	}
+
+	spend := wire.NewMsgTx(1)
+	spend.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&prevHash, 0), nil, nil))
+	spend.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&prevHash, 1), nil, nil))
+	spend.AddTxOut(wire.NewTxOut(60000, nil))
+	spend.AddTxOut(wire.NewTxOut(15000, nil))
+
+	// An input spending an output which is not known.
+	missing := spend.Copy()
+	missing.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&prevHash, 2), nil, nil))
+
+	// Outputs worth more than the inputs.
+	overspend := spend.Copy()
+	overspend.AddTxOut(wire.NewTxOut(10000, nil))
+
+	tests := []struct {
+		name string
+		tx   *wire.MsgTx
+		fee  btcutil.Amount
+		err  error
+	}{
+		{"spend", spend, 5000, nil},
+		{"missing prevout", missing, 0, btcutil.ErrMissingPrevOut},
+		{"overspend", overspend, -5000, nil},
+		{"coinbase", Block100000.Transactions[0], -5000000000, nil},
+		{"no outputs", wire.NewMsgTx(1), 0, nil},
+	}
+
+	for _, test := range tests {
+		fee, err := btcutil.NewTx(test.tx).Fee(lookup)
+		if err != test.err {
+			t.Errorf("Fee (%s): got error %v, want %v", test.name, err,
+				test.err)
+			continue
+		}
+		if fee != test.fee {
+			t.Errorf("Fee (%s): got %d, want %d", test.name, int64(fee),
+				int64(test.fee))
+		}
+	}
+}
+
+// TestTxCounts ensures the input and output counts and the total output value
+// of a transaction are reported correctly, including when the total would
+// overflow.
+func TestTxCounts(t *testing.T) {
+	prevHash := chainhash.Hash{0x01}
+	multi := wire.NewMsgTx(1)
+	multi.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&prevHash, 0), nil, nil))
+	multi.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&prevHash, 1), nil, nil))
+	multi.AddTxOut(wire.NewTxOut(60000, nil))
+	multi.AddTxOut(wire.NewTxOut(15000, nil))
+	multi.AddTxOut(wire.NewTxOut(0, nil))
+
+	overflow := wire.NewMsgTx(1)
+	overflow.AddTxOut(wire.NewTxOut(math.MaxInt64, nil))
+	overflow.AddTxOut(wire.NewTxOut(1, nil))
+	overflow.AddTxOut(wire.NewTxOut(-math.MaxInt64, nil))
+
+	underflow := wire.NewMsgTx(1)
+	underflow.AddTxOut(wire.NewTxOut(math.MinInt64, nil))
+	underflow.AddTxOut(wire.NewTxOut(-1, nil))
+
+	tests := []struct {
+		name       string
+		tx         *wire.MsgTx
+		numInputs  int
+		numOutputs int
+		total      btcutil.Amount
+	}{
+		{"multiple outputs", multi, 2, 3, 75000},
+		{"coinbase", Block100000.Transactions[0], 1, 1, 5000000000},
+		{"block 100000 tx 1", Block100000.Transactions[1], 1, 2, 5000000000},
+		{"empty", wire.NewMsgTx(1), 0, 0, 0},
+		{"overflow", overflow, 0, 3, math.MaxInt64},
+		{"underflow", underflow, 0, 2, math.MinInt64},
+	}
+
+	for _, test := range tests {
+		tx := btcutil.NewTx(test.tx)
+		if got := tx.NumInputs(); got != test.numInputs {
+			t.Errorf("NumInputs (%s): got %d, want %d", test.name, got,
+				test.numInputs)
+		}
+		if got := tx.NumOutputs(); got != test.numOutputs {
+			t.Errorf("NumOutputs (%s): got %d, want %d", test.name, got,
+				test.numOutputs)
+		}
+		if got := tx.TotalOutputValue(); got != test.total {
+			t.Errorf("TotalOutputValue (%s): got %d, want %d",
+				test.name, int64(got), int64(test.total))
+		}
+	}
+}
+
+// TestTxOutputAddresses ensures the addresses paid to by the outputs of a
+// transaction are decoded from each of the standard script types.
+func TestTxOutputAddresses(t *testing.T) {
+	hexToBytes := func(s string) []byte {
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			t.Fatalf("DecodeString: %v", err)
+		}
+		return b
+	}
+	compressed := "02192d74d0cb94344c9569c2e77901573d8d7903c3ebec3a957724895dca52c6b4"
+	uncompressed := "0411db93e1dcdb8a016b49840f8c53bc1eb68a382e97b1482ecad7b148a" +
+		"6909a5cb2e0eaddfb84ccf9744464f82e160bfa9b8b64f9d4c03f999b864" +
+		"3f656b412a3"
+	net := &chaincfg.MainNetParams
+	pubKey1, _ := btcutil.NewAddressPubKey(hexToBytes(compressed), net)
+	pubKey2, _ := btcutil.NewAddressPubKey(hexToBytes(uncompressed), net)
+	multiSig, err := txscript.MultiSigScript([]*btcutil.AddressPubKey{pubKey1,
+		pubKey2}, 1)
+	if err != nil {
+		t.Fatalf("MultiSigScript: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		script []byte
+		addrs  []string
+	}{
+		{
+			name:   "p2pkh",
+			script: hexToBytes("76a914e34cce70c86373273efcc54ce7d2a491bb4a0e8488ac"),
+			addrs:  []string{"1MirQ9bwyQcGVJPwKUgapu5ouK2E2Ey4gX"},
+		},
+		{
+			name:   "p2sh",
+			script: hexToBytes("a914f815b036d9bbbce5e9f2a00abd1bf3dc91e9551087"),
+			addrs:  []string{"3QJmV3qfvL9SuYo34YihAf3sRCW3qSinyC"},
+		},
+		{
+			name:   "p2pk compressed",
+			script: hexToBytes("21" + compressed + "ac"),
+			addrs:  []string{compressed},
+		},
+		{
+			name:   "p2pk uncompressed",
+			script: hexToBytes("41" + uncompressed + "ac"),
+			addrs:  []string{uncompressed},
+		},
+		{
+			name:   "multisig",
+			script: multiSig,
+			addrs:  []string{compressed, uncompressed},
+		},
+		{
+			name:   "p2wpkh",
+			script: hexToBytes("0014751e76e8199196d454941c45d1b3a323f1433bd6"),
+			addrs:  []string{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"},
+		},
+		{
+			name: "p2wsh",
+			script: hexToBytes("00201863143c14c5166804bd19203356da136c985678" +
+				"cd4d27a1b8c6329604903262"),
+			addrs: []string{"bc1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3qccfmv3"},
+		},
+		{
+			name:   "p2pk invalid pubkey",
+			script: hexToBytes("21" + "02" + strings.Repeat("00", 32) + "ac"),
+		},
+		{
+			name:   "nulldata",
+			script: hexToBytes("6a0401020304"),
+		},
+		{
+			name:   "truncated p2pkh",
+			script: hexToBytes("76a914e34cce70c86373273efcc54ce7d2a491bb4a0e8488"),
+		},
+		{
+			name:   "multisig key count mismatch",
+			script: append(append([]byte{}, multiSig[:len(multiSig)-2]...), 0x53, 0xae),
+		},
+		{
+			name: "empty",
+		},
+	}
+
+	msgTx := wire.NewMsgTx(1)
+	for _, test := range tests {
+		msgTx.AddTxOut(wire.NewTxOut(1000, test.script))
+	}
+	addrs, err := btcutil.NewTx(msgTx).OutputAddresses(net)
+	if err != nil {
+		t.Fatalf("OutputAddresses: unexpected error: %v", err)
+	}
+	if len(addrs) != len(tests) {
+		t.Fatalf("OutputAddresses: got %d outputs, want %d", len(addrs),
+			len(tests))
+	}
+	for i, test := range tests {
+		if len(addrs[i]) != len(test.addrs) {
+			t.Errorf("OutputAddresses (%s): got %d addresses, want %d",
+				test.name, len(addrs[i]), len(test.addrs))
+			continue
+		}
+		for j, addr := range addrs[i] {
+			if addr.String() != test.addrs[j] {
+				t.Errorf("OutputAddresses (%s): address %d got %s, "+
+					"want %s", test.name, j, addr, test.addrs[j])
+			}
+		}
+	}
+
+	// The addresses must match those extracted by txscript, including for
+	// the outputs of a real block.
+	txns := append([]*wire.MsgTx{msgTx}, Block100000.Transactions...)
+	for _, msgTx := range txns {
+		tx := btcutil.NewTx(msgTx)
+		txAddrs, err := tx.OutputAddresses(net)
+		if err != nil {
+			t.Errorf("OutputAddresses (%v): unexpected error: %v",
+				tx.Hash(), err)
+			continue
+		}
+		for i, addrs := range txAddrs {
+			_, want, _, _ := txscript.ExtractPkScriptAddrs(
+				msgTx.TxOut[i].PkScript, net)
+			if len(addrs) != len(want) {
+				t.Errorf("OutputAddresses (%v:%d): got %d addresses, "+
+					"want %d", tx.Hash(), i, len(addrs), len(want))
+				continue
+			}
+			for j := range addrs {
+				if !btcutil.AddressEqual(addrs[j], want[j]) {
+					t.Errorf("OutputAddresses (%v:%d): address %d got "+
+						"%v, want %v", tx.Hash(), i, j, addrs[j],
+						want[j])
+				}
+			}
+		}
+	}
+
+	// Addresses can not be encoded without a network.
+	if _, err := btcutil.NewTx(msgTx).OutputAddresses(nil); err != btcutil.ErrNoNetwork {
+		t.Errorf("OutputAddresses: got error %v for nil network, want %v",
+			err, btcutil.ErrNoNetwork)
+	}
+}
+
+// TestTxSerializeSize tests the serialized size of a Tx is calculated and
+// cached properly.
+func TestTxSerializeSize(t *testing.T) {
+	tests := []struct {
+		tx   *wire.MsgTx
+		want int
+	}{
+		{Block100000.Transactions[0], 135},
+		{Block100000.Transactions[1], 259},
+		{Block100000.Transactions[3], 225},
+	}
+
+	for i, test := range tests {
+		tx := btcutil.NewTx(test.tx)
+
+		// Request the size multiple times to test generation and
+		// caching.
+		for j := 0; j < 2; j++ {
+			if got := tx.SerializeSize(); got != test.want {
+				t.Errorf("SerializeSize #%d (call %d): got %d, want %d",
+					i, j, got, test.want)
+			}
+		}
+	}
+}
+
+// TestTxBytes tests the serialized bytes of a Tx with and without witness
+// data are generated and cached properly.
+func TestTxBytes(t *testing.T) {
+	legacyTx := Block100000.Transactions[1]
+	witnessTx := legacyTx.Copy()
+	witnessTx.TxIn[0].Witness = wire.TxWitness{{0x01, 0x02}, {0x03}}
+
+	tests := []struct {
+		name   string
+		tx     *wire.MsgTx
+		differ bool
+	}{
+		{"legacy", legacyTx, false},
+		{"witness", witnessTx, true},
+	}
+
+	for _, test := range tests {
+		var full, stripped bytes.Buffer
+		if err := test.tx.Serialize(&full); err != nil {
+			t.Fatalf("Serialize (%s): %v", test.name, err)
+		}
+		if err := test.tx.SerializeNoWitness(&stripped); err != nil {
+			t.Fatalf("SerializeNoWitness (%s): %v", test.name, err)
+		}
+
+		tx := btcutil.NewTx(test.tx)
+
+		// Request the bytes multiple times to test generation and
+		// caching.
+		for j := 0; j < 2; j++ {
+			gotFull, err := tx.Bytes()
+			if err != nil {
+				t.Errorf("Bytes (%s): unexpected error: %v", test.name,
+					err)
+				continue
+			}
+			if !bytes.Equal(gotFull, full.Bytes()) {
+				t.Errorf("Bytes (%s, call %d): mismatched bytes - "+
+					"got %x, want %x", test.name, j, gotFull,
+					full.Bytes())
+			}
+			gotStripped, err := tx.BytesNoWitness()
+			if err != nil {
+				t.Errorf("BytesNoWitness (%s): unexpected error: %v",
+					test.name, err)
+				continue
+			}
+			if !bytes.Equal(gotStripped, stripped.Bytes()) {
+				t.Errorf("BytesNoWitness (%s, call %d): mismatched "+
+					"bytes - got %x, want %x", test.name, j,
+					gotStripped, stripped.Bytes())
+			}
+			if differ := !bytes.Equal(gotFull, gotStripped); differ != test.differ {
+				t.Errorf("BytesNoWitness (%s): witness-stripped "+
+					"bytes differ from full bytes: got %v, want %v",
+					test.name, differ, test.differ)
+			}
+		}
+
+		// The transaction hash commits to the witness-stripped bytes.
+		if hash := chainhash.DoubleHashH(stripped.Bytes()); !hash.IsEqual(tx.Hash()) {
+			t.Errorf("BytesNoWitness (%s): hash of bytes %v does not "+
+				"match transaction hash %v", test.name, hash, tx.Hash())
+		}
+	}
+}
+
+// TestTxSignatureHashLegacy ensures the legacy signature hashes of a
+// transaction match vectors from the reference implementation and the
+// signature hashes calculated by txscript for each hash type.
+func TestTxSignatureHashLegacy(t *testing.T) {
+	// Vectors from the reference implementation's sighash.json, covering
+	// each hash type with and without SigHashAnyOneCanPay, an undefined
+	// hash type, and scripts containing OP_CODESEPARATOR.
+	tests := []struct {
+		rawTx    string
+		script   string
+		inputIdx int
+		hashType int32
+		want     string
+	}{
+		{"b7877f82019c832707a60cf14fba44cfa254d787501fdd676bd58c744f6e951dbba0b3b77f0200000009ac515263ac53525300a5a36e500148f89c0500000000085265ac6a6a65acab00000000", "6563", 0, -1785108415, "cb6e4322955af12eb29613c70e1a00ddbb559c887ba844df0bcdebed736dffbd"},
+		{"e3cdbfb4014d90ae6a4401e85f7ac717adc2c035858bf6ff48979dd399d155bce1f150daea0300000002ac51a67a0d39017f6c71040000000005535200535200000000", "", 0, -1899950911, "c1c7df8206e661d593f6455db1d61a364a249407f88e99ecad05346e495b38d7"},
+		{"2f7353dd02e395b0a4d16da0f7472db618857cd3de5b9e2789232952a9b154d249102245fd030000000151617fd88f103280b85b0a198198e438e7cab1a4c92ba58409709997cc7a65a619eb9eec3c0200000003636aabffffffff0397481c0200000000045300636a0dc97803000000000009d389030000000003ac6a53134007bb", "0000536552526a", 0, -1912746174, "30c4cd4bd6b291f7e9489cc4b4440a083f93a7664ea1f93e77a9597dab8ded9c"},
+		{"25ee54ef0187387564bb86e0af96baec54289ca8d15e81a507a2ed6668dc92683111dfb7a50100000004005263634cecf17d0429aa4d000000000007636a6aabab5263daa75601000000000251ab4df70a01000000000151980a890400000000065253ac6a006377fd24e3", "65ab", 0, 797877378, "069f38fd5d47abff46f04ee3ae27db03275e9aa4737fa0d2f5394779f9654845"},
+		{"6f62138301436f33a00b84a26a0457ccbfc0f82403288b9cbae39986b34357cb2ff9b889b302000000045253655335a7ff6701bac9960400000000086552ab656352635200000000", "6aac51", 0, 1444414211, "502a2435fd02898d2ff3ab08a3c19078414b32ec9b73d64a944834efc9dae10c"},
+		{"d3b7421e011f4de0f1cea9ba7458bf3486bee722519efab711a963fa8c100970cf7488b7bb0200000003525352dcd61b300148be5d05000000000000000000", "535251536aac536a", 0, -1960128125, "29aa6d2d752d3310eba20442770ad345b7f6a35f96161ede5f07b33e92053e2a"},
+		{"fea256ce01272d125e577c0a09570a71366898280dda279b021000db1325f27edda41a53460100000002ab53c752c21c013c2b3a01000000000000000000", "65", 0, 1145543262, "076b9f844f6ae429de228a2c337c704df1652c292b6c6494882190638dad9efd"},
+		{"8edcf5a1014b604e53f0d12fe143cf4284f86dc79a634a9f17d7e9f8725f7beb95e8ffcd2403000000046aabac52ffffffff01c402b5040000000005ab6a63525100000000", "6351525251acabab6a", 0, 1520147826, "2765bbdcd3ebb8b1a316c04656b28d637f80bffbe9b040661481d3dc83eea6d6"},
+	}
+
+	for i, test := range tests {
+		rawTx, err := hex.DecodeString(test.rawTx)
+		if err != nil {
+			t.Fatalf("#%d: DecodeString: unexpected error: %v", i, err)
+		}
+		tx, err := btcutil.NewTxFromBytes(rawTx)
+		if err != nil {
+			t.Fatalf("#%d: NewTxFromBytes: unexpected error: %v", i, err)
+		}
+		script, err := hex.DecodeString(test.script)
+		if err != nil {
+			t.Fatalf("#%d: DecodeString: unexpected error: %v", i, err)
+		}
+		want, err := chainhash.NewHashFromStr(test.want)
+		if err != nil {
+			t.Fatalf("#%d: NewHashFromStr: unexpected error: %v", i, err)
+		}
+
+		hash, err := tx.SignatureHashLegacy(test.inputIdx, script,
+			uint32(test.hashType))
+		if err != nil {
+			t.Errorf("#%d: SignatureHashLegacy: unexpected error: %v",
+				i, err)
+			continue
+		}
+		if !bytes.Equal(hash, want[:]) {
+			t.Errorf("#%d: SignatureHashLegacy: got %x, want %x", i,
+				hash, want[:])
+		}
+	}
+
+	// A transaction with fewer outputs than inputs, so a SigHashSingle
+	// signature for the last input hashes to 1.
+	prevHash := chainhash.Hash{0x01}
+	msgTx := wire.NewMsgTx(1)
+	msgTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&prevHash, 0), []byte{0x51}, nil))
+	msgTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&prevHash, 1), []byte{0x52}, nil))
+	msgTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&prevHash, 2), nil, nil))
+	msgTx.AddTxOut(wire.NewTxOut(60000, []byte{0x76, 0xa9}))
+	msgTx.AddTxOut(wire.NewTxOut(15000, []byte{0x87}))
+	msgTx.TxIn[1].Sequence = 5
+	tx := btcutil.NewTx(msgTx)
+	serialized, err := tx.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: unexpected error: %v", err)
+	}
+
+	var one [chainhash.HashSize]byte
+	one[0] = 0x01
+	hash, err := tx.SignatureHashLegacy(2, nil, uint32(txscript.SigHashSingle))
+	if err != nil || !bytes.Equal(hash, one[:]) {
+		t.Errorf("SignatureHashLegacy: got %x (error %v), want %x", hash,
+			err, one)
+	}
+
+	// The hashes must match those of txscript for each hash type and each
+	// input, including for a script with an OP_CODESEPARATOR and data
+	// pushes containing its value.
+	script := []byte{
+		txscript.OP_DUP, txscript.OP_CODESEPARATOR, txscript.OP_DATA_2,
+		txscript.OP_CODESEPARATOR, 0x01, txscript.OP_PUSHDATA1, 0x01,
+		txscript.OP_CODESEPARATOR, txscript.OP_CHECKSIG,
+	}
+	hashTypes := []txscript.SigHashType{
+		txscript.SigHashAll,
+		txscript.SigHashNone,
+		txscript.SigHashSingle,
+		txscript.SigHashAll | txscript.SigHashAnyOneCanPay,
+		txscript.SigHashNone | txscript.SigHashAnyOneCanPay,
+		txscript.SigHashSingle | txscript.SigHashAnyOneCanPay,
+	}
+	for _, hashType := range hashTypes {
+		for idx := range msgTx.TxIn {
+			want, err := txscript.CalcSignatureHash(script, hashType,
+				msgTx, idx)
+			if err != nil {
+				t.Fatalf("CalcSignatureHash: unexpected error: %v", err)
+			}
+			hash, err := tx.SignatureHashLegacy(idx, script,
+				uint32(hashType))
+			if err != nil {
+				t.Errorf("SignatureHashLegacy (%v, %d): unexpected "+
+					"error: %v", hashType, idx, err)
+				continue
+			}
+			if !bytes.Equal(hash, want) {
+				t.Errorf("SignatureHashLegacy (%v, %d): got %x, "+
+					"want %x", hashType, idx, hash, want)
+			}
+		}
+	}
+
+	// Hashing must not modify the transaction.
+	after, err := btcutil.NewTx(msgTx).Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: unexpected error: %v", err)
+	}
+	if !bytes.Equal(after, serialized) {
+		t.Errorf("SignatureHashLegacy: transaction was modified")
+	}
+
+	// Invalid input indexes and malformed scripts are rejected.
+	for _, idx := range []int{-1, len(msgTx.TxIn)} {
+		_, err := tx.SignatureHashLegacy(idx, script,
+			uint32(txscript.SigHashAll))
+		if _, ok := err.(btcutil.OutOfRangeError); !ok {
+			t.Errorf("SignatureHashLegacy (input %d): got error %v, "+
+				"want OutOfRangeError", idx, err)
+		}
+	}
+	malformed := [][]byte{
+		{txscript.OP_DATA_2, 0x01},
+		{txscript.OP_PUSHDATA1},
+		{txscript.OP_PUSHDATA2, 0x01},
+		{txscript.OP_PUSHDATA4, 0xff, 0xff, 0xff, 0xff},
+	}
+	for _, script := range malformed {
+		_, err := tx.SignatureHashLegacy(0, script,
+			uint32(txscript.SigHashAll))
+		if err != btcutil.ErrMalformedScript {
+			t.Errorf("SignatureHashLegacy (script %x): got error %v, "+
+				"want %v", script, err, btcutil.ErrMalformedScript)
+		}
+	}
+}
+
+// TestFeeRate tests the fee rate calculation including rounding and the
+// zero size guard.
+func TestFeeRate(t *testing.T) {
+	tests := []struct {
+		fee  btcutil.Amount
+		size int
+		want btcutil.Amount
+	}{
+		{25900, 259, 100},
+		{10000, 259, 39},
+		{10100, 259, 39},
+		{10230, 259, 39},
+		{10231, 259, 40},
+		{1, 2, 1},
+		{1, 3, 0},
+		{-10231, 259, -40},
+		{0, 259, 0},
+		{10000, 0, 0},
+		{10000, -1, 0},
+	}
+
+	for i, test := range tests {
+		if got := btcutil.FeeRate(test.fee, test.size); got != test.want {
+			t.Errorf("FeeRate #%d (%d, %d): got %d, want %d", i,
+				int64(test.fee), test.size, int64(got), int64(test.want))
+		}
+	}
+}
+
+// TestTxCopy ensures copies of a transaction are independent of the original
+// and recalculate their cached values.
+func TestTxCopy(t *testing.T) {
+	testTx := Block100000.Transactions[1].Copy()
+	tx := btcutil.NewTx(testTx)
+	tx.SetIndex(1)
+	origHash := *tx.Hash()
+	origSize := tx.SerializeSize()
+
+	txCopy := tx.Copy()
+	if txCopy.MsgTx() == tx.MsgTx() {
+		t.Fatal("Copy: underlying MsgTx was not copied")
+	}
+	if !reflect.DeepEqual(txCopy.MsgTx(), tx.MsgTx()) {
+		t.Fatalf("Copy: mismatched MsgTx - got %v, want %v",
+			spew.Sdump(txCopy.MsgTx()), spew.Sdump(tx.MsgTx()))
+	}
+	if gotIndex := txCopy.Index(); gotIndex != btcutil.TxIndexUnknown {
+		t.Errorf("Index: mismatched index - got %v, want %v", gotIndex,
+			btcutil.TxIndexUnknown)
+	}
+	if !txCopy.Hash().IsEqual(&origHash) {
+		t.Errorf("Hash: mismatched hash - got %v, want %v",
+			txCopy.Hash(), origHash)
+	}
+
+	// Modify the outputs, inputs, and witness of the copy, which must not
+	// affect the original.
+	copyMsgTx := txCopy.Copy().MsgTx()
+	copyMsgTx.TxOut[0].Value++
+	copyMsgTx.TxOut[0].PkScript[0] ^= 0xff
+	copyMsgTx.TxIn[0].SignatureScript[0] ^= 0xff
+	copyMsgTx.TxIn[0].Witness = wire.TxWitness{{0x01}}
+	copyMsgTx.AddTxOut(wire.NewTxOut(1, nil))
+	if !reflect.DeepEqual(tx.MsgTx(), Block100000.Transactions[1]) {
+		t.Fatalf("Copy: original was modified - got %v, want %v",
+			spew.Sdump(tx.MsgTx()), spew.Sdump(Block100000.Transactions[1]))
+	}
+	if !tx.Hash().IsEqual(&origHash) || tx.SerializeSize() != origSize {
+		t.Errorf("Copy: cached values of original changed")
+	}
+
+	// The modified copy must calculate its own hash and size.
+	modified := btcutil.NewTx(copyMsgTx).Copy()
+	wantHash := copyMsgTx.TxHash()
+	if !modified.Hash().IsEqual(&wantHash) || modified.Hash().IsEqual(&origHash) {
+		t.Errorf("Hash: mismatched hash for modified copy - got %v, "+
+			"want %v", modified.Hash(), wantHash)
+	}
+	if size := modified.SerializeSize(); size != copyMsgTx.SerializeSize() {
+		t.Errorf("SerializeSize: mismatched size for modified copy - got "+
+			"%d, want %d", size, copyMsgTx.SerializeSize())
+	}
+	if !modified.HasWitness() || tx.HasWitness() {
+		t.Errorf("HasWitness: mismatched witness flags - got %v and %v",
+			modified.HasWitness(), tx.HasWitness())
+	}
+}
+
 // TestNewTxFromBytes tests creation of a Tx from serialized bytes.
 func TestNewTxFromBytes(t *testing.T) {
 	// Serialize the test transaction.
@@ -74,6 +727,12 @@ func TestNewTxFromBytes(t *testing.T) {
 		t.Errorf("MsgTx: mismatched MsgTx - got %v, want %v",
 			spew.Sdump(msgTx), spew.Sdump(testTx))
 	}
+
+	// Ensure the transaction index is unknown.
+	if gotIndex := tx.Index(); gotIndex != btcutil.TxIndexUnknown {
+		t.Errorf("Index: mismatched index - got %v, want %v", gotIndex,
+			btcutil.TxIndexUnknown)
+	}
 }
 
 // TestTxErrors tests the error paths for the Tx API.
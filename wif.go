@@ -5,8 +5,12 @@
 package btcutil
 
 import (
+	"bufio"
 	"bytes"
 	"errors"
+	"fmt"
+	"io"
+	"strings"
 
 	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/chaincfg"
@@ -20,6 +24,50 @@ import (
 // encountered.
 var ErrMalformedPrivateKey = errors.New("malformed private key")
 
+// ErrUnknownNet describes an error where a WIF-encoded private key cannot be
+// decoded because its network identifier byte does not belong to any of the
+// known bitcoin networks.
+var ErrUnknownNet = errors.New("unknown network for private key")
+
+// wifNets are the known networks a WIF-encoded private key may be decoded
+// for, in the order they are matched against the network identifier byte.
+// The regression test network is not listed since it uses the same
+// identifier as testnet3.  Further networks are added by RegisterWIFNet.
+var wifNets = []*chaincfg.Params{
+	&chaincfg.MainNetParams,
+	&chaincfg.TestNet3Params,
+	&chaincfg.SimNetParams,
+}
+
+// RegisterWIFNet adds the passed network to the known networks so that
+// DecodeWIF accepts private keys using its PrivateKeyID and Net reports it
+// for them.  This is intended for networks which are also registered with
+// chaincfg.Register, and like it, should be called from an init function
+// since it is not safe for concurrent use.  ErrNoNetwork is returned if net is
+// nil, and chaincfg.ErrDuplicateNet is returned if a known network already uses
+// the same PrivateKeyID.
+func RegisterWIFNet(net *chaincfg.Params) error {
+	if net == nil {
+		return ErrNoNetwork
+	}
+	if wifNet(net.PrivateKeyID) != nil {
+		return chaincfg.ErrDuplicateNet
+	}
+	wifNets = append(wifNets, net)
+	return nil
+}
+
+// wifNet returns the known network using the passed WIF network identifier
+// byte, or nil if there is no such network.
+func wifNet(netID byte) *chaincfg.Params {
+	for _, net := range wifNets {
+		if net.PrivateKeyID == netID {
+			return net
+		}
+	}
+	return nil
+}
+
 // compressMagic is the magic byte used to identify a WIF encoding for
 // an address created from a compressed serialized public key.
 const compressMagic byte = 0x01
@@ -62,14 +110,26 @@ func (w *WIF) IsForNet(net *chaincfg.Params) bool {
 	return w.netID == net.PrivateKeyID
 }
 
+// Net returns the bitcoin network the WIF structure is associated with, as
+// determined by its network identifier byte.  Since testnet3 and the
+// regression test network share the same identifier, TestNet3Params is
+// returned for both, and IsForNet should be used when the distinction
+// matters.  Nil is returned if the WIF was created by NewWIF for a network
+// other than mainnet, testnet3, the regression test network, simnet, or one
+// added by RegisterWIFNet.
+func (w *WIF) Net() *chaincfg.Params {
+	return wifNet(w.netID)
+}
+
 // DecodeWIF creates a new WIF structure by decoding the string encoding of
 // the import format.
 //
 // The WIF string must be a base58-encoded string of the following byte
 // sequence:
 //
-//  * 1 byte to identify the network, must be 0x80 for mainnet or 0xef for
-//    either testnet3 or the regression test network
+//  * 1 byte to identify the network, must be 0x80 for mainnet, 0xef for
+//    either testnet3 or the regression test network, 0x64 for simnet, or the
+//    PrivateKeyID of a network added by RegisterWIFNet
 //  * 32 bytes of a binary-encoded, big-endian, zero-padded private key
 //  * Optional 1 byte (equal to 0x01) if the address being imported or exported
 //    was created by taking the RIPEMD160 after SHA256 hash of a serialized
@@ -81,7 +141,9 @@ func (w *WIF) IsForNet(net *chaincfg.Params) bool {
 // return a non-nil error.  ErrMalformedPrivateKey is returned when the WIF
 // is of an impossible length or the expected compressed pubkey magic number
 // does not equal the expected value of 0x01.  ErrChecksumMismatch is returned
-// if the expected WIF checksum does not match the calculated checksum.
+// if the expected WIF checksum does not match the calculated checksum, and
+// ErrUnknownNet is returned if the network identifier byte does not belong to
+// any known network.
 func DecodeWIF(wif string) (*WIF, error) {
 	decoded := base58.Decode(wif)
 	decodedLen := len(decoded)
@@ -116,11 +178,67 @@ func DecodeWIF(wif string) (*WIF, error) {
 	}
 
 	netID := decoded[0]
+	if wifNet(netID) == nil {
+		return nil, ErrUnknownNet
+	}
 	privKeyBytes := decoded[1 : 1+btcec.PrivKeyBytesLen]
 	privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), privKeyBytes)
 	return &WIF{privKey, compress, netID}, nil
 }
 
+// WIFLineError describes an error decoding one line of a newline-delimited
+// list of WIF-encoded private keys read by DecodeWIFs.
+type WIFLineError struct {
+	Line int   // 1-based line number in the input
+	Err  error // Error returned by DecodeWIF
+}
+
+// Error satisfies the error interface and prints human-readable errors.
+func (e *WIFLineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the underlying error returned by DecodeWIF.
+func (e *WIFLineError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeWIFs decodes a newline-delimited list of WIF-encoded private keys
+// read from r, such as one exported by a wallet for recovery.  Leading and
+// trailing whitespace is ignored, as are blank lines and lines beginning with
+// '#', which may be used for comments.
+//
+// The returned slices are parallel and contain an entry for each remaining
+// line, in order.  For each line, either the decoded WIF is returned with a
+// nil error, or a nil WIF is returned with a *WIFLineError describing why the
+// line could not be decoded.  Should reading from r fail, the read error is
+// returned as the final entry along with a nil WIF.
+func DecodeWIFs(r io.Reader) ([]*WIF, []error) {
+	var wifs []*WIF
+	var errs []error
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || text[0] == '#' {
+			continue
+		}
+
+		wif, err := DecodeWIF(text)
+		if err != nil {
+			wifs = append(wifs, nil)
+			errs = append(errs, &WIFLineError{Line: line, Err: err})
+			continue
+		}
+		wifs = append(wifs, wif)
+		errs = append(errs, nil)
+	}
+	if err := scanner.Err(); err != nil {
+		wifs = append(wifs, nil)
+		errs = append(errs, err)
+	}
+	return wifs, errs
+}
+
 // String creates the Wallet Import Format string encoding of a WIF structure.
 // See DecodeWIF for a detailed breakdown of the format and requirements of
 // a valid WIF string.
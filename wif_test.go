@@ -5,11 +5,15 @@
 package btcutil_test
 
 import (
+	"errors"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/chaincfg"
 	. "github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/base58"
 )
 
 func TestEncodeDecodeWIF(t *testing.T) {
@@ -69,3 +73,204 @@ func TestEncodeDecodeWIF(t *testing.T) {
 		}
 	}
 }
+
+func TestDecodeWIFNet(t *testing.T) {
+	tests := []struct {
+		encoded string
+		net     *chaincfg.Params
+	}{
+		{
+			"5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTJ",
+			&chaincfg.MainNetParams,
+		},
+		{
+			"cV1Y7ARUr9Yx7BR55nTdnR7ZXNJphZtCCMBTEZBJe1hXt2kB684q",
+			&chaincfg.TestNet3Params,
+		},
+	}
+
+	for _, test := range tests {
+		w, err := DecodeWIF(test.encoded)
+		if err != nil {
+			t.Errorf("DecodeWIF %s: unexpected error: %v", test.encoded, err)
+			continue
+		}
+		if net := w.Net(); net != test.net {
+			t.Errorf("DecodeWIF %s: wrong network - got %v, want %v",
+				test.encoded, net.Name, test.net.Name)
+		}
+		if !w.IsForNet(test.net) {
+			t.Errorf("DecodeWIF %s: IsForNet(%s) returned false",
+				test.encoded, test.net.Name)
+		}
+	}
+
+	// Simnet keys round trip and report their network.
+	priv, _ := btcec.NewPrivateKey(btcec.S256())
+	simWIF, err := NewWIF(priv, &chaincfg.SimNetParams, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := DecodeWIF(simWIF.String())
+	if err != nil {
+		t.Fatalf("DecodeWIF simnet: unexpected error: %v", err)
+	}
+	if w.Net() != &chaincfg.SimNetParams {
+		t.Errorf("DecodeWIF simnet: wrong network - got %v", w.Net())
+	}
+
+	// A key with a network identifier byte that no known network uses
+	// must be rejected.
+	unknown := base58.CheckEncode(priv.Serialize(), 0x01)
+	if _, err := DecodeWIF(unknown); err != ErrUnknownNet {
+		t.Errorf("DecodeWIF unknown network: got error %v, want %v", err,
+			ErrUnknownNet)
+	}
+	unknownNet := chaincfg.MainNetParams
+	unknownNet.PrivateKeyID = 0x01
+	unknownWIF, err := NewWIF(priv, &unknownNet, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if net := unknownWIF.Net(); net != nil {
+		t.Errorf("Net: got %v for unknown network, want nil", net.Name)
+	}
+}
+
+// TestRegisterWIFNet ensures private keys for a network added by
+// RegisterWIFNet can be decoded, and that networks sharing an identifier
+// with a known network are rejected.
+func TestRegisterWIFNet(t *testing.T) {
+	defer TstRestoreWIFNets()()
+
+	customNet := chaincfg.MainNetParams
+	customNet.Name = "customwifnet"
+	customNet.PrivateKeyID = 0x02
+
+	priv, _ := btcec.NewPrivateKey(btcec.S256())
+	customWIF, err := NewWIF(priv, &customNet, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeWIF(customWIF.String()); err != ErrUnknownNet {
+		t.Errorf("DecodeWIF before registration: got error %v, want %v",
+			err, ErrUnknownNet)
+	}
+
+	if err := RegisterWIFNet(&customNet); err != nil {
+		t.Fatalf("RegisterWIFNet: unexpected error: %v", err)
+	}
+	w, err := DecodeWIF(customWIF.String())
+	if err != nil {
+		t.Fatalf("DecodeWIF: unexpected error: %v", err)
+	}
+	if w.Net() != &customNet {
+		t.Errorf("Net: got %v, want %v", w.Net(), customNet.Name)
+	}
+	if !w.IsForNet(&customNet) || !w.PrivKey.ToECDSA().Equal(priv.ToECDSA()) {
+		t.Errorf("DecodeWIF: decoded key does not match")
+	}
+
+	for _, net := range []*chaincfg.Params{&customNet, &chaincfg.RegressionNetParams} {
+		if err := RegisterWIFNet(net); err != chaincfg.ErrDuplicateNet {
+			t.Errorf("RegisterWIFNet %s: got error %v, want %v",
+				net.Name, err, chaincfg.ErrDuplicateNet)
+		}
+	}
+	if err := RegisterWIFNet(nil); err != ErrNoNetwork {
+		t.Errorf("RegisterWIFNet nil: got error %v, want %v", err,
+			ErrNoNetwork)
+	}
+}
+
+// errReader is an io.Reader which always fails with err.
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+func TestDecodeWIFs(t *testing.T) {
+	const (
+		mainKey = "5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTJ"
+		testKey = "cV1Y7ARUr9Yx7BR55nTdnR7ZXNJphZtCCMBTEZBJe1hXt2kB684q"
+	)
+	input := "# recovered keys\n" +
+		mainKey + "\n" +
+		"\n" +
+		"  notakey  \n" +
+		"\t# indented comment\n" +
+		"  " + testKey + " \r\n" +
+		mainKey[:len(mainKey)-1] + "K\n" +
+		testKey
+
+	tests := []struct {
+		key  string
+		line int
+		err  error
+	}{
+		{mainKey, 2, nil},
+		{"", 4, ErrMalformedPrivateKey},
+		{testKey, 6, nil},
+		{"", 7, ErrChecksumMismatch},
+		{testKey, 8, nil},
+	}
+
+	wifs, errs := DecodeWIFs(strings.NewReader(input))
+	if len(wifs) != len(tests) || len(errs) != len(tests) {
+		t.Fatalf("DecodeWIFs: got %d keys and %d errors, want %d of each",
+			len(wifs), len(errs), len(tests))
+	}
+	for i, test := range tests {
+		if test.err == nil {
+			if errs[i] != nil {
+				t.Errorf("DecodeWIFs #%d: unexpected error: %v", i, errs[i])
+				continue
+			}
+			if got := wifs[i].String(); got != test.key {
+				t.Errorf("DecodeWIFs #%d: got key %s, want %s", i, got,
+					test.key)
+			}
+			continue
+		}
+
+		if wifs[i] != nil {
+			t.Errorf("DecodeWIFs #%d: unexpected key %v", i, wifs[i])
+		}
+		lineErr, ok := errs[i].(*WIFLineError)
+		if !ok {
+			t.Errorf("DecodeWIFs #%d: got error %v (%T), want *WIFLineError",
+				i, errs[i], errs[i])
+			continue
+		}
+		if lineErr.Line != test.line || lineErr.Err != test.err {
+			t.Errorf("DecodeWIFs #%d: got error on line %d: %v, want "+
+				"line %d: %v", i, lineErr.Line, lineErr.Err, test.line,
+				test.err)
+		}
+		if !errors.Is(errs[i], test.err) {
+			t.Errorf("DecodeWIFs #%d: error %v does not wrap %v", i,
+				errs[i], test.err)
+		}
+	}
+
+	// Input without any keys decodes to nothing.
+	wifs, errs = DecodeWIFs(strings.NewReader("\n# no keys\n\n"))
+	if len(wifs) != 0 || len(errs) != 0 {
+		t.Errorf("DecodeWIFs: got %d keys and %d errors for input "+
+			"without keys", len(wifs), len(errs))
+	}
+
+	// A read error is returned as the final entry.
+	errRead := errors.New("read failed")
+	wifs, errs = DecodeWIFs(io.MultiReader(strings.NewReader(mainKey+"\n"),
+		errReader{errRead}))
+	if len(wifs) != 2 || len(errs) != 2 || errs[0] != nil ||
+		wifs[1] != nil || errs[1] != errRead {
+
+		t.Errorf("DecodeWIFs: got keys %v and errors %v, want one key "+
+			"followed by %v", wifs, errs, errRead)
+	}
+}